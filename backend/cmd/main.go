@@ -2,21 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/api"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/blockchain"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/cache"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/crossrate"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/fetcher"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/metrics"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/normalizer"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/publisher"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/scheduler"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/storage"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/utils"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/validation"
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
 )
 
 func main() {
@@ -44,33 +55,132 @@ func main() {
 		log.Fatalf("Failed to initialize publisher: %v", err)
 	}
 	defer publisher.Close()
-
-	// Initialize real blockchain client
-	blockchainConfig := &blockchain.Config{
-		RPCURL:       config.BlockchainRPCURL,
-		ContractAddr: config.OracleContractAddr,
-		PrivateKey:   config.BlockchainPrivateKey,
-		GasLimit:     100000,
+	publisher.SetSubjectPrefix(config.NATSSubjectPrefix)
+	if err := publisher.SetEncoding(wire.Encoding(config.NATSEncoding)); err != nil {
+		log.Fatalf("Failed to set the NATS encoding: %v", err)
 	}
-	blockchainClient, err := blockchain.NewRealClient(blockchainConfig)
-	if err != nil {
-		log.Fatalf("Failed to initialize blockchain client: %v", err)
+	if config.SigningKey != "" {
+		signingKey, err := wire.ParsePrivateKey(config.SigningKey)
+		if err != nil {
+			log.Fatalf("Failed to load the signing key: %v", err)
+		}
+		publisher.SetSigningKey(signingKey)
+		log.Printf("Signing price messages with public key %x", signingKey.Public())
+	} else {
+		log.Printf("Warning: SIGNING_KEY is not set, price messages are published unsigned")
+	}
+	if config.NATSJetStream {
+		natsStream := config.NATSStreamConfig()
+		if err := publisher.EnableJetStream(context.Background(), natsStream); err != nil {
+			log.Fatalf("Failed to set up JetStream: %v", err)
+		}
+		log.Printf("Publishing prices to JetStream stream %s on %v", natsStream.Name, natsStream.Subjects)
 	}
-	defer blockchainClient.Close()
 
 	metrics := metrics.NewMetrics()
-	normalizer := normalizer.NewDefaultNormalizer()
-	fetcher := fetcher.NewFetcher(config.CoinGeckoURL, config.FetchTimeout)
+
+	registry := fetcher.NewRegistry()
+	streamConfig := fetcher.StreamConfig{
+		MaxAge:           config.StreamMaxAge,
+		MinBackoff:       config.StreamReconnectMin,
+		MaxBackoff:       config.StreamReconnectMax,
+		HandshakeTimeout: config.StreamHandshakeTimeout,
+	}
+	if err := registry.SetStreamConfig(streamConfig); err != nil {
+		log.Fatalf("Failed to configure streaming sources: %v", err)
+	}
+	registry.SetRPCURL(config.BlockchainRPCURL)
+
+	// Replay a recording instead of the live sources, or record the live sources, when configured
+	builder := &sourceBuilder{
+		registry: registry,
+		rates:    fetcher.NewRateBook(),
+		conversion: fetcher.ConversionConfig{
+			DepegThreshold: config.DepegThreshold,
+			Action:         fetcher.DepegAction(config.DepegAction),
+			MaxRateAge:     config.ConversionMaxRateAge,
+		},
+	}
+	if config.ReplayFile != "" {
+		replayer, err := fetcher.LoadReplayer(config.ReplayFile, config.ReplaySpeed)
+		if err != nil {
+			log.Fatalf("Failed to load replay: %v", err)
+		}
+		builder.replayer = replayer
+		log.Printf("Replaying %s at %gx speed", config.ReplayFile, config.ReplaySpeed)
+	}
+	if config.RecordFile != "" {
+		recorder, err := fetcher.NewRecorder(config.RecordFile)
+		if err != nil {
+			log.Fatalf("Failed to open recording: %v", err)
+		}
+		defer recorder.Close()
+		builder.recorder = recorder
+		log.Printf("Recording quotes to %s", config.RecordFile)
+	}
+
+	// Build one pipeline per configured pair
+	var pipelines []*pairPipeline
+	var fetchers []*fetcher.Fetcher
+	for _, pairConfig := range config.Pairs {
+		pipeline, err := newPairPipeline(pairConfig, config, builder)
+		if err != nil {
+			log.Fatalf("Failed to initialize pipeline for %s: %v", pairConfig.Pair, err)
+		}
+		defer pipeline.close()
+
+		if pipeline.blockchainClient == nil {
+			log.Printf("No oracle contract configured for %s, on-chain updates are disabled for it", pairConfig.Pair)
+		}
+		pipelines = append(pipelines, pipeline)
+		fetchers = append(fetchers, pipeline.fetcher)
+	}
+
+	// Build one pipeline per derived pair on top of the served pairs
+	var derivedPipelines []*derivedPipeline
+	var derivedPairs []pair.Pair
+	for _, derivedConfig := range config.DerivedPairs {
+		derived, err := newDerivedPipeline(derivedConfig, config, pipelines)
+		if err != nil {
+			log.Fatalf("Failed to initialize derived pair %s: %v", derivedConfig.Pair, err)
+		}
+		defer derived.close()
+
+		log.Printf("Deriving %s", derived.derivation)
+		derivedPipelines = append(derivedPipelines, derived)
+		derivedPairs = append(derivedPairs, derived.pair())
+	}
+
+	// Adapt the fetch interval to realised volatility when enabled
+	var scheduler *scheduler.Scheduler
+	if config.AdaptiveInterval {
+		adaptive, err := newScheduler(config)
+		if err != nil {
+			log.Fatalf("Failed to initialize adaptive interval: %v", err)
+		}
+		scheduler = adaptive
+	}
 
 	// Initialize API
-	api := api.NewAPI(cache, storage, metrics)
+	api := api.NewAPI(cache, storage, metrics, fetchers)
+	api.SetDerivedPairs(derivedPairs)
+	api.SetValidators(validators(pipelines, derivedPipelines))
 
 	// Start the price fetcher service
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Connect streaming sources so they have data by the first tick
+	for _, pipeline := range pipelines {
+		pipeline.fetcher.Start(ctx)
+	}
+
 	// Start price fetching goroutine
-	go startPriceFetcher(ctx, fetcher, normalizer, cache, storage, publisher, metrics, config, blockchainClient)
+	fetcherDone := make(chan struct{})
+	go func() {
+		defer close(fetcherDone)
+		startPriceFetcher(ctx, pipelines, derivedPipelines, scheduler, cache, storage, publisher, metrics, config)
+	}()
 
 	// Start HTTP server
 	go func() {
@@ -88,27 +198,68 @@ func main() {
 	log.Println("Shutting down server...")
 	cancel()
 
-	// Give services time to shut down gracefully
-	time.Sleep(2 * time.Second)
+	// Cancelling ctx aborts any in-flight tick, so wait for it to drain; a tick can
+	// never outlive its interval, which bounds the wait
+	select {
+	case <-fetcherDone:
+	case <-time.After(config.LongestFetchInterval()):
+		log.Println("Timed out waiting for price fetcher to stop")
+	}
 	log.Println("Server stopped")
 }
 
+// priceSourceLabel is the source recorded for failures that cannot be attributed to a single source
+const priceSourceLabel = "aggregate"
+
+// derivedSourceLabel is the source recorded for failures to derive a cross rate
+const derivedSourceLabel = "derived"
+
+// stageDeadlines splits one fetch interval into per-stage time budgets so a tick never spills into the next
+type stageDeadlines struct {
+	fetch   time.Duration
+	cache   time.Duration
+	storage time.Duration
+}
+
+// newStageDeadlines derives stage budgets from the fetch interval
+// The blockchain update gets whatever is left of the interval once the other stages are done
+func newStageDeadlines(interval, fetchTimeout time.Duration) stageDeadlines {
+	fetch := interval * 4 / 10
+	if fetchTimeout > 0 && fetchTimeout < fetch {
+		fetch = fetchTimeout
+	}
+
+	return stageDeadlines{
+		fetch:   fetch,
+		cache:   interval / 20,
+		storage: interval / 10,
+	}
+}
+
 // startPriceFetcher runs the price fetching service
+// Every tick processes all pairs concurrently so a slow pair cannot delay the others,
+// then derives the cross rates from the prices of that tick
+// With a scheduler the interval is adapted to realised volatility after every tick; without one it is fixed
 func startPriceFetcher(
 	ctx context.Context,
-	fetcher *fetcher.Fetcher,
-	normalizer *normalizer.Normalizer,
+	pipelines []*pairPipeline,
+	derivedPipelines []*derivedPipeline,
+	scheduler *scheduler.Scheduler,
 	cache *cache.Cache,
 	storage *storage.Storage,
 	publisher *publisher.Publisher,
 	metrics *metrics.Metrics,
 	config *utils.Config,
-	blockchainClient *blockchain.RealClient,
 ) {
-	ticker := time.NewTicker(config.FetchInterval)
+	interval := config.FetchInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	metrics.RecordFetchInterval(interval, "", false)
+
+	log.Printf("Starting price fetcher for %d pair(s) and %d derived pair(s) with interval %v",
+		len(pipelines), len(derivedPipelines), interval)
 
-	log.Printf("Starting price fetcher with interval %v", config.FetchInterval)
+	deadlines := newStageDeadlines(interval, config.FetchTimeout)
 
 	for {
 		select {
@@ -116,89 +267,715 @@ func startPriceFetcher(
 			log.Println("Price fetcher stopped")
 			return
 		case <-ticker.C:
-			fetchAndProcessPrice(fetcher, normalizer, cache, storage, publisher, metrics, config, blockchainClient)
+			tickCtx, cancel := context.WithTimeout(ctx, interval)
+			var wg sync.WaitGroup
+			for _, pipeline := range pipelines {
+				wg.Add(1)
+				go func(pipeline *pairPipeline) {
+					defer wg.Done()
+					fetchAndProcessPrice(tickCtx, deadlines, pipeline, cache, storage, publisher, metrics)
+				}(pipeline)
+			}
+			wg.Wait()
+
+			for _, derived := range derivedPipelines {
+				wg.Add(1)
+				go func(derived *derivedPipeline) {
+					defer wg.Done()
+					deriveAndProcessPrice(tickCtx, deadlines, derived, cache, storage, publisher, metrics)
+				}(derived)
+			}
+			wg.Wait()
+			cancel()
+
+			if scheduler == nil {
+				continue
+			}
+			if next := adjustInterval(scheduler, pipelines, storage, metrics, config.VolatilityWindow); next != interval {
+				interval = next
+				ticker.Reset(interval)
+				deadlines = newStageDeadlines(interval, config.FetchTimeout)
+			}
+		}
+	}
+}
+
+// newScheduler creates the adaptive scheduler configured for the fetch interval
+func newScheduler(config *utils.Config) (*scheduler.Scheduler, error) {
+	return scheduler.NewScheduler(scheduler.Config{
+		Base:           config.FetchInterval,
+		Min:            config.MinFetchInterval,
+		Max:            config.MaxFetchInterval,
+		HighVolatility: config.HighVolatility,
+		LowVolatility:  config.LowVolatility,
+	})
+}
+
+// adjustInterval measures the realised volatility of the recent stored prices of every served pair and
+// lets the most volatile pair set the next interval
+// Derived pairs are left out, since they only move when their legs do
+func adjustInterval(
+	scheduler *scheduler.Scheduler,
+	pipelines []*pairPipeline,
+	storage *storage.Storage,
+	metrics *metrics.Metrics,
+	window int,
+) time.Duration {
+	var highest float64
+	var measured bool
+	for _, pipeline := range pipelines {
+		records, err := storage.GetPriceHistoryForPair(pipeline.pair(), window)
+		if err != nil {
+			metrics.RecordDBError("select", "price_records", "query_failed")
+			log.Printf("Failed to load %s price history for volatility: %v", pipeline.label(), err)
+			continue
+		}
+
+		volatility, ok := realisedVolatility(records)
+		if !ok {
+			continue
 		}
+		metrics.RecordRealisedVolatility(pipeline.label(), volatility)
+		if !measured || volatility > highest {
+			highest = volatility
+		}
+		measured = true
+	}
+
+	interval, reason, changed := scheduler.Adjust(highest, measured)
+	metrics.RecordFetchInterval(interval, string(reason), changed)
+	if changed {
+		log.Printf("Fetch interval changed to %v (%s, realised volatility %.6f)", interval, reason, highest)
 	}
+	return interval
 }
 
-// fetchAndProcessPrice fetches a price and processes it through the pipeline
+// realisedVolatility returns the realised volatility of stored price records
+func realisedVolatility(records []storage.PriceRecord) (float64, bool) {
+	observations := make([]scheduler.Observation, len(records))
+	for i, record := range records {
+		observations[i] = scheduler.Observation{Price: record.Price, Time: record.Timestamp}
+	}
+	return scheduler.RealisedVolatility(observations)
+}
+
+// fetchAndProcessPrice fetches the price of one pair and processes it through the pipeline
+// Every stage runs under its own deadline derived from ctx, which expires at the end of the tick
 func fetchAndProcessPrice(
-	fetcher *fetcher.Fetcher,
-	normalizer *normalizer.Normalizer,
+	ctx context.Context,
+	deadlines stageDeadlines,
+	pipeline *pairPipeline,
 	cache *cache.Cache,
 	storage *storage.Storage,
 	publisher *publisher.Publisher,
 	metrics *metrics.Metrics,
-	config *utils.Config,
-	blockchainClient *blockchain.RealClient,
 ) {
+	priceFetcher := pipeline.fetcher
+	normalizer := pipeline.normalizer
+	p := pipeline.pair()
+	pairLabel := pipeline.label()
+
 	start := time.Now()
+	defer recordSourceHealth(metrics, pairLabel, priceFetcher)
+
+	// Fetch quotes from all configured sources
+	fetchCtx, cancelFetch := context.WithTimeout(ctx, deadlines.fetch)
+	report, err := priceFetcher.FetchQuotes(fetchCtx)
+	cancelFetch()
+	if report != nil {
+		recordSourceResults(metrics, pairLabel, report)
+	}
 
-	// Fetch price
-	price, err := fetcher.FetchETHUSDPrice()
+	// Archive what every source returned, linked to the price when the round produces one
+	responses := rawResponses(pipeline, report, start)
+	linked := false
+	defer func() {
+		if !linked {
+			archiveResponses(ctx, deadlines, storage, responses, metrics)
+		}
+	}()
 	if err != nil {
-		metrics.RecordFetchError("coingecko", "fetch_failed")
-		log.Printf("Failed to fetch price: %v", err)
+		var quorumErr *fetcher.QuorumError
+		if errors.As(err, &quorumErr) {
+			// Too few sources answered to trust an aggregate, so skip the whole round
+			metrics.RecordQuorumFailure(pairLabel)
+		} else {
+			metrics.RecordFetchError(pairLabel, priceSourceLabel, "fetch_failed")
+		}
+		log.Printf("Failed to fetch %s price: %v", pairLabel, err)
 		return
 	}
 
-	// Validate price
-	if err := normalizer.ValidatePrice(price); err != nil {
-		metrics.RecordFetchError("coingecko", "validation_failed")
-		log.Printf("Price validation failed: %v", err)
+	// Aggregate the quotes into a single price, discarding outliers
+	aggregate, err := priceFetcher.Aggregate(report.Quotes())
+	if err != nil {
+		metrics.RecordFetchError(pairLabel, priceSourceLabel, "aggregation_failed")
+		log.Printf("Failed to aggregate %s prices: %v", pairLabel, err)
 		return
 	}
+	markDiscarded(responses, aggregate.Discarded)
+	for _, quote := range aggregate.Quotes {
+		if quote.Depegged {
+			metrics.RecordDepeggedQuote(pairLabel, quote.Source, quote.QuoteAsset)
+			log.Printf("Using %s quote from %s converted from depegged %s at %.6f", pairLabel, quote.Source, quote.QuoteAsset, quote.ConversionRate)
+		}
+	}
+	for _, discarded := range aggregate.Discarded {
+		metrics.RecordSourceDiscarded(pairLabel, discarded.Quote.Source, discarded.Reason)
+		log.Printf("Discarded %s quote from %s (%s): %s", pairLabel, discarded.Quote.Source, discarded.Reason, discarded.Detail)
+	}
+	metrics.RecordAggregate(pairLabel, aggregate.Sources, aggregate.SpreadRatio)
 
-	// Normalize price
-	normalizedPrice := normalizer.NormalizePrice(price)
-	timestamp := time.Now()
+	price := aggregate.Price
+	source := sourceLabel(aggregate.Quotes)
+
+	// Hold large moves until consecutive fetches or enough sources confirm them; this runs before validation,
+	// since the last-price rule would otherwise reject every move the guard is waiting to confirm
+	confirmed := false
+	if pipeline.jumpGuard != nil {
+		var publish bool
+		publish, confirmed = checkJump(ctx, deadlines, pipeline, price, aggregate.Quotes, storage, metrics)
+		if !publish {
+			return
+		}
+	}
+
+	// Validate price against the pair's rules; a confirmed move is not judged by the last delivered price
+	input := validation.Input{Price: price, Sources: aggregate.Sources, Time: pipeline.now(), Confirmed: confirmed}
+	if !validatePrice(pipeline.validator, p, source, input, storage, metrics) {
+		return
+	}
+
+	// Withhold prices that stray too far from the reference feed
+	if pipeline.referenceGuard != nil && !checkReference(ctx, deadlines, pipeline, price, metrics) {
+		return
+	}
+
+	// Normalize price; the fixed-point price is what gets pushed on-chain
+	fixedPrice := normalizer.NormalizeExact(aggregate.ExactPrice())
+	normalizedPrice := fixedPrice.Float64()
+	confidence := fetcher.ConfidenceBand{
+		Lower:    normalizer.NormalizePrice(aggregate.Confidence.Lower),
+		Upper:    normalizer.NormalizePrice(aggregate.Confidence.Upper),
+		StdError: aggregate.Confidence.StdError,
+	}
+	timestamp := pipeline.now()
+	observedAt := oldestQuoteTime(aggregate.Quotes, timestamp)
 
 	// Record success metrics
-	metrics.RecordFetchSuccess("coingecko")
-	metrics.RecordFetchLatency(time.Since(start), "coingecko", "success")
+	metrics.RecordFetchLatency(time.Since(start), pairLabel, source, "success")
 
-	// Get last price for comparison
-	lastPriceData, err := cache.GetCachedPrice()
-	var lastPrice float64
-	if err == nil {
-		lastPrice = lastPriceData.Price
+	// Stablecoin prices convert the quotes of sources that quote in the stablecoin from the next tick on
+	if pipeline.rates != nil {
+		pipeline.rates.Set(p, normalizedPrice, observedAt)
+		metrics.RecordPegDeviation(pairLabel, math.Abs(normalizedPrice-1))
 	}
 
-	// Cache the price
-	if err := cache.CachePrice(normalizedPrice, timestamp, "coingecko"); err != nil {
-		metrics.RecordCacheError("redis", "set")
-		log.Printf("Failed to cache price: %v", err)
+	// Keep the price as a leg for derived pairs, dated by the oldest quote behind it
+	pipeline.setLatest(crossrate.Leg{
+		Pair:        p,
+		Price:       normalizedPrice,
+		Timestamp:   observedAt,
+		SpreadRatio: aggregate.SpreadRatio,
+		Sources:     aggregate.Sources,
+		Lower:       confidence.Lower,
+		Upper:       confidence.Upper,
+		Exact:       fixedPrice,
+	})
+
+	linked = true
+	recordID, delivered := deliverPrice(ctx, deadlines, priceUpdate{
+		pair:             p,
+		price:            fixedPrice,
+		timestamp:        timestamp,
+		observedAt:       observedAt,
+		confidence:       confidence,
+		source:           source,
+		threshold:        pipeline.config.PriceChangeThreshold,
+		blockchainClient: pipeline.blockchainClient,
+		responses:        responses,
+	}, cache, storage, publisher, metrics)
+	pipeline.setLatestRecordID(recordID)
+
+	// Only a price that got past every guard and out of the pipeline anchors the last-price rule
+	if delivered {
+		pipeline.validator.Deliver(normalizedPrice, timestamp)
+	}
+}
+
+// validatePrice checks a candidate price against the pair's validation rules and reports
+// whether it was accepted
+// The TWAP is read from storage only when a rule needs it; without stored prices the TWAP rule is skipped
+func validatePrice(
+	validator *validation.Engine,
+	p pair.Pair,
+	source string,
+	input validation.Input,
+	storage *storage.Storage,
+	metrics *metrics.Metrics,
+) bool {
+	if window := validator.TWAPWindow(); window > 0 {
+		if twap, err := storage.CalculateTWAPForPair(p, window); err == nil {
+			input.TWAP = twap
+		}
+	}
+
+	rejection := validator.Validate(input)
+	if rejection == nil {
+		return true
+	}
+
+	pairLabel := p.String()
+	metrics.RecordValidationRejection(pairLabel, string(rejection.Reason))
+	metrics.RecordFetchError(pairLabel, source, "validation_failed")
+	log.Printf("%s price validation failed (%s): %v", pairLabel, rejection.Reason, rejection)
+	return false
+}
+
+// checkJump passes a price through the pair's jump guard and reports whether it may be published,
+// and whether it is a large move the guard confirmed
+// Every hold, confirmation and discarded hold is counted and stored
+func checkJump(
+	ctx context.Context,
+	deadlines stageDeadlines,
+	pipeline *pairPipeline,
+	price float64,
+	quotes []fetcher.Quote,
+	storage *storage.Storage,
+	metrics *metrics.Metrics,
+) (bool, bool) {
+	pairLabel := pipeline.label()
+
+	sourcePrices := make([]float64, len(quotes))
+	for i, quote := range quotes {
+		sourcePrices[i] = quote.Price
+	}
+	publish, events := pipeline.jumpGuard.Check(price, sourcePrices)
+	if len(events) == 0 {
+		return publish, false
+	}
+
+	confirmed := false
+	for _, event := range events {
+		confirmed = confirmed || event.Kind == normalizer.JumpConfirmed
+		metrics.RecordJumpEvent(pairLabel, string(event.Kind))
+		log.Printf("%s price move of %.2f%% to %.8g %s after %d fetches and %d sources",
+			pairLabel, event.Change*100, event.Price, event.Kind, event.Fetches, event.Sources)
+	}
+
+	storageCtx, cancelStorage := context.WithTimeout(ctx, deadlines.storage)
+	defer cancelStorage()
+	if err := storage.SaveJumpEvents(storageCtx, jumpEventRecords(pairLabel, pipeline.now(), events)); err != nil {
+		metrics.RecordDBError("insert", "jump_events", "save_failed")
+		log.Printf("Failed to save %s jump events: %v", pairLabel, err)
 	} else {
-		metrics.RecordCacheHit("redis")
+		metrics.RecordDBOperation("insert", "jump_events")
 	}
+	return publish, confirmed
+}
 
-	// Store in database
-	if err := storage.SavePrice(normalizedPrice, timestamp, "coingecko"); err != nil {
+// jumpEventRecords converts the jump guard events of a pair to their database records
+func jumpEventRecords(pairLabel string, timestamp time.Time, events []normalizer.JumpEvent) []storage.JumpEvent {
+	records := make([]storage.JumpEvent, len(events))
+	for i, event := range events {
+		records[i] = storage.JumpEvent{
+			Pair:           pairLabel,
+			Kind:           string(event.Kind),
+			Price:          event.Price,
+			Timestamp:      timestamp,
+			ReferencePrice: event.Reference,
+			Change:         event.Change,
+			Fetches:        event.Fetches,
+			Sources:        event.Sources,
+			ConfirmedBy:    event.ConfirmedBy,
+		}
+	}
+	return records
+}
+
+// checkReference compares a price with the pair's reference feed and reports whether it may be published
+// A reference that cannot be read does not hold the price back, since the feed may lag or be down
+func checkReference(ctx context.Context, deadlines stageDeadlines, pipeline *pairPipeline, price float64, metrics *metrics.Metrics) bool {
+	pairLabel := pipeline.label()
+	guard := pipeline.referenceGuard
+
+	referenceCtx, cancel := context.WithTimeout(ctx, deadlines.fetch)
+	check, err := guard.Check(referenceCtx, price)
+	cancel()
+
+	var deviationErr *fetcher.ReferenceDeviationError
+	switch {
+	case errors.As(err, &deviationErr):
+		metrics.RecordReferenceCheck(pairLabel, guard.Name(), check.Deviation, true)
+		log.Printf("Withholding %s price: %v", pairLabel, err)
+		return false
+	case err != nil:
+		class, _ := fetcher.ClassifyError(err)
+		metrics.RecordFetchError(pairLabel, guard.Name(), string(class))
+		log.Printf("Skipping %s reference check: %v", pairLabel, err)
+		return true
+	}
+
+	metrics.RecordReferenceCheck(pairLabel, guard.Name(), check.Deviation, false)
+	return true
+}
+
+// deriveAndProcessPrice derives the price of a cross rate from the latest prices of its legs
+// and processes it through the pipeline like a fetched price
+func deriveAndProcessPrice(
+	ctx context.Context,
+	deadlines stageDeadlines,
+	derived *derivedPipeline,
+	cache *cache.Cache,
+	storage *storage.Storage,
+	publisher *publisher.Publisher,
+	metrics *metrics.Metrics,
+) {
+	pairLabel := derived.label()
+
+	legs := derived.latestLegs()
+	result, err := derived.derivation.Derive(legs, derived.now(), derived.config.MaxLegAge)
+	if err != nil {
+		var staleErr *crossrate.StaleLegError
+		if errors.As(err, &staleErr) {
+			metrics.RecordFetchError(pairLabel, derivedSourceLabel, "stale_leg")
+		} else {
+			metrics.RecordFetchError(pairLabel, derivedSourceLabel, "derivation_failed")
+		}
+		log.Printf("Failed to derive %s price: %v", pairLabel, err)
+		return
+	}
+	metrics.RecordAggregate(pairLabel, result.Sources, result.SpreadRatio)
+
+	source := derived.derivation.Source()
+
+	// Validate price against the pair's rules
+	timestamp := derived.now()
+	input := validation.Input{Price: result.Price, Sources: result.Sources, Time: timestamp}
+	if !validatePrice(derived.validator, derived.pair(), source, input, storage, metrics) {
+		return
+	}
+
+	// The band of a derived price is where it lands when every leg moves within its own band
+	confidence := fetcher.ConfidenceBand{
+		Lower: derived.normalizer.NormalizePrice(result.Lower),
+		Upper: derived.normalizer.NormalizePrice(result.Upper),
+	}
+
+	// The cross rate is rounded from the exact leg prices, not from its float
+	fixedPrice := derived.normalizer.NormalizeRat(result.Exact)
+	_, delivered := deliverPrice(ctx, deadlines, priceUpdate{
+		pair:             derived.pair(),
+		price:            fixedPrice,
+		timestamp:        timestamp,
+		observedAt:       result.Timestamp,
+		confidence:       confidence,
+		source:           source,
+		derived:          true,
+		threshold:        derived.config.PriceChangeThreshold,
+		blockchainClient: derived.blockchainClient,
+		legs:             derivationLegs(legs, derived.latestLegRecordIDs()),
+	}, cache, storage, publisher, metrics)
+	if delivered {
+		derived.validator.Deliver(fixedPrice.Float64(), timestamp)
+	}
+}
+
+// priceUpdate is a validated, normalized price ready to be cached, stored, published and pushed on-chain
+type priceUpdate struct {
+	pair  pair.Pair
+	price fixedpoint.Price
+	// timestamp is when the price was fetched or derived, observedAt when its oldest input was observed
+	timestamp  time.Time
+	observedAt time.Time
+	// confidence bounds the price; derived prices have no standard error of their own
+	confidence fetcher.ConfidenceBand
+	source     string
+	// derived marks cross rates computed from other pairs
+	derived bool
+	// threshold is the relative move needed before the price is published
+	threshold float64
+	// blockchainClient is nil when the pair has no oracle contract
+	blockchainClient *blockchain.RealClient
+	// responses and legs are the archived inputs the price was computed from
+	responses []storage.RawResponse
+	legs      []storage.DerivationLeg
+}
+
+// deliverPrice caches, stores and publishes a price, then pushes it on-chain with the rest of the tick's budget
+// It returns the ID of the stored price record, or zero if it could not be stored, and whether the price
+// reached storage, the cache or NATS
+func deliverPrice(
+	ctx context.Context,
+	deadlines stageDeadlines,
+	update priceUpdate,
+	cache *cache.Cache,
+	storage *storage.Storage,
+	publisher *publisher.Publisher,
+	metrics *metrics.Metrics,
+) (uint, bool) {
+	p := update.pair
+	pairLabel := p.String()
+
+	// Store in database first, so the cached and published price can refer to its record
+	storageCtx, cancelStorage := context.WithTimeout(ctx, deadlines.storage)
+	record := priceRecord(update)
+	err := storage.SavePriceRecordWithInputs(storageCtx, record, update.responses, update.legs)
+	cancelStorage()
+	if err != nil {
+		// The transaction was rolled back, so the ID the record was given does not exist
+		record.ID = 0
 		metrics.RecordDBError("insert", "price_records", "save_failed")
 		log.Printf("Failed to save price to database: %v", err)
 	} else {
 		metrics.RecordDBOperation("insert", "price_records")
 	}
+	delivered := err == nil
+
+	// Get last price for comparison, then cache the new one
+	cacheCtx, cancelCache := context.WithTimeout(ctx, deadlines.cache)
+	lastPriceData, err := cache.GetCachedPriceForPair(cacheCtx, p)
+	var lastPrice float64
+	if err == nil {
+		lastPrice = lastPriceData.Price
+	}
+
+	priceData := cachePriceData(update, record.ID)
+	if err := cache.CachePriceData(cacheCtx, p, priceData); err != nil {
+		metrics.RecordCacheError("redis", "set")
+		log.Printf("Failed to cache price: %v", err)
+	} else {
+		metrics.RecordCacheHit("redis")
+		delivered = true
+	}
+	cancelCache()
 
 	// Publish to NATS with filtering
-	if err := publisher.PublishPriceWithFilter(normalizedPrice, timestamp, "coingecko", lastPrice, config.PriceChangeThreshold); err != nil {
+	if err := publisher.PublishMessageWithFilter(ctx, p, priceMessage(update, record.ID), lastPrice, update.threshold); err != nil {
 		metrics.RecordNATSError("publish")
-		log.Printf("Failed to publish price: %v", err)
+		log.Printf("Failed to publish %s price: %v", pairLabel, err)
 	} else {
-		metrics.RecordNATSPublished(config.NATSSubject)
-		metrics.RecordPriceUpdate("coingecko", "published")
+		metrics.RecordNATSPublished(publisher.SubjectForPair(p))
+		metrics.RecordPriceUpdate(pairLabel, update.source, "published")
+		delivered = true
 	}
 
-	// Update blockchain Oracle contract
-	if err := blockchainClient.UpdateOraclePrice(normalizedPrice); err != nil {
-		log.Printf("Failed to update blockchain Oracle: %v", err)
-		// Don't fail the entire process if blockchain update fails
-	} else {
-		log.Printf("Successfully updated blockchain Oracle with price: $%.2f", normalizedPrice)
+	// Update blockchain Oracle contract with the rest of the tick's budget
+	if update.blockchainClient != nil {
+		if err := update.blockchainClient.UpdateOraclePriceWithContext(ctx, update.price); err != nil {
+			log.Printf("Failed to update blockchain Oracle for %s: %v", pairLabel, err)
+			// Don't fail the entire process if blockchain update fails
+		} else {
+			log.Printf("Successfully updated blockchain Oracle for %s with price: %s", pairLabel, update.price)
+		}
 	}
 
-	// Update price age metric
-	metrics.RecordPriceAge(0, "coingecko") // Just updated, so age is 0
+	// Update price age metric; derived prices are as old as their oldest leg
+	metrics.RecordPriceAge(time.Since(update.observedAt), pairLabel, update.source)
+
+	log.Printf("Successfully processed %s price: %s", pairLabel, update.price)
+	return record.ID, delivered
+}
+
+// cachePriceData converts a price update stored as recordID to its cached form
+func cachePriceData(update priceUpdate, recordID uint) cache.PriceData {
+	return cache.PriceData{
+		RecordID:   recordID,
+		Price:      update.price.Float64(),
+		Timestamp:  update.timestamp,
+		Source:     update.source,
+		Derived:    update.derived,
+		ObservedAt: update.observedAt,
 
-	log.Printf("Successfully processed price: $%.2f", normalizedPrice)
+		ConfidenceLower: update.confidence.Lower,
+		ConfidenceUpper: update.confidence.Upper,
+		StdError:        update.confidence.StdError,
+	}
+}
+
+// priceRecord converts a price update to its database record
+func priceRecord(update priceUpdate) *storage.PriceRecord {
+	observedAt := update.observedAt
+	lower, upper, stdError := update.confidence.Lower, update.confidence.Upper, update.confidence.StdError
+	record := &storage.PriceRecord{
+		Pair:            update.pair.String(),
+		Price:           update.price.Float64(),
+		Timestamp:       update.timestamp,
+		Source:          update.source,
+		Derived:         update.derived,
+		ObservedAt:      &observedAt,
+		ConfidenceLower: &lower,
+		ConfidenceUpper: &upper,
+	}
+	if !update.derived {
+		record.StdError = &stdError
+	}
+	return record
+}
+
+// priceMessage converts a price update stored as recordID to its NATS message
+func priceMessage(update priceUpdate, recordID uint) publisher.PriceMessage {
+	return publisher.PriceMessage{
+		RecordID:   recordID,
+		Price:      update.price.Float64(),
+		PriceUnits: update.price.Units().String(),
+		Decimals:   update.price.Decimals(),
+		Timestamp:  update.timestamp,
+		Source:     update.source,
+		Derived:    update.derived,
+		ObservedAt: update.observedAt,
+
+		ConfidenceLower: update.confidence.Lower,
+		ConfidenceUpper: update.confidence.Upper,
+		StdError:        update.confidence.StdError,
+	}
+}
+
+// rawResponses converts the source results of a fetch round to archived responses, or returns nil
+// when the pipeline does not archive them
+// Sources that failed before returning a quote are archived with their error and the round's start time
+func rawResponses(pipeline *pairPipeline, report *fetcher.FetchReport, start time.Time) []storage.RawResponse {
+	if !pipeline.archive || report == nil {
+		return nil
+	}
+
+	responses := make([]storage.RawResponse, 0, len(report.Results))
+	for _, result := range report.Results {
+		response := storage.RawResponse{
+			Pair:       pipeline.label(),
+			Source:     result.Source,
+			FetchedAt:  start,
+			LatencyMS:  float64(result.Latency) / float64(time.Millisecond),
+			StatusCode: result.StatusCode,
+			ErrorClass: string(result.ErrorClass),
+		}
+		if result.Err != nil {
+			response.Error = result.Err.Error()
+		}
+
+		if quote := result.Quote; quote != nil {
+			response.FetchedAt = quote.Timestamp
+			response.Price = quote.Price
+			if !quote.ObservedAt.IsZero() {
+				observedAt := quote.ObservedAt
+				response.ObservedAt = &observedAt
+			}
+			if err := response.SetHeaders(quote.Header); err != nil {
+				log.Printf("Failed to archive headers from %s: %v", result.Source, err)
+			}
+			if err := response.SetBody(quote.Raw); err != nil {
+				log.Printf("Failed to archive response from %s: %v", result.Source, err)
+			}
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// markDiscarded records why aggregation left quotes out of the price
+func markDiscarded(responses []storage.RawResponse, discarded []fetcher.DiscardedQuote) {
+	for _, d := range discarded {
+		for i := range responses {
+			if responses[i].Source == d.Quote.Source {
+				responses[i].Discarded = d.Reason
+			}
+		}
+	}
+}
+
+// archiveResponses stores the responses of a fetch round that produced no price
+func archiveResponses(ctx context.Context, deadlines stageDeadlines, storage *storage.Storage, responses []storage.RawResponse, metrics *metrics.Metrics) {
+	if len(responses) == 0 {
+		return
+	}
+
+	storageCtx, cancel := context.WithTimeout(ctx, deadlines.storage)
+	defer cancel()
+
+	if err := storage.SaveRawResponses(storageCtx, responses); err != nil {
+		metrics.RecordDBError("insert", "raw_responses", "save_failed")
+		log.Printf("Failed to archive raw responses: %v", err)
+		return
+	}
+	metrics.RecordDBOperation("insert", "raw_responses")
+}
+
+// derivationLegs converts the legs of a derived price to their archived form
+func derivationLegs(legs [2]crossrate.Leg, recordIDs [2]uint) []storage.DerivationLeg {
+	archived := make([]storage.DerivationLeg, len(legs))
+	for i, leg := range legs {
+		archived[i] = storage.DerivationLeg{
+			Pair:       leg.Pair.String(),
+			Price:      leg.Price,
+			ObservedAt: leg.Timestamp,
+		}
+		if recordIDs[i] != 0 {
+			recordID := recordIDs[i]
+			archived[i].LegRecordID = &recordID
+		}
+	}
+	return archived
+}
+
+// oldestQuoteTime returns the observation time of the oldest quote, or now if there are no quotes
+func oldestQuoteTime(quotes []fetcher.Quote, now time.Time) time.Time {
+	oldest := now
+	for _, quote := range quotes {
+		if observed := quote.ObservedTime(); !observed.IsZero() && observed.Before(oldest) {
+			oldest = observed
+		}
+	}
+	return oldest
+}
+
+// recordSourceResults records the per-source outcome of a fetch round
+func recordSourceResults(metrics *metrics.Metrics, pairLabel string, report *fetcher.FetchReport) {
+	for _, result := range report.Results {
+		if result.OK() {
+			metrics.RecordFetchSuccess(pairLabel, result.Source)
+			metrics.RecordFetchLatency(result.Latency, pairLabel, result.Source, "success")
+			continue
+		}
+
+		// Sources backing off or with an open circuit were never contacted, so they are not failures
+		if result.Skipped() {
+			metrics.RecordSourceSkipped(pairLabel, result.Source)
+			log.Printf("%s source %s skipped: %v", pairLabel, result.Source, result.Err)
+			continue
+		}
+
+		metrics.RecordFetchError(pairLabel, result.Source, string(result.ErrorClass))
+		metrics.RecordFetchLatency(result.Latency, pairLabel, result.Source, "error")
+		log.Printf("%s source %s failed (%s, status %d) after %v: %v",
+			pairLabel, result.Source, result.ErrorClass, result.StatusCode, result.Latency, result.Err)
+	}
+}
+
+// recordSourceHealth exports the health score and circuit breaker state of every source,
+// plus the connection state of streaming sources
+func recordSourceHealth(metrics *metrics.Metrics, pairLabel string, priceFetcher *fetcher.Fetcher) {
+	for _, status := range priceFetcher.SourceStatuses() {
+		var state float64
+		switch status.State {
+		case fetcher.CircuitHalfOpen:
+			state = 1
+		case fetcher.CircuitOpen:
+			state = 2
+		}
+		metrics.RecordSourceHealth(pairLabel, status.Name, status.HealthScore, state)
+
+		if status.Stream != nil && !status.Stream.UpdatedAt.IsZero() {
+			metrics.RecordStreamStatus(pairLabel, status.Name, status.Stream.Connected, time.Since(status.Stream.UpdatedAt))
+		}
+	}
+}
+
+// sourceLabel returns the sorted, "+"-joined names of the sources that contributed to a price
+func sourceLabel(quotes []fetcher.Quote) string {
+	names := make([]string, len(quotes))
+	for i, quote := range quotes {
+		names[i] = quote.Source
+	}
+	sort.Strings(names)
+	return strings.Join(names, "+")
 }
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/blockchain"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/crossrate"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/fetcher"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/normalizer"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/utils"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/validation"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// pairPipeline holds the per-pair stages of the price pipeline
+type pairPipeline struct {
+	config     utils.PairConfig
+	fetcher    *fetcher.Fetcher
+	normalizer *normalizer.Normalizer
+	validator  *validation.Engine
+	// jumpGuard is nil when large moves are published without confirmation
+	jumpGuard *normalizer.JumpGuard
+	// blockchainClient is nil when the pair has no oracle contract
+	blockchainClient *blockchain.RealClient
+	// referenceGuard is nil when the pair has no reference feed
+	referenceGuard *fetcher.ReferenceGuard
+	// now is the pipeline clock, the replay clock when replaying a recording
+	now func() time.Time
+	// archive enables archiving the raw responses of every fetch round
+	archive bool
+	// rates receives the pair's price when it converts stablecoin-quoted sources, nil otherwise
+	rates *fetcher.RateBook
+
+	// latest is the last processed price, used as a leg of derived pairs, and recordID its stored record
+	mu       sync.Mutex
+	latest   crossrate.Leg
+	recordID uint
+}
+
+// pair returns the pair the pipeline serves
+func (p *pairPipeline) pair() pair.Pair {
+	return p.config.Pair
+}
+
+// label returns the pair as used in metrics and logs
+func (p *pairPipeline) label() string {
+	return p.config.Pair.String()
+}
+
+// close releases the pipeline's blockchain connection
+func (p *pairPipeline) close() {
+	if p.blockchainClient != nil {
+		p.blockchainClient.Close()
+	}
+}
+
+// setLatest records the last processed price of the pair
+func (p *pairPipeline) setLatest(leg crossrate.Leg) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latest = leg
+}
+
+// setLatestRecordID records the ID of the stored record of the last processed price
+func (p *pairPipeline) setLatestRecordID(id uint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.recordID = id
+}
+
+// latestRecordID returns the ID of the stored record of the last processed price, zero if it was not stored
+func (p *pairPipeline) latestRecordID() uint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.recordID
+}
+
+// latestLeg returns the last processed price of the pair; its timestamp is zero before the first price
+func (p *pairPipeline) latestLeg() crossrate.Leg {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	leg := p.latest
+	leg.Pair = p.config.Pair
+	return leg
+}
+
+// sourceBuilder builds the price sources of each pair, either live from the registry or from a recording
+type sourceBuilder struct {
+	registry *fetcher.Registry
+	// replayer replaces the configured sources with recorded ones when set
+	replayer *fetcher.Replayer
+	// recorder captures the quotes of the live sources when set
+	recorder *fetcher.Recorder
+	// rates holds the stablecoin rates that sources quoting in a stablecoin are converted at
+	rates      *fetcher.RateBook
+	conversion fetcher.ConversionConfig
+}
+
+// clock returns the clock pipelines date prices with
+func (b *sourceBuilder) clock() func() time.Time {
+	if b.replayer != nil {
+		return b.replayer.Now
+	}
+	return time.Now
+}
+
+// sources builds the price sources of one pair
+func (b *sourceBuilder) sources(pairConfig utils.PairConfig, config *utils.Config) ([]fetcher.PriceSource, error) {
+	if b.replayer != nil {
+		sources := b.replayer.Sources(pairConfig.Pair)
+		if len(sources) == 0 {
+			return nil, fmt.Errorf("recording has no quotes for %s", pairConfig.Pair)
+		}
+		return sources, nil
+	}
+
+	var sources []fetcher.PriceSource
+	for _, sourceConfig := range pairConfig.Sources {
+		quoteAsset := sourceConfig.QuoteAsset
+		if quoteAsset == "" {
+			quoteAsset = b.registry.QuoteAsset(sourceConfig.Kind, pairConfig.Pair)
+		}
+
+		source, err := b.registry.Build(fetcher.SourceSpec{
+			Name:              sourceConfig.Name,
+			Kind:              sourceConfig.Kind,
+			Pair:              pairConfig.Pair,
+			URL:               sourceConfig.URL,
+			RequestsPerMinute: sourceConfig.RequestsPerMinute,
+			Params:            sourceConfig.Params,
+			QuoteAsset:        quoteAsset,
+		}, config.FetchTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize price source %s: %w", sourceConfig.Name, err)
+		}
+
+		if quoteAsset != pairConfig.Pair.Quote {
+			if source, err = b.convert(source, quoteAsset, pairConfig.Pair, config); err != nil {
+				return nil, fmt.Errorf("failed to configure conversion of price source %s: %w", sourceConfig.Name, err)
+			}
+		}
+
+		// Recordings hold converted quotes, so a replay needs no rates
+		if b.recorder != nil {
+			source = fetcher.NewRecordingSource(source, pairConfig.Pair, b.recorder)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// convert wraps a source quoting p in quoteAsset so its quotes are converted into the pair's quote currency
+// Without a tracked rate for the stablecoin the quotes are used at par, as they were before conversion existed
+func (b *sourceBuilder) convert(source fetcher.PriceSource, quoteAsset string, p pair.Pair, config *utils.Config) (fetcher.PriceSource, error) {
+	ratePair := pair.New(quoteAsset, p.Quote)
+	if !config.IsStablecoinPair(ratePair) {
+		log.Printf("Using %s quotes of %s from %s at par with %s; list %s in STABLECOIN_PAIRS to convert them",
+			quoteAsset, p, source.Name(), p.Quote, ratePair)
+		return source, nil
+	}
+
+	log.Printf("Converting %s quotes of %s from %s at the %s rate", quoteAsset, p, source.Name(), ratePair)
+	return fetcher.NewConvertedSource(source, quoteAsset, p.Quote, b.rates, b.conversion)
+}
+
+// newPairPipeline builds the sources, fetcher, normalizer, validator and blockchain client of one pair
+func newPairPipeline(pairConfig utils.PairConfig, config *utils.Config, builder *sourceBuilder) (*pairPipeline, error) {
+	sources, err := builder.sources(pairConfig, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configure how quotes from the sources are combined
+	weights := make(map[string]float64)
+	for _, sourceConfig := range pairConfig.Sources {
+		weights[sourceConfig.Name] = sourceConfig.Weight
+	}
+	aggregationConfig := fetcher.AggregationConfig{
+		Method:           fetcher.AggregationMethod(config.AggregationMethod),
+		OutlierFilter:    fetcher.OutlierFilter(config.OutlierFilter),
+		OutlierThreshold: config.OutlierThreshold,
+		Weights:          weights,
+	}
+	breakerConfig := fetcher.BreakerConfig{
+		FailureThreshold:   config.CircuitFailureThreshold,
+		OpenTimeout:        config.CircuitOpenTimeout,
+		Window:             config.HealthWindow,
+		LatencyTarget:      config.HealthLatencyTarget,
+		DeviationTolerance: config.HealthDeviationTolerance,
+	}
+
+	priceFetcher := fetcher.NewFetcherForPair(pairConfig.Pair, sources, config.FetchTimeout)
+	if err := priceFetcher.SetAggregationConfig(aggregationConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure aggregation: %w", err)
+	}
+	if err := priceFetcher.SetQuorum(pairConfig.MinSources); err != nil {
+		return nil, fmt.Errorf("failed to configure source quorum: %w", err)
+	}
+	if err := priceFetcher.SetMaxQuoteAge(pairConfig.MaxQuoteAge); err != nil {
+		return nil, fmt.Errorf("failed to configure max quote age: %w", err)
+	}
+	if config.CircuitFailureThreshold > 0 {
+		if err := priceFetcher.EnableCircuitBreakers(breakerConfig); err != nil {
+			return nil, fmt.Errorf("failed to configure circuit breakers: %w", err)
+		}
+	}
+
+	// Connect to the oracle contract first, since its bounds are the pair's unless configured narrower
+	blockchainClient, err := newBlockchainClient(pairConfig.OracleContractAddr, config, pairConfig.Pair, pairConfig.MinPrice, pairConfig.MaxPrice)
+	if err != nil {
+		return nil, err
+	}
+	built := false
+	defer func() {
+		if !built && blockchainClient != nil {
+			blockchainClient.Close()
+		}
+	}()
+	pairConfig.MinPrice, pairConfig.MaxPrice = priceBounds(blockchainClient, pairConfig.MinPrice, pairConfig.MaxPrice)
+
+	validator, err := validation.NewEngine(pairConfig.ValidationConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := &pairPipeline{
+		config:     pairConfig,
+		fetcher:    priceFetcher,
+		normalizer: normalizer.NewNormalizerWithBounds(config.PriceDecimals, pairConfig.MinPrice, pairConfig.MaxPrice),
+		validator:  validator,
+		now:        builder.clock(),
+		archive:    config.ArchiveResponses,
+
+		blockchainClient: blockchainClient,
+	}
+	if config.IsStablecoinPair(pairConfig.Pair) {
+		pipeline.rates = builder.rates
+	}
+	if pairConfig.JumpThreshold > 0 {
+		jumpGuard, err := normalizer.NewJumpGuard(normalizer.JumpConfig{
+			Threshold:      pairConfig.JumpThreshold,
+			ConfirmFetches: pairConfig.JumpConfirmFetches,
+			ConfirmSources: pairConfig.JumpConfirmSources,
+		})
+		if err != nil {
+			return nil, err
+		}
+		pipeline.jumpGuard = jumpGuard
+	}
+
+	// Cross-check prices against an on-chain reference feed when one is configured
+	// A replay has no live chain to read the reference from, so the check is skipped
+	if pairConfig.ReferenceFeed != "" && builder.replayer != nil {
+		log.Printf("Skipping reference feed of %s during replay", pairConfig.Pair)
+	} else if pairConfig.ReferenceFeed != "" {
+		reference, err := builder.registry.Build(fetcher.SourceSpec{
+			Name: "reference",
+			Kind: "aggregator_v3",
+			Pair: pairConfig.Pair,
+			Params: map[string]string{
+				"feed":    pairConfig.ReferenceFeed,
+				"max_age": pairConfig.ReferenceMaxAge.String(),
+			},
+		}, config.FetchTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize reference feed: %w", err)
+		}
+		referenceGuard, err := fetcher.NewReferenceGuard(reference, pairConfig.ReferenceMaxDeviation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure reference guard: %w", err)
+		}
+		pipeline.referenceGuard = referenceGuard
+	}
+
+	built = true
+	return pipeline, nil
+}
+
+// derivedPipeline holds the stages of a pair computed from two served pairs
+type derivedPipeline struct {
+	config     utils.DerivedPairConfig
+	derivation *crossrate.Derivation
+	legs       [2]*pairPipeline
+	normalizer *normalizer.Normalizer
+	validator  *validation.Engine
+	// blockchainClient is nil when the pair has no oracle contract
+	blockchainClient *blockchain.RealClient
+}
+
+// now returns the time on the clock of the leg pipelines
+func (d *derivedPipeline) now() time.Time {
+	return d.legs[0].now()
+}
+
+// pair returns the derived pair
+func (d *derivedPipeline) pair() pair.Pair {
+	return d.config.Pair
+}
+
+// label returns the pair as used in metrics and logs
+func (d *derivedPipeline) label() string {
+	return d.config.Pair.String()
+}
+
+// close releases the pipeline's blockchain connection
+func (d *derivedPipeline) close() {
+	if d.blockchainClient != nil {
+		d.blockchainClient.Close()
+	}
+}
+
+// latestLegs returns the last processed price of both legs
+func (d *derivedPipeline) latestLegs() [2]crossrate.Leg {
+	return [2]crossrate.Leg{d.legs[0].latestLeg(), d.legs[1].latestLeg()}
+}
+
+// latestLegRecordIDs returns the stored record IDs of the last processed price of both legs
+func (d *derivedPipeline) latestLegRecordIDs() [2]uint {
+	return [2]uint{d.legs[0].latestRecordID(), d.legs[1].latestRecordID()}
+}
+
+// newDerivedPipeline builds the derivation, normalizer, validator and blockchain client of one derived pair
+// The legs are looked up among the served pipelines
+func newDerivedPipeline(derivedConfig utils.DerivedPairConfig, config *utils.Config, pipelines []*pairPipeline) (*derivedPipeline, error) {
+	derivation, err := crossrate.NewDerivation(derivedConfig.Pair, derivedConfig.Legs[0], derivedConfig.Legs[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var legs [2]*pairPipeline
+	for i, leg := range derivedConfig.Legs {
+		for _, pipeline := range pipelines {
+			if pipeline.pair() == leg {
+				legs[i] = pipeline
+			}
+		}
+		if legs[i] == nil {
+			return nil, fmt.Errorf("leg %s is not served", leg)
+		}
+	}
+
+	// Connect to the oracle contract first, since its bounds are the pair's unless configured narrower
+	blockchainClient, err := newBlockchainClient(derivedConfig.OracleContractAddr, config, derivedConfig.Pair, derivedConfig.MinPrice, derivedConfig.MaxPrice)
+	if err != nil {
+		return nil, err
+	}
+	derivedConfig.MinPrice, derivedConfig.MaxPrice = priceBounds(blockchainClient, derivedConfig.MinPrice, derivedConfig.MaxPrice)
+
+	validator, err := validation.NewEngine(derivedConfig.ValidationConfig())
+	if err != nil {
+		if blockchainClient != nil {
+			blockchainClient.Close()
+		}
+		return nil, err
+	}
+
+	return &derivedPipeline{
+		config:           derivedConfig,
+		derivation:       derivation,
+		legs:             legs,
+		normalizer:       normalizer.NewNormalizerWithBounds(config.PriceDecimals, derivedConfig.MinPrice, derivedConfig.MaxPrice),
+		validator:        validator,
+		blockchainClient: blockchainClient,
+	}, nil
+}
+
+// validators returns the validation engine of every served and derived pair
+func validators(pipelines []*pairPipeline, derivedPipelines []*derivedPipeline) map[pair.Pair]*validation.Engine {
+	engines := make(map[pair.Pair]*validation.Engine, len(pipelines)+len(derivedPipelines))
+	for _, pipeline := range pipelines {
+		engines[pipeline.pair()] = pipeline.validator
+	}
+	for _, derived := range derivedPipelines {
+		engines[derived.pair()] = derived.validator
+	}
+	return engines
+}
+
+// newBlockchainClient connects to the oracle contract of p at contractAddr, returning nil when no contract is configured
+// It refuses a contract whose decimals, bounds or max age disagree with the pair's configuration
+func newBlockchainClient(contractAddr string, config *utils.Config, p pair.Pair, minPrice, maxPrice float64) (*blockchain.RealClient, error) {
+	if contractAddr == "" {
+		return nil, nil
+	}
+
+	blockchainConfig := &blockchain.Config{
+		RPCURL:       config.BlockchainRPCURL,
+		ContractAddr: contractAddr,
+		PrivateKey:   config.BlockchainPrivateKey,
+		GasLimit:     100000,
+	}
+	blockchainClient, err := blockchain.NewRealClient(blockchainConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize blockchain client: %w", err)
+	}
+
+	params := blockchainClient.Params()
+	if err := checkContractParams(params, config, p, minPrice, maxPrice); err != nil {
+		blockchainClient.Close()
+		return nil, fmt.Errorf("oracle contract %s: %w", contractAddr, err)
+	}
+	log.Printf("Oracle contract of %s at %s: %d decimals, prices in [%s, %s], max age %v",
+		p, contractAddr, params.Decimals, params.MinPrice, params.MaxPrice, params.MaxAge)
+	return blockchainClient, nil
+}
+
+// priceBounds returns the bounds a pair's prices are normalized and validated against: the configured bounds,
+// with unset ones taken from the pair's oracle contract, or from the defaults when it has none
+func priceBounds(blockchainClient *blockchain.RealClient, minPrice, maxPrice float64) (float64, float64) {
+	defaultMin, defaultMax := float64(normalizer.DefaultMinPrice), float64(normalizer.DefaultMaxPrice)
+	if blockchainClient != nil {
+		params := blockchainClient.Params()
+		defaultMin, defaultMax = params.MinPrice.Float64(), params.MaxPrice.Float64()
+	}
+	if minPrice == 0 {
+		minPrice = defaultMin
+	}
+	if maxPrice == 0 {
+		maxPrice = defaultMax
+	}
+	return minPrice, maxPrice
+}
+
+// checkContractParams compares the pair's configuration with the constants of its oracle contract
+// Prices the contract would revert on, or an interval that lets the on-chain price go stale, are refused
+func checkContractParams(params blockchain.ContractParams, config *utils.Config, p pair.Pair, minPrice, maxPrice float64) error {
+	prefix := p.EnvPrefix()
+	if params.Decimals != config.PriceDecimals {
+		return fmt.Errorf("contract has %d decimals but PRICE_DECIMALS is %d", params.Decimals, config.PriceDecimals)
+	}
+
+	// Configured bounds may only narrow the contract's; unset ones are taken from it
+	if minPrice != 0 {
+		localMin, err := fixedpoint.FromFloat(minPrice, params.Decimals)
+		if err != nil {
+			return fmt.Errorf("invalid %s_MIN_PRICE: %w", prefix, err)
+		}
+		if localMin.Cmp(params.MinPrice) < 0 {
+			return fmt.Errorf("%s_MIN_PRICE %s is below the contract's MIN_PRICE %s", prefix, localMin, params.MinPrice)
+		}
+	}
+	if maxPrice != 0 {
+		localMax, err := fixedpoint.FromFloat(maxPrice, params.Decimals)
+		if err != nil {
+			return fmt.Errorf("invalid %s_MAX_PRICE: %w", prefix, err)
+		}
+		if localMax.Cmp(params.MaxPrice) > 0 {
+			return fmt.Errorf("%s_MAX_PRICE %s is above the contract's MAX_PRICE %s", prefix, localMax, params.MaxPrice)
+		}
+	}
+
+	if interval := config.LongestFetchInterval(); params.MaxAge > 0 && interval >= params.MaxAge {
+		return fmt.Errorf("fetch interval %v would let the price outlive the contract's MAX_AGE %v", interval, params.MaxAge)
+	}
+	return nil
+}
@@ -6,50 +6,74 @@ import (
 	"time"
 
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/cache"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/fetcher"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/metrics"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
 	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/storage"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // API handles HTTP endpoints
 type API struct {
-	router  *gin.Engine
-	cache   *cache.Cache
-	storage *storage.Storage
-	metrics *metrics.Metrics
+	router   *gin.Engine
+	cache    *cache.Cache
+	storage  *storage.Storage
+	metrics  *metrics.Metrics
+	fetchers []*fetcher.Fetcher
+	// derivedPairs are cross rates computed from the fetched pairs
+	derivedPairs []pair.Pair
+	// validators are the validation engines of the served pairs
+	validators map[pair.Pair]*validation.Engine
 }
 
-// NewAPI creates a new API instance
-func NewAPI(cache *cache.Cache, storage *storage.Storage, metrics *metrics.Metrics) *API {
+// NewAPI creates a new API instance serving the pairs of the given fetchers
+func NewAPI(cache *cache.Cache, storage *storage.Storage, metrics *metrics.Metrics, fetchers []*fetcher.Fetcher) *API {
 	router := gin.Default()
 
 	api := &API{
-		router:  router,
-		cache:   cache,
-		storage: storage,
-		metrics: metrics,
+		router:   router,
+		cache:    cache,
+		storage:  storage,
+		metrics:  metrics,
+		fetchers: fetchers,
 	}
 
 	api.setupRoutes()
 	return api
 }
 
+// SetDerivedPairs sets the derived pairs served alongside the fetched ones
+func (a *API) SetDerivedPairs(pairs []pair.Pair) {
+	a.derivedPairs = pairs
+}
+
+// SetValidators sets the validation engines whose rules and rejections are served per pair
+func (a *API) SetValidators(validators map[pair.Pair]*validation.Engine) {
+	a.validators = validators
+}
+
 // setupRoutes configures all the API routes
 func (a *API) setupRoutes() {
 	// Health check endpoint
 	a.router.GET("/health", a.healthCheck)
 
-	// Price endpoints
+	// Price endpoints; all take an optional ?pair= parameter that defaults to the first configured pair
+	a.router.GET("/pairs", a.getPairs)
 	a.router.GET("/price", a.getLatestPrice)
 	a.router.GET("/price/history", a.getPriceHistory)
 	a.router.GET("/price/twap", a.getTWAP)
+	a.router.GET("/price/:id/derivation", a.getPriceDerivation)
+	a.router.GET("/price/jumps", a.getJumpEvents)
 
 	// Metrics endpoint
 	a.router.GET("/metrics", a.getMetrics)
 
 	// Admin endpoints
 	a.router.GET("/admin/stats", a.getStats)
+	a.router.GET("/admin/sources", a.getSources)
+	a.router.GET("/admin/validation", a.getValidation)
 }
 
 // healthCheck returns the health status of the service
@@ -78,15 +102,95 @@ func (a *API) healthCheck(c *gin.Context) {
 	})
 }
 
-// getLatestPrice returns the latest ETH/USD price
+// pairParam resolves the ?pair= query parameter to a configured pair, writing an error response if it cannot
+func (a *API) pairParam(c *gin.Context) (pair.Pair, bool) {
+	value := c.Query("pair")
+	if value == "" {
+		if len(a.fetchers) == 0 {
+			return pair.ETHUSD, true
+		}
+		return a.fetchers[0].Pair(), true
+	}
+
+	p, err := pair.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return pair.Pair{}, false
+	}
+
+	if a.fetcherFor(p) == nil && !a.isDerived(p) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "pair " + p.String() + " is not served",
+		})
+		return pair.Pair{}, false
+	}
+
+	return p, true
+}
+
+// fetcherFor returns the fetcher of p, or nil if the pair is not served
+func (a *API) fetcherFor(p pair.Pair) *fetcher.Fetcher {
+	for _, f := range a.fetchers {
+		if f.Pair() == p {
+			return f
+		}
+	}
+	return nil
+}
+
+// isDerived reports whether p is a derived pair
+func (a *API) isDerived(p pair.Pair) bool {
+	for _, derived := range a.derivedPairs {
+		if derived == p {
+			return true
+		}
+	}
+	return false
+}
+
+// servedPairs returns the fetched pairs followed by the derived ones
+func (a *API) servedPairs() []pair.Pair {
+	pairs := make([]pair.Pair, 0, len(a.fetchers)+len(a.derivedPairs))
+	for _, f := range a.fetchers {
+		pairs = append(pairs, f.Pair())
+	}
+	return append(pairs, a.derivedPairs...)
+}
+
+// getPairs returns the pairs served by this deployment
+func (a *API) getPairs(c *gin.Context) {
+	pairs := make([]string, 0, len(a.fetchers)+len(a.derivedPairs))
+	for _, p := range a.servedPairs() {
+		pairs = append(pairs, p.String())
+	}
+	derived := make([]string, len(a.derivedPairs))
+	for i, p := range a.derivedPairs {
+		derived[i] = p.String()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pairs":   pairs,
+		"derived": derived,
+		"count":   len(pairs),
+	})
+}
+
+// getLatestPrice returns the latest price of a pair
 func (a *API) getLatestPrice(c *gin.Context) {
 	start := time.Now()
 
+	p, ok := a.pairParam(c)
+	if !ok {
+		return
+	}
+
 	// Try to get from cache first
-	priceData, err := a.cache.GetCachedPrice()
+	priceData, err := a.cache.GetCachedPriceForPair(c.Request.Context(), p)
 	if err != nil {
 		// Fallback to database
-		record, dbErr := a.storage.GetLatestPrice()
+		record, dbErr := a.storage.GetLatestPriceForPair(p)
 		if dbErr != nil {
 			a.metrics.RecordCacheError("redis", "get")
 			a.metrics.RecordDBError("select", "price_records", "not_found")
@@ -96,32 +200,54 @@ func (a *API) getLatestPrice(c *gin.Context) {
 			return
 		}
 
-		priceData = &cache.PriceData{
-			Price:     record.Price,
-			Timestamp: record.Timestamp,
-			Source:    record.Source,
-		}
+		data := recordPriceData(*record)
+		priceData = &data
 		a.metrics.RecordCacheMiss("redis")
 	} else {
 		a.metrics.RecordCacheHit("redis")
 	}
 
+	// Prices are as old as their observation, which older records did not keep
+	observedAt := priceData.ObservedAt
+	if observedAt.IsZero() {
+		observedAt = priceData.Timestamp
+	}
+
 	// Record metrics
-	a.metrics.RecordPriceAge(time.Since(priceData.Timestamp), priceData.Source)
+	a.metrics.RecordPriceAge(time.Since(observedAt), p.String(), priceData.Source)
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
+		"pair":        p.String(),
 		"price":       priceData.Price,
 		"timestamp":   priceData.Timestamp.Unix(),
+		"observed_at": observedAt.Unix(),
+		"record_id":   priceData.RecordID,
 		"source":      priceData.Source,
-		"age_seconds": time.Since(priceData.Timestamp).Seconds(),
-	})
+		"derived":     priceData.Derived,
+		"age_seconds": time.Since(observedAt).Seconds(),
+	}
+	// Prices stored before confidence bands were kept have none
+	if priceData.ConfidenceUpper > 0 {
+		response["confidence"] = gin.H{
+			"lower":       priceData.ConfidenceLower,
+			"upper":       priceData.ConfidenceUpper,
+			"std_error":   priceData.StdError,
+			"width_ratio": (priceData.ConfidenceUpper - priceData.ConfidenceLower) / priceData.Price,
+		}
+	}
+	c.JSON(http.StatusOK, response)
 
 	// Record latency
-	a.metrics.RecordFetchLatency(time.Since(start), "api", "success")
+	a.metrics.RecordFetchLatency(time.Since(start), p.String(), "api", "success")
 }
 
-// getPriceHistory returns historical price data
+// getPriceHistory returns historical price data of a pair
 func (a *API) getPriceHistory(c *gin.Context) {
+	p, ok := a.pairParam(c)
+	if !ok {
+		return
+	}
+
 	limitStr := c.DefaultQuery("limit", "100")
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 || limit > 1000 {
@@ -129,10 +255,10 @@ func (a *API) getPriceHistory(c *gin.Context) {
 	}
 
 	// Try cache first
-	history, err := a.cache.GetPriceHistory(limit)
+	history, err := a.cache.GetPriceHistoryForPair(p, limit)
 	if err != nil || len(history) == 0 {
 		// Fallback to database
-		records, dbErr := a.storage.GetPriceHistory(limit)
+		records, dbErr := a.storage.GetPriceHistoryForPair(p, limit)
 		if dbErr != nil {
 			a.metrics.RecordDBError("select", "price_records", "query_failed")
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -144,11 +270,7 @@ func (a *API) getPriceHistory(c *gin.Context) {
 		// Convert records to cache format
 		history = make([]cache.PriceData, len(records))
 		for i, record := range records {
-			history[i] = cache.PriceData{
-				Price:     record.Price,
-				Timestamp: record.Timestamp,
-				Source:    record.Source,
-			}
+			history[i] = recordPriceData(record)
 		}
 		a.metrics.RecordCacheMiss("redis")
 	} else {
@@ -156,13 +278,48 @@ func (a *API) getPriceHistory(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"pair":   p.String(),
 		"prices": history,
 		"count":  len(history),
 	})
 }
 
-// getTWAP returns the Time-Weighted Average Price
+// getJumpEvents returns the most recent large moves of a pair held, confirmed or discarded by its jump guard
+func (a *API) getJumpEvents(c *gin.Context) {
+	p, ok := a.pairParam(c)
+	if !ok {
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "100")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	events, err := a.storage.GetJumpEvents(p, limit)
+	if err != nil {
+		a.metrics.RecordDBError("select", "jump_events", "query_failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to retrieve jump events",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pair":   p.String(),
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// getTWAP returns the Time-Weighted Average Price of a pair
 func (a *API) getTWAP(c *gin.Context) {
+	p, ok := a.pairParam(c)
+	if !ok {
+		return
+	}
+
 	durationStr := c.DefaultQuery("duration", "1h")
 	duration, err := time.ParseDuration(durationStr)
 	if err != nil {
@@ -173,7 +330,7 @@ func (a *API) getTWAP(c *gin.Context) {
 	}
 
 	start := time.Now()
-	twap, err := a.storage.CalculateTWAP(duration)
+	twap, err := a.storage.CalculateTWAPForPair(p, duration)
 	if err != nil {
 		a.metrics.RecordDBError("select", "price_records", "twap_calculation")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -185,6 +342,7 @@ func (a *API) getTWAP(c *gin.Context) {
 	a.metrics.RecordDBLatency(time.Since(start), "calculate_twap", "price_records")
 
 	c.JSON(http.StatusOK, gin.H{
+		"pair":          p.String(),
 		"twap":          twap,
 		"duration":      duration.String(),
 		"calculated_at": time.Now().Unix(),
@@ -209,23 +367,185 @@ func (a *API) getStats(c *gin.Context) {
 		return
 	}
 
-	// Get latest price
-	latestPrice, err := a.storage.GetLatestPrice()
+	// Get latest price of every pair
+	servedPairs := a.servedPairs()
+	latestPrices := make([]gin.H, 0, len(servedPairs))
+	for _, p := range servedPairs {
+		latestPrice, err := a.storage.GetLatestPriceForPair(p)
+		if err != nil {
+			latestPrice = &storage.PriceRecord{}
+		}
+		latestPrices = append(latestPrices, gin.H{
+			"pair":      p.String(),
+			"price":     latestPrice.Price,
+			"timestamp": latestPrice.Timestamp.Unix(),
+			"source":    latestPrice.Source,
+			"derived":   a.isDerived(p),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_prices":  count,
+		"latest_prices": latestPrices,
+		"uptime":        time.Now().Unix(),
+	})
+}
+
+// getSources returns the circuit breaker state and health score of every price source of a pair
+func (a *API) getSources(c *gin.Context) {
+	p, ok := a.pairParam(c)
+	if !ok {
+		return
+	}
+	f := a.fetcherFor(p)
+	if f == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "pair " + p.String() + " has no price sources",
+		})
+		return
+	}
+
+	statuses := f.SourceStatuses()
+
+	c.JSON(http.StatusOK, gin.H{
+		"pair":    p.String(),
+		"sources": statuses,
+		"count":   len(statuses),
+		"quorum":  f.GetQuorum(),
+	})
+}
+
+// getValidation returns the validation rules of a pair with its accepted and rejected counts by reason code
+func (a *API) getValidation(c *gin.Context) {
+	p, ok := a.pairParam(c)
+	if !ok {
+		return
+	}
+	validator := a.validators[p]
+	if validator == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "pair " + p.String() + " has no validation rules",
+		})
+		return
+	}
+
+	config := validator.Config()
+	rules := gin.H{
+		"min_price": config.MinPrice,
+		"max_price": config.MaxPrice,
+	}
+	if config.MaxLastDeviation > 0 {
+		rules["max_last_deviation"] = config.MaxLastDeviation
+	}
+	if config.MaxTWAPDeviation > 0 {
+		rules["max_twap_deviation"] = config.MaxTWAPDeviation
+		rules["twap_window"] = config.TWAPWindow.String()
+	}
+	if config.MinSources > 0 {
+		rules["min_sources"] = config.MinSources
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pair":  p.String(),
+		"rules": rules,
+		"stats": validator.Stats(),
+	})
+}
+
+// getPriceDerivation returns a stored price together with the archived inputs it was computed from:
+// the upstream responses of every source for fetched prices, and the leg prices for derived ones
+func (a *API) getPriceDerivation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		latestPrice = &storage.PriceRecord{}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid price record id",
+		})
+		return
+	}
+
+	record, err := a.storage.GetPriceRecord(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	responses, err := a.storage.GetRawResponses(record.ID)
+	if err != nil {
+		a.metrics.RecordDBError("select", "raw_responses", "query_failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to retrieve raw responses",
+		})
+		return
+	}
+	legs, err := a.storage.GetDerivationLegs(record.ID)
+	if err != nil {
+		a.metrics.RecordDBError("select", "derivation_legs", "query_failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to retrieve derivation legs",
+		})
+		return
+	}
+
+	inputs := make([]gin.H, 0, len(responses))
+	for _, response := range responses {
+		input := gin.H{
+			"source":      response.Source,
+			"fetched_at":  response.FetchedAt,
+			"observed_at": response.ObservedAt,
+			"latency_ms":  response.LatencyMS,
+			"status_code": response.StatusCode,
+			"price":       response.Price,
+			"used":        response.Error == "" && response.Discarded == "",
+			"body_size":   response.BodySize,
+		}
+		if response.Error != "" {
+			input["error"] = response.Error
+			input["error_class"] = response.ErrorClass
+		}
+		if response.Discarded != "" {
+			input["discarded"] = response.Discarded
+		}
+		if header, err := response.Header(); err == nil && header != nil {
+			input["headers"] = header
+		}
+		if body, err := response.RawBody(); err == nil && body != nil {
+			input["body"] = string(body)
+		}
+		inputs = append(inputs, input)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total_prices": count,
-		"latest_price": gin.H{
-			"price":     latestPrice.Price,
-			"timestamp": latestPrice.Timestamp.Unix(),
-			"source":    latestPrice.Source,
-		},
-		"uptime": time.Now().Unix(),
+		"record": record,
+		"inputs": inputs,
+		"legs":   legs,
 	})
 }
 
+// recordPriceData converts a stored price record to its cached form
+func recordPriceData(record storage.PriceRecord) cache.PriceData {
+	data := cache.PriceData{
+		RecordID:  record.ID,
+		Pair:      record.Pair,
+		Price:     record.Price,
+		Timestamp: record.Timestamp,
+		Source:    record.Source,
+		Derived:   record.Derived,
+	}
+	if record.ObservedAt != nil {
+		data.ObservedAt = *record.ObservedAt
+	}
+	if record.ConfidenceLower != nil && record.ConfidenceUpper != nil {
+		data.ConfidenceLower = *record.ConfidenceLower
+		data.ConfidenceUpper = *record.ConfidenceUpper
+	}
+	if record.StdError != nil {
+		data.StdError = *record.StdError
+	}
+	return data
+}
+
 // Run starts the HTTP server
 func (a *API) Run(addr string) error {
 	return a.router.Run(addr)
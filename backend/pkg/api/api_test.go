@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestAPI returns an API over a fresh in-memory SQLite database
+func newTestAPI(t *testing.T) (*API, *storage.Storage) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database handle: %v", err)
+	}
+	// Every connection to :memory: opens its own database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&storage.PriceRecord{}, &storage.RawResponse{}, &storage.DerivationLeg{}, &storage.JumpEvent{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	store := storage.NewStorageWithDB(db)
+	return NewAPI(nil, store, nil, nil), store
+}
+
+// derivationResponse is the body of /price/{id}/derivation
+type derivationResponse struct {
+	Record storage.PriceRecord `json:"record"`
+	Inputs []struct {
+		Source string  `json:"source"`
+		Price  float64 `json:"price"`
+		Used   bool    `json:"used"`
+		Error  string  `json:"error"`
+		Body   string  `json:"body"`
+	} `json:"inputs"`
+	Legs []storage.DerivationLeg `json:"legs"`
+}
+
+// getDerivation requests the derivation of record id
+func getDerivation(t *testing.T, api *API, id string) (int, derivationResponse) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	api.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/price/"+id+"/derivation", nil))
+
+	var body derivationResponse
+	if recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode derivation: %v", err)
+		}
+	}
+	return recorder.Code, body
+}
+
+// saveFetchedPrice stores a price of p with one archived response per source price
+func saveFetchedPrice(t *testing.T, store *storage.Storage, p string, price float64, sources map[string]float64) *storage.PriceRecord {
+	t.Helper()
+
+	now := time.Now().UTC()
+	record := &storage.PriceRecord{Pair: p, Price: price, Timestamp: now, Source: "aggregated"}
+	var responses []storage.RawResponse
+	for source, quoted := range sources {
+		response := storage.RawResponse{Pair: p, Source: source, FetchedAt: now, StatusCode: 200, Price: quoted}
+		if err := response.SetBody([]byte(fmt.Sprintf(`{"price":"%v"}`, quoted))); err != nil {
+			t.Fatalf("SetBody() error = %v", err)
+		}
+		responses = append(responses, response)
+	}
+	if err := store.SavePriceRecordWithInputs(context.Background(), record, responses, nil); err != nil {
+		t.Fatalf("SavePriceRecordWithInputs() error = %v", err)
+	}
+	return record
+}
+
+func TestGetPriceDerivationOfFetchedPrice(t *testing.T) {
+	api, store := newTestAPI(t)
+	record := saveFetchedPrice(t, store, "ETH/USD", 3412.57, map[string]float64{"binance": 3412.01, "kraken": 3412.58})
+
+	// A failed source of the same round is archived with the record too
+	failed := storage.RawResponse{
+		PriceRecordID: &record.ID, Pair: "ETH/USD", Source: "coingecko", FetchedAt: record.Timestamp,
+		StatusCode: 503, Error: "API returned status 503", ErrorClass: "http_status",
+	}
+	if err := store.SaveRawResponses(context.Background(), []storage.RawResponse{failed}); err != nil {
+		t.Fatalf("SaveRawResponses() error = %v", err)
+	}
+
+	code, body := getDerivation(t, api, fmt.Sprint(record.ID))
+	if code != http.StatusOK {
+		t.Fatalf("GET derivation status = %d, want 200", code)
+	}
+	if body.Record.ID != record.ID || body.Record.Price != 3412.57 {
+		t.Errorf("record = %+v, want record %d", body.Record, record.ID)
+	}
+	if len(body.Legs) != 0 {
+		t.Errorf("legs = %+v, want none for a fetched price", body.Legs)
+	}
+
+	// Inputs are ordered by source
+	if len(body.Inputs) != 3 {
+		t.Fatalf("inputs = %+v, want the three responses of the round", body.Inputs)
+	}
+	want := []struct {
+		source string
+		used   bool
+		body   string
+	}{
+		{source: "binance", used: true, body: `{"price":"3412.01"}`},
+		{source: "coingecko", used: false},
+		{source: "kraken", used: true, body: `{"price":"3412.58"}`},
+	}
+	for i, input := range body.Inputs {
+		if input.Source != want[i].source || input.Used != want[i].used || input.Body != want[i].body {
+			t.Errorf("input %d = %+v, want %s used %v with body %q", i, input, want[i].source, want[i].used, want[i].body)
+		}
+	}
+	if body.Inputs[1].Error != "API returned status 503" {
+		t.Errorf("failed input error = %q, want the archived error", body.Inputs[1].Error)
+	}
+}
+
+func TestGetPriceDerivationOfDerivedPrice(t *testing.T) {
+	api, store := newTestAPI(t)
+	ethUSD := saveFetchedPrice(t, store, "ETH/USD", 3000, map[string]float64{"binance": 3000})
+	eurUSD := saveFetchedPrice(t, store, "EUR/USD", 1.08, map[string]float64{"kraken": 1.08})
+
+	now := time.Now().UTC()
+	derived := &storage.PriceRecord{Pair: "ETH/EUR", Price: 2777.77777778, Timestamp: now, Source: "derived:ETH/USD,EUR/USD", Derived: true}
+	legs := []storage.DerivationLeg{
+		{LegRecordID: &ethUSD.ID, Pair: "ETH/USD", Price: 3000, ObservedAt: now},
+		{LegRecordID: &eurUSD.ID, Pair: "EUR/USD", Price: 1.08, ObservedAt: now},
+	}
+	if err := store.SavePriceRecordWithInputs(context.Background(), derived, nil, legs); err != nil {
+		t.Fatalf("SavePriceRecordWithInputs() error = %v", err)
+	}
+
+	code, body := getDerivation(t, api, fmt.Sprint(derived.ID))
+	if code != http.StatusOK {
+		t.Fatalf("GET derivation status = %d, want 200", code)
+	}
+	if !body.Record.Derived || len(body.Inputs) != 0 || len(body.Legs) != 2 {
+		t.Fatalf("derivation = %+v, want a derived record with two legs and no responses", body)
+	}
+
+	// Each leg resolves to the derivation of the record it was taken from
+	for i, want := range []*storage.PriceRecord{ethUSD, eurUSD} {
+		leg := body.Legs[i]
+		if leg.LegRecordID == nil || *leg.LegRecordID != want.ID {
+			t.Errorf("leg %d record = %v, want %d", i, leg.LegRecordID, want.ID)
+			continue
+		}
+		code, legBody := getDerivation(t, api, fmt.Sprint(*leg.LegRecordID))
+		if code != http.StatusOK {
+			t.Fatalf("GET derivation of leg %s status = %d, want 200", leg.Pair, code)
+		}
+		if legBody.Record.Pair != leg.Pair || legBody.Record.Price != leg.Price || len(legBody.Inputs) != 1 {
+			t.Errorf("derivation of leg %s = %+v, want its record and response", leg.Pair, legBody)
+		}
+	}
+}
+
+func TestGetPriceDerivationErrors(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	tests := []struct {
+		id   string
+		code int
+	}{
+		{id: "abc", code: http.StatusBadRequest},
+		{id: "-1", code: http.StatusBadRequest},
+		{id: "42", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if code, _ := getDerivation(t, api, tt.id); code != tt.code {
+			t.Errorf("GET /price/%s/derivation status = %d, want %d", tt.id, code, tt.code)
+		}
+	}
+}
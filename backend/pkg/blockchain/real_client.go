@@ -4,15 +4,41 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
+	"log"
 	"math/big"
 	"time"
 
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// ContractParams are the constants the Oracle contract enforces on price updates
+type ContractParams struct {
+	// Decimals is the contract's PRICE_DECIMALS
+	Decimals int
+	// MinPrice and MaxPrice are the contract's MIN_PRICE and MAX_PRICE
+	MinPrice fixedpoint.Price
+	MaxPrice fixedpoint.Price
+	// MaxAge is the contract's MAX_AGE, beyond which its price is stale
+	MaxAge time.Duration
+}
+
+// CheckPrice returns an error when price, rescaled to the contract's decimals, lies outside [MinPrice, MaxPrice],
+// the range updatePrice accepts
+func (p ContractParams) CheckPrice(price fixedpoint.Price) error {
+	price = price.Rescale(p.Decimals)
+	if price.Cmp(p.MinPrice) < 0 {
+		return fmt.Errorf("price %s is below the contract's MIN_PRICE %s", price, p.MinPrice)
+	}
+	if price.Cmp(p.MaxPrice) > 0 {
+		return fmt.Errorf("price %s is above the contract's MAX_PRICE %s", price, p.MaxPrice)
+	}
+	return nil
+}
+
 // RealClient handles real blockchain interactions using go-ethereum
 type RealClient struct {
 	client       *ethclient.Client
@@ -22,6 +48,7 @@ type RealClient struct {
 	fromAddress  common.Address
 	chainID      *big.Int
 	contractAddr common.Address
+	params       ContractParams
 }
 
 // Config holds blockchain client configuration
@@ -69,6 +96,12 @@ func NewRealClient(config *Config) (*RealClient, error) {
 		return nil, fmt.Errorf("failed to create contract instance: %v", err)
 	}
 
+	// Read the decimals and bounds prices are checked against from the contract itself
+	params, err := readContractParams(context.Background(), oracle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contract parameters: %w", err)
+	}
+
 	return &RealClient{
 		client:       client,
 		oracle:       oracle,
@@ -77,9 +110,47 @@ func NewRealClient(config *Config) (*RealClient, error) {
 		fromAddress:  fromAddress,
 		chainID:      chainID,
 		contractAddr: contractAddr,
+		params:       params,
+	}, nil
+}
+
+// readContractParams reads PRICE_DECIMALS, MIN_PRICE, MAX_PRICE and MAX_AGE from the contract
+func readContractParams(ctx context.Context, oracle *OracleContract) (ContractParams, error) {
+	callOpts := &bind.CallOpts{Context: ctx}
+
+	decimals, err := oracle.PRICEDECIMALS(callOpts)
+	if err != nil {
+		return ContractParams{}, fmt.Errorf("failed to call PRICE_DECIMALS: %w", err)
+	}
+	if !decimals.IsInt64() || decimals.Int64() > 36 {
+		return ContractParams{}, fmt.Errorf("unsupported PRICE_DECIMALS %s", decimals)
+	}
+	minPrice, err := oracle.MINPRICE(callOpts)
+	if err != nil {
+		return ContractParams{}, fmt.Errorf("failed to call MIN_PRICE: %w", err)
+	}
+	maxPrice, err := oracle.MAXPRICE(callOpts)
+	if err != nil {
+		return ContractParams{}, fmt.Errorf("failed to call MAX_PRICE: %w", err)
+	}
+	maxAge, err := oracle.MAXAGE(callOpts)
+	if err != nil {
+		return ContractParams{}, fmt.Errorf("failed to call MAX_AGE: %w", err)
+	}
+
+	return ContractParams{
+		Decimals: int(decimals.Int64()),
+		MinPrice: fixedpoint.New(minPrice, int(decimals.Int64())),
+		MaxPrice: fixedpoint.New(maxPrice, int(decimals.Int64())),
+		MaxAge:   time.Duration(maxAge.Int64()) * time.Second,
 	}, nil
 }
 
+// Params returns the constants the contract enforces, read when the client connected
+func (c *RealClient) Params() ContractParams {
+	return c.params
+}
+
 // Close closes the blockchain client connection
 func (c *RealClient) Close() {
 	c.client.Close()
@@ -87,17 +158,34 @@ func (c *RealClient) Close() {
 
 // UpdateOraclePrice sends a real transaction to update the Oracle price
 func (c *RealClient) UpdateOraclePrice(priceUSD float64) error {
-	// Convert price to contract units (8 decimals)
-	priceWei := new(big.Int)
-	priceWei.SetInt64(int64(priceUSD * 100000000)) // Multiply by 10^8
+	price, err := fixedpoint.FromFloat(priceUSD, c.params.Decimals)
+	if err != nil {
+		return fmt.Errorf("failed to convert price: %w", err)
+	}
+	return c.UpdateOraclePriceWithContext(context.Background(), price)
+}
+
+// UpdateOraclePriceWithContext sends a transaction to update the Oracle price and waits for it
+// to be mined, giving up when ctx is done
+func (c *RealClient) UpdateOraclePriceWithContext(ctx context.Context, price fixedpoint.Price) error {
+	// Convert price to contract units
+	price = price.Rescale(c.params.Decimals)
+	if price.Sign() <= 0 {
+		return fmt.Errorf("price must be positive, got: %s", price)
+	}
+	// A price the contract would revert on is not sent, so it costs no gas
+	if err := c.params.CheckPrice(price); err != nil {
+		return err
+	}
+	priceWei := price.Units()
 
 	// Create transaction options
-	nonce, err := c.client.PendingNonceAt(context.Background(), c.fromAddress)
+	nonce, err := c.client.PendingNonceAt(ctx, c.fromAddress)
 	if err != nil {
 		return fmt.Errorf("failed to get nonce: %v", err)
 	}
 
-	gasPrice, err := c.client.SuggestGasPrice(context.Background())
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to suggest gas price: %v", err)
 	}
@@ -111,6 +199,7 @@ func (c *RealClient) UpdateOraclePrice(priceUSD float64) error {
 	auth.Value = big.NewInt(0)
 	auth.GasLimit = 100000 // Gas limit
 	auth.GasPrice = gasPrice
+	auth.Context = ctx
 
 	// Call the updatePrice function
 	tx, err := c.oracle.UpdatePrice(auth, priceWei)
@@ -118,11 +207,10 @@ func (c *RealClient) UpdateOraclePrice(priceUSD float64) error {
 		return fmt.Errorf("failed to send transaction: %v", err)
 	}
 
-	fmt.Printf("✅ Transaction sent: %s\n", tx.Hash().Hex())
-	fmt.Printf("💰 Updating Oracle price to: $%.2f (contract units: %s)\n", priceUSD, priceWei.String())
+	log.Printf("Sent Oracle price update to %s (contract units: %s) in transaction %s", price, priceWei.String(), tx.Hash().Hex())
 
 	// Wait for transaction to be mined
-	receipt, err := bind.WaitMined(context.Background(), c.client, tx)
+	receipt, err := bind.WaitMined(ctx, c.client, tx)
 	if err != nil {
 		return fmt.Errorf("failed to wait for transaction: %v", err)
 	}
@@ -131,7 +219,7 @@ func (c *RealClient) UpdateOraclePrice(priceUSD float64) error {
 		return fmt.Errorf("transaction failed")
 	}
 
-	fmt.Printf("✅ Transaction confirmed in block: %d\n", receipt.BlockNumber.Uint64())
+	log.Printf("Transaction %s confirmed in block %d", tx.Hash().Hex(), receipt.BlockNumber.Uint64())
 	return nil
 }
 
@@ -149,7 +237,7 @@ func (c *RealClient) GetLatestPrice() (float64, time.Time, uint64, error) {
 	}
 
 	// Convert price back to USD
-	priceUSD := float64(result.Price.Int64()) / 100000000
+	priceUSD := fixedpoint.New(result.Price, c.params.Decimals).Float64()
 
 	// Convert timestamp
 	timestamp := time.Unix(result.Timestamp.Int64(), 0)
@@ -0,0 +1,39 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+func TestContractParamsCheckPriceInContractUnits(t *testing.T) {
+	params := ContractParams{
+		Decimals: 8,
+		MinPrice: fixedpoint.New(big.NewInt(100000000), 8),
+		MaxPrice: fixedpoint.New(big.NewInt(100000000000000), 8),
+	}
+
+	tests := []struct {
+		price   string
+		wantErr bool
+	}{
+		{price: "1", wantErr: false},
+		{price: "1000000", wantErr: false},
+		{price: "0.99999999", wantErr: true},
+		// Rounds to MIN_PRICE at the contract's 8 decimals
+		{price: "0.999999995", wantErr: false},
+		{price: "1000000.00000001", wantErr: true},
+		{price: "1000000.000000004", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		price, err := fixedpoint.ParseExact(tt.price)
+		if err != nil {
+			t.Fatalf("ParseExact(%q) error = %v", tt.price, err)
+		}
+		if err := params.CheckPrice(price); (err != nil) != tt.wantErr {
+			t.Errorf("CheckPrice(%s) error = %v, want error %v", tt.price, err, tt.wantErr)
+		}
+	}
+}
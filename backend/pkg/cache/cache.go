@@ -6,14 +6,35 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
 	"github.com/redis/go-redis/v9"
 )
 
 // PriceData represents cached price information
 type PriceData struct {
+	Pair      string    `json:"pair,omitempty"`
 	Price     float64   `json:"price"`
 	Timestamp time.Time `json:"timestamp"`
 	Source    string    `json:"source,omitempty"`
+	Derived   bool      `json:"derived,omitempty"`
+	// ObservedAt is when the providers observed the price, while Timestamp is when it was fetched
+	ObservedAt time.Time `json:"observed_at"`
+	// RecordID is the stored price record, whose inputs are served at /price/{id}/derivation
+	RecordID uint `json:"record_id,omitempty"`
+	// ConfidenceLower and ConfidenceUpper bound the price and StdError is the standard error of its quotes
+	ConfidenceLower float64 `json:"confidence_lower,omitempty"`
+	ConfidenceUpper float64 `json:"confidence_upper,omitempty"`
+	StdError        float64 `json:"std_error,omitempty"`
+}
+
+// priceKey returns the key of the latest price of p, e.g. eth_usd_price
+func priceKey(p pair.Pair) string {
+	return p.Key() + "_price"
+}
+
+// historyKeyPrefix returns the key prefix of the price history of p
+func historyKeyPrefix(p pair.Pair) string {
+	return p.Key() + "_price_history:"
 }
 
 // Cache handles Redis operations for price caching
@@ -53,19 +74,34 @@ func NewCacheWithClient(client *redis.Client) *Cache {
 
 // CachePrice stores the latest ETH/USD price in Redis
 func (c *Cache) CachePrice(price float64, timestamp time.Time, source string) error {
-	priceData := PriceData{
+	return c.CachePriceWithContext(c.ctx, price, timestamp, source)
+}
+
+// CachePriceWithContext stores the latest ETH/USD price in Redis, giving up when ctx is done
+func (c *Cache) CachePriceWithContext(ctx context.Context, price float64, timestamp time.Time, source string) error {
+	return c.CachePriceForPair(ctx, pair.ETHUSD, price, timestamp, source)
+}
+
+// CachePriceForPair stores the latest price of p in Redis, giving up when ctx is done
+func (c *Cache) CachePriceForPair(ctx context.Context, p pair.Pair, price float64, timestamp time.Time, source string) error {
+	return c.CachePriceData(ctx, p, PriceData{
 		Price:     price,
 		Timestamp: timestamp,
 		Source:    source,
-	}
+	})
+}
+
+// CachePriceData stores priceData as the latest price of p in Redis, giving up when ctx is done
+func (c *Cache) CachePriceData(ctx context.Context, p pair.Pair, priceData PriceData) error {
+	priceData.Pair = p.String()
 
 	data, err := json.Marshal(priceData)
 	if err != nil {
 		return fmt.Errorf("failed to marshal price data: %w", err)
 	}
 
-	key := "eth_usd_price"
-	err = c.client.Set(c.ctx, key, data, 0).Err() // No expiration for latest price
+	key := priceKey(p)
+	err = c.client.Set(ctx, key, data, 0).Err() // No expiration for latest price
 	if err != nil {
 		return fmt.Errorf("failed to cache price: %w", err)
 	}
@@ -75,11 +111,21 @@ func (c *Cache) CachePrice(price float64, timestamp time.Time, source string) er
 
 // GetCachedPrice retrieves the most recent cached ETH/USD price
 func (c *Cache) GetCachedPrice() (*PriceData, error) {
-	key := "eth_usd_price"
-	data, err := c.client.Get(c.ctx, key).Result()
+	return c.GetCachedPriceWithContext(c.ctx)
+}
+
+// GetCachedPriceWithContext retrieves the most recent cached ETH/USD price, giving up when ctx is done
+func (c *Cache) GetCachedPriceWithContext(ctx context.Context) (*PriceData, error) {
+	return c.GetCachedPriceForPair(ctx, pair.ETHUSD)
+}
+
+// GetCachedPriceForPair retrieves the most recent cached price of p, giving up when ctx is done
+func (c *Cache) GetCachedPriceForPair(ctx context.Context, p pair.Pair) (*PriceData, error) {
+	key := priceKey(p)
+	data, err := c.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, fmt.Errorf("no cached price found")
+			return nil, fmt.Errorf("no cached price found for %s", p)
 		}
 		return nil, fmt.Errorf("failed to get cached price: %w", err)
 	}
@@ -92,9 +138,15 @@ func (c *Cache) GetCachedPrice() (*PriceData, error) {
 	return &priceData, nil
 }
 
-// CachePriceHistory stores historical price data
+// CachePriceHistory stores historical ETH/USD price data
 func (c *Cache) CachePriceHistory(price float64, timestamp time.Time, source string) error {
+	return c.CachePriceHistoryForPair(pair.ETHUSD, price, timestamp, source)
+}
+
+// CachePriceHistoryForPair stores historical price data of p
+func (c *Cache) CachePriceHistoryForPair(p pair.Pair, price float64, timestamp time.Time, source string) error {
 	priceData := PriceData{
+		Pair:      p.String(),
 		Price:     price,
 		Timestamp: timestamp,
 		Source:    source,
@@ -106,7 +158,7 @@ func (c *Cache) CachePriceHistory(price float64, timestamp time.Time, source str
 	}
 
 	// Use timestamp as part of the key for historical data
-	key := fmt.Sprintf("eth_usd_price_history:%d", timestamp.Unix())
+	key := fmt.Sprintf("%s%d", historyKeyPrefix(p), timestamp.Unix())
 
 	// Store with 24 hour expiration for historical data
 	err = c.client.Set(c.ctx, key, data, 24*time.Hour).Err()
@@ -117,9 +169,14 @@ func (c *Cache) CachePriceHistory(price float64, timestamp time.Time, source str
 	return nil
 }
 
-// GetPriceHistory retrieves recent price records from cache
+// GetPriceHistory retrieves recent ETH/USD price records from cache
 func (c *Cache) GetPriceHistory(limit int) ([]PriceData, error) {
-	pattern := "eth_usd_price_history:*"
+	return c.GetPriceHistoryForPair(pair.ETHUSD, limit)
+}
+
+// GetPriceHistoryForPair retrieves recent price records of p from cache
+func (c *Cache) GetPriceHistoryForPair(p pair.Pair, limit int) ([]PriceData, error) {
+	pattern := historyKeyPrefix(p) + "*"
 	keys, err := c.client.Keys(c.ctx, pattern).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get price history keys: %w", err)
@@ -148,7 +205,7 @@ func (c *Cache) GetPriceHistory(limit int) ([]PriceData, error) {
 	return prices, nil
 }
 
-// IsPriceStale checks if the cached price is older than the specified duration
+// IsPriceStale checks if the cached ETH/USD price is older than the specified duration
 func (c *Cache) IsPriceStale(maxAge time.Duration) (bool, error) {
 	priceData, err := c.GetCachedPrice()
 	if err != nil {
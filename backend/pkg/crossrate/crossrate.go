@@ -0,0 +1,247 @@
+package crossrate
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// Leg is the latest price of a component pair a cross rate is derived from
+type Leg struct {
+	Pair  pair.Pair `json:"pair"`
+	Price float64   `json:"price"`
+	// Timestamp is when the oldest quote behind the price was observed
+	Timestamp time.Time `json:"timestamp"`
+	// SpreadRatio is the relative spread of the quotes behind the price, i.e. how far they disagreed
+	SpreadRatio float64 `json:"spread_ratio"`
+	// Sources is the number of quotes behind the price
+	Sources int `json:"sources"`
+	// Lower and Upper bound the confidence band of the price; zero bounds are taken as the price itself
+	Lower float64 `json:"lower,omitempty"`
+	Upper float64 `json:"upper,omitempty"`
+	// Exact is the fixed-point price Price approximates; a zero price is taken as the shortest decimal of Price
+	Exact fixedpoint.Price `json:"-"`
+}
+
+// exact returns the exact price of the leg
+func (l Leg) exact() (*big.Rat, error) {
+	if l.Exact.Sign() > 0 {
+		return l.Exact.Rat(), nil
+	}
+	exact, err := fixedpoint.FromFloatExact(l.Price)
+	if err != nil {
+		return nil, err
+	}
+	return exact.Rat(), nil
+}
+
+// bounds returns the confidence band of the leg, falling back to its price for missing bounds
+func (l Leg) bounds() (float64, float64) {
+	lower, upper := l.Lower, l.Upper
+	if lower <= 0 || lower > l.Price {
+		lower = l.Price
+	}
+	if upper < l.Price {
+		upper = l.Price
+	}
+	return lower, upper
+}
+
+// Result is a derived price together with the legs it was computed from
+type Result struct {
+	Pair  pair.Pair `json:"pair"`
+	Price float64   `json:"price"`
+	// Timestamp is the timestamp of the oldest leg
+	Timestamp time.Time `json:"timestamp"`
+	// SpreadRatio is the combined relative spread of the legs; relative spreads add under
+	// multiplication and division, so a derived price is never more certain than its legs
+	SpreadRatio float64 `json:"spread_ratio"`
+	// Sources is the smallest number of quotes behind any leg
+	Sources int `json:"sources"`
+	// Lower and Upper bound the derived price when each leg moves anywhere within its own band
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Legs  []Leg   `json:"legs"`
+	// Exact is the derived price computed from the exact leg prices, which Price approximates
+	Exact *big.Rat `json:"-"`
+}
+
+// StaleLegError is returned when a leg is missing or too old to derive from
+type StaleLegError struct {
+	Pair   pair.Pair
+	Age    time.Duration
+	MaxAge time.Duration
+}
+
+func (e *StaleLegError) Error() string {
+	if e.Age == 0 {
+		return fmt.Sprintf("no price available for leg %s", e.Pair)
+	}
+	return fmt.Sprintf("leg %s is %v old, exceeding %v", e.Pair, e.Age.Round(time.Second), e.MaxAge)
+}
+
+// step is one leg of a derivation, used as quoted or inverted
+type step struct {
+	index  int
+	invert bool
+}
+
+// from returns the asset the step converts from
+func (s step) from(p pair.Pair) string {
+	if s.invert {
+		return p.Quote
+	}
+	return p.Base
+}
+
+// to returns the asset the step converts to
+func (s step) to(p pair.Pair) string {
+	if s.invert {
+		return p.Base
+	}
+	return p.Quote
+}
+
+// Derivation computes a target pair from two component pairs that share one asset
+// The operation is inferred from the pairs: ETH/EUR from ETH/USD and EUR/USD is ETH/USD ÷ EUR/USD,
+// ETH/BTC from ETH/USD and BTC/USD is ETH/USD ÷ BTC/USD, and BTC/EUR from BTC/USD and USD/EUR is a product
+type Derivation struct {
+	target pair.Pair
+	legs   [2]pair.Pair
+	steps  [2]step
+}
+
+// NewDerivation creates a derivation of target from the legs a and b
+func NewDerivation(target, a, b pair.Pair) (*Derivation, error) {
+	legs := [2]pair.Pair{a, b}
+	for _, leg := range legs {
+		if leg == target {
+			return nil, fmt.Errorf("cannot derive %s from itself", target)
+		}
+	}
+	if a == b {
+		return nil, fmt.Errorf("cannot derive %s from %s twice", target, a)
+	}
+
+	// Find an orientation of the legs that converts the target base into the target quote
+	for _, order := range [][2]int{{0, 1}, {1, 0}} {
+		for _, invertFirst := range []bool{false, true} {
+			for _, invertSecond := range []bool{false, true} {
+				first := step{index: order[0], invert: invertFirst}
+				second := step{index: order[1], invert: invertSecond}
+				firstPair, secondPair := legs[first.index], legs[second.index]
+
+				if first.from(firstPair) == target.Base &&
+					first.to(firstPair) == second.from(secondPair) &&
+					second.to(secondPair) == target.Quote {
+					return &Derivation{
+						target: target,
+						legs:   legs,
+						steps:  [2]step{first, second},
+					}, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cannot derive %s from %s and %s: the legs must share one asset and cover %s and %s",
+		target, a, b, target.Base, target.Quote)
+}
+
+// Pair returns the derived pair
+func (d *Derivation) Pair() pair.Pair {
+	return d.target
+}
+
+// Legs returns the component pairs in the order they were configured
+func (d *Derivation) Legs() [2]pair.Pair {
+	return d.legs
+}
+
+// String describes the derivation, e.g. "ETH/EUR = ETH/USD ÷ EUR/USD"
+func (d *Derivation) String() string {
+	first, second := d.steps[0], d.steps[1]
+	firstPair, secondPair := d.legs[first.index].String(), d.legs[second.index].String()
+
+	var formula string
+	switch {
+	case !first.invert && !second.invert:
+		formula = firstPair + " × " + secondPair
+	case !first.invert && second.invert:
+		formula = firstPair + " ÷ " + secondPair
+	case first.invert && !second.invert:
+		formula = secondPair + " ÷ " + firstPair
+	default:
+		formula = "1 ÷ (" + firstPair + " × " + secondPair + ")"
+	}
+
+	return d.target.String() + " = " + formula
+}
+
+// Source returns the source label of derived prices, e.g. "derived:ETH/USD,EUR/USD"
+func (d *Derivation) Source() string {
+	return "derived:" + strings.Join([]string{d.legs[0].String(), d.legs[1].String()}, ",")
+}
+
+// Derive computes the target price from the latest price of each leg, given in the order of Legs
+// It refuses to derive when either leg is missing or older than maxAge at now
+func (d *Derivation) Derive(legs [2]Leg, now time.Time, maxAge time.Duration) (*Result, error) {
+	result := &Result{
+		Pair:  d.target,
+		Price: 1,
+		Lower: 1,
+		Upper: 1,
+		Legs:  legs[:],
+		Exact: big.NewRat(1, 1),
+	}
+
+	for i, leg := range legs {
+		if leg.Pair != d.legs[i] {
+			return nil, fmt.Errorf("expected leg %s, got %s", d.legs[i], leg.Pair)
+		}
+		if leg.Timestamp.IsZero() {
+			return nil, &StaleLegError{Pair: leg.Pair, MaxAge: maxAge}
+		}
+		if age := now.Sub(leg.Timestamp); age > maxAge {
+			return nil, &StaleLegError{Pair: leg.Pair, Age: age, MaxAge: maxAge}
+		}
+		if leg.Price <= 0 {
+			return nil, fmt.Errorf("invalid price for leg %s: %f", leg.Pair, leg.Price)
+		}
+
+		if result.Timestamp.IsZero() || leg.Timestamp.Before(result.Timestamp) {
+			result.Timestamp = leg.Timestamp
+		}
+		if result.Sources == 0 || leg.Sources < result.Sources {
+			result.Sources = leg.Sources
+		}
+		result.SpreadRatio += leg.SpreadRatio
+	}
+
+	// An inverted leg swaps its bounds, since a lower rate gives a higher derived price
+	for _, s := range d.steps {
+		leg := legs[s.index]
+		exact, err := leg.exact()
+		if err != nil {
+			return nil, fmt.Errorf("invalid price for leg %s: %w", leg.Pair, err)
+		}
+		lower, upper := leg.bounds()
+		if s.invert {
+			result.Price /= leg.Price
+			result.Exact.Quo(result.Exact, exact)
+			result.Lower /= upper
+			result.Upper /= lower
+		} else {
+			result.Price *= leg.Price
+			result.Exact.Mul(result.Exact, exact)
+			result.Lower *= lower
+			result.Upper *= upper
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,298 @@
+package crossrate
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+var (
+	ethUSD = pair.New("ETH", "USD")
+	eurUSD = pair.New("EUR", "USD")
+	btcUSD = pair.New("BTC", "USD")
+	usdEUR = pair.New("USD", "EUR")
+	ethBTC = pair.New("ETH", "BTC")
+)
+
+// approxEqual reports whether a and b agree to within float rounding
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-12*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// leg returns a fresh leg of p at price without a confidence band
+func leg(p pair.Pair, price float64, now time.Time) Leg {
+	return Leg{Pair: p, Price: price, Timestamp: now, Sources: 3}
+}
+
+func TestDeriveOrientations(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		target  pair.Pair
+		a, b    Leg
+		formula string
+		price   float64
+	}{
+		{
+			name:    "quotient",
+			target:  pair.New("ETH", "EUR"),
+			a:       leg(ethUSD, 3000, now),
+			b:       leg(eurUSD, 1.08, now),
+			formula: "ETH/EUR = ETH/USD ÷ EUR/USD",
+			price:   3000 / 1.08,
+		},
+		{
+			name:    "quotient with the legs swapped",
+			target:  pair.New("ETH", "EUR"),
+			a:       leg(eurUSD, 1.08, now),
+			b:       leg(ethUSD, 3000, now),
+			formula: "ETH/EUR = ETH/USD ÷ EUR/USD",
+			price:   3000 / 1.08,
+		},
+		{
+			name:    "quotient of two USD pairs",
+			target:  pair.New("ETH", "BTC"),
+			a:       leg(ethUSD, 3000, now),
+			b:       leg(btcUSD, 60000, now),
+			formula: "ETH/BTC = ETH/USD ÷ BTC/USD",
+			price:   0.05,
+		},
+		{
+			name:    "product",
+			target:  pair.New("BTC", "EUR"),
+			a:       leg(btcUSD, 60000, now),
+			b:       leg(usdEUR, 0.92, now),
+			formula: "BTC/EUR = BTC/USD × USD/EUR",
+			price:   60000 * 0.92,
+		},
+		{
+			name:    "first leg inverted",
+			target:  pair.New("USD", "BTC"),
+			a:       leg(ethUSD, 3000, now),
+			b:       leg(ethBTC, 0.05, now),
+			formula: "USD/BTC = ETH/BTC ÷ ETH/USD",
+			price:   0.05 / 3000,
+		},
+		{
+			name:    "both legs inverted",
+			target:  pair.New("EUR", "ETH"),
+			a:       leg(ethUSD, 3000, now),
+			b:       leg(usdEUR, 0.92, now),
+			formula: "EUR/ETH = 1 ÷ (USD/EUR × ETH/USD)",
+			price:   1 / (0.92 * 3000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			derivation, err := NewDerivation(tt.target, tt.a.Pair, tt.b.Pair)
+			if err != nil {
+				t.Fatalf("NewDerivation() error = %v", err)
+			}
+			if got := derivation.String(); got != tt.formula {
+				t.Errorf("String() = %q, want %q", got, tt.formula)
+			}
+
+			result, err := derivation.Derive([2]Leg{tt.a, tt.b}, now, time.Minute)
+			if err != nil {
+				t.Fatalf("Derive() error = %v", err)
+			}
+			if !approxEqual(result.Price, tt.price) {
+				t.Errorf("Derive() price = %v, want %v", result.Price, tt.price)
+			}
+			// Legs without bounds give a band collapsed onto the price
+			if !approxEqual(result.Lower, tt.price) || !approxEqual(result.Upper, tt.price) {
+				t.Errorf("Derive() band = [%v, %v], want the price %v", result.Lower, result.Upper, tt.price)
+			}
+		})
+	}
+}
+
+func TestNewDerivationRejectsUnrelatedLegs(t *testing.T) {
+	tests := []struct {
+		name   string
+		target pair.Pair
+		a, b   pair.Pair
+	}{
+		{name: "target as a leg", target: ethUSD, a: ethUSD, b: eurUSD},
+		{name: "same leg twice", target: pair.New("ETH", "EUR"), a: ethUSD, b: ethUSD},
+		{name: "no shared asset", target: pair.New("ETH", "EUR"), a: ethBTC, b: usdEUR},
+		{name: "target quote missing", target: pair.New("ETH", "GBP"), a: ethUSD, b: eurUSD},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDerivation(tt.target, tt.a, tt.b); err == nil {
+				t.Errorf("NewDerivation(%s, %s, %s) succeeded, want an error", tt.target, tt.a, tt.b)
+			}
+		})
+	}
+}
+
+func TestDeriveRefusesMissingAndStaleLegs(t *testing.T) {
+	now := time.Now()
+	maxAge := time.Minute
+	derivation, err := NewDerivation(pair.New("ETH", "EUR"), ethUSD, eurUSD)
+	if err != nil {
+		t.Fatalf("NewDerivation() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		legs  [2]Leg
+		stale pair.Pair
+		age   time.Duration
+	}{
+		{name: "missing leg", legs: [2]Leg{leg(ethUSD, 3000, now), {Pair: eurUSD}}, stale: eurUSD},
+		{name: "stale leg", legs: [2]Leg{leg(ethUSD, 3000, now.Add(-2*time.Minute)), leg(eurUSD, 1.08, now)}, stale: ethUSD, age: 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := derivation.Derive(tt.legs, now, maxAge)
+			var staleErr *StaleLegError
+			if !errors.As(err, &staleErr) {
+				t.Fatalf("Derive() error = %v, want a *StaleLegError", err)
+			}
+			if staleErr.Pair != tt.stale || staleErr.Age != tt.age || staleErr.MaxAge != maxAge {
+				t.Errorf("StaleLegError = %+v, want leg %s aged %v past %v", staleErr, tt.stale, tt.age, maxAge)
+			}
+		})
+	}
+
+	// A leg exactly maxAge old is still usable
+	if _, err := derivation.Derive([2]Leg{leg(ethUSD, 3000, now.Add(-maxAge)), leg(eurUSD, 1.08, now)}, now, maxAge); err != nil {
+		t.Errorf("Derive() of a leg maxAge old error = %v", err)
+	}
+
+	// Legs out of order or without a price are errors, not stale legs
+	for _, legs := range [][2]Leg{
+		{leg(eurUSD, 1.08, now), leg(ethUSD, 3000, now)},
+		{leg(ethUSD, 3000, now), leg(eurUSD, 0, now)},
+	} {
+		_, err := derivation.Derive(legs, now, maxAge)
+		var staleErr *StaleLegError
+		if err == nil || errors.As(err, &staleErr) {
+			t.Errorf("Derive(%s, %s) error = %v, want a non-stale error", legs[0].Pair, legs[1].Pair, err)
+		}
+	}
+}
+
+func TestDeriveSwapsBandOfInvertedLegs(t *testing.T) {
+	now := time.Now()
+	derivation, err := NewDerivation(pair.New("ETH", "EUR"), ethUSD, eurUSD)
+	if err != nil {
+		t.Fatalf("NewDerivation() error = %v", err)
+	}
+
+	ethLeg := Leg{Pair: ethUSD, Price: 3000, Timestamp: now, Lower: 2990, Upper: 3010}
+	eurLeg := Leg{Pair: eurUSD, Price: 1.08, Timestamp: now, Lower: 1.07, Upper: 1.09}
+	result, err := derivation.Derive([2]Leg{ethLeg, eurLeg}, now, time.Minute)
+	if err != nil {
+		t.Fatalf("Derive() error = %v", err)
+	}
+
+	// The divisor is inverted, so its upper bound gives the lowest derived price
+	if want := 2990 / 1.09; !approxEqual(result.Lower, want) {
+		t.Errorf("Derive() lower = %v, want %v", result.Lower, want)
+	}
+	if want := 3010 / 1.07; !approxEqual(result.Upper, want) {
+		t.Errorf("Derive() upper = %v, want %v", result.Upper, want)
+	}
+	if result.Lower > result.Price || result.Price > result.Upper {
+		t.Errorf("Derive() price %v outside its band [%v, %v]", result.Price, result.Lower, result.Upper)
+	}
+}
+
+func TestDerivePropagatesSourcesAndSpread(t *testing.T) {
+	now := time.Now()
+	derivation, err := NewDerivation(pair.New("ETH", "EUR"), ethUSD, eurUSD)
+	if err != nil {
+		t.Fatalf("NewDerivation() error = %v", err)
+	}
+
+	ethLeg := Leg{Pair: ethUSD, Price: 3000, Timestamp: now.Add(-10 * time.Second), SpreadRatio: 0.002, Sources: 5}
+	eurLeg := Leg{Pair: eurUSD, Price: 1.08, Timestamp: now.Add(-30 * time.Second), SpreadRatio: 0.0005, Sources: 2}
+	result, err := derivation.Derive([2]Leg{ethLeg, eurLeg}, now, time.Minute)
+	if err != nil {
+		t.Fatalf("Derive() error = %v", err)
+	}
+
+	if result.Sources != 2 {
+		t.Errorf("Derive() sources = %d, want the smaller leg's 2", result.Sources)
+	}
+	if !approxEqual(result.SpreadRatio, 0.0025) {
+		t.Errorf("Derive() spread ratio = %v, want the summed 0.0025", result.SpreadRatio)
+	}
+	if !result.Timestamp.Equal(eurLeg.Timestamp) {
+		t.Errorf("Derive() timestamp = %v, want the oldest leg's %v", result.Timestamp, eurLeg.Timestamp)
+	}
+	if len(result.Legs) != 2 || result.Legs[0].Pair != ethUSD || result.Legs[1].Pair != eurUSD {
+		t.Errorf("Derive() legs = %+v, want ETH/USD and EUR/USD", result.Legs)
+	}
+	if source := derivation.Source(); source != "derived:ETH/USD,EUR/USD" {
+		t.Errorf("Source() = %q, want derived:ETH/USD,EUR/USD", source)
+	}
+}
+
+func TestDeriveIsExact(t *testing.T) {
+	now := time.Now()
+
+	// exactPrice parses s as the exact price of a leg
+	exactPrice := func(s string) fixedpoint.Price {
+		t.Helper()
+		price, err := fixedpoint.ParseExact(s)
+		if err != nil {
+			t.Fatalf("ParseExact(%q) error = %v", s, err)
+		}
+		return price
+	}
+
+	tests := []struct {
+		name   string
+		target pair.Pair
+		a, b   Leg
+		exact  *big.Rat
+	}{
+		{
+			name:   "quotient of exact legs",
+			target: pair.New("ETH", "EUR"),
+			a:      Leg{Pair: ethUSD, Price: 3412.57, Exact: exactPrice("3412.57"), Timestamp: now},
+			b:      Leg{Pair: eurUSD, Price: 1.08, Exact: exactPrice("1.08"), Timestamp: now},
+			exact:  big.NewRat(341257, 108),
+		},
+		{
+			// 0.1 × 0.2 is 0.020000000000000004 in floats
+			name:   "product of float legs",
+			target: pair.New("BTC", "EUR"),
+			a:      leg(btcUSD, 0.1, now),
+			b:      leg(usdEUR, 0.2, now),
+			exact:  big.NewRat(1, 50),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			derivation, err := NewDerivation(tt.target, tt.a.Pair, tt.b.Pair)
+			if err != nil {
+				t.Fatalf("NewDerivation() error = %v", err)
+			}
+			result, err := derivation.Derive([2]Leg{tt.a, tt.b}, now, time.Minute)
+			if err != nil {
+				t.Fatalf("Derive() error = %v", err)
+			}
+			if result.Exact.Cmp(tt.exact) != 0 {
+				t.Errorf("Derive() exact = %s, want %s", result.Exact.RatString(), tt.exact.RatString())
+			}
+			if exact, _ := result.Exact.Float64(); !approxEqual(result.Price, exact) {
+				t.Errorf("Derive() price = %v, want close to the exact %v", result.Price, exact)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package fetcher
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// AggregationMethod selects how contributing quotes are combined into one price
+type AggregationMethod string
+
+const (
+	// AggregationMean is the arithmetic mean of all quotes, weighted by source health when circuit breakers are enabled
+	AggregationMean AggregationMethod = "mean"
+	// AggregationMedian is the median of all quotes, weighted by source health when circuit breakers are enabled
+	AggregationMedian AggregationMethod = "median"
+	// AggregationWeightedMedian is the median with each quote weighted by its source weight and health
+	AggregationWeightedMedian AggregationMethod = "weighted_median"
+)
+
+// OutlierFilter selects the statistical test used to discard outlying quotes
+type OutlierFilter string
+
+const (
+	// OutlierFilterNone keeps every quote
+	OutlierFilterNone OutlierFilter = "none"
+	// OutlierFilterMAD discards quotes more than N scaled median absolute deviations from the median
+	OutlierFilterMAD OutlierFilter = "mad"
+	// OutlierFilterIQR discards quotes more than N interquartile ranges outside the quartiles
+	OutlierFilterIQR OutlierFilter = "iqr"
+)
+
+// Discard reasons reported for quotes that did not contribute to the aggregate
+const (
+	DiscardReasonOutlierMAD = "outlier_mad"
+	DiscardReasonOutlierIQR = "outlier_iqr"
+	DiscardReasonZeroWeight = "zero_weight"
+)
+
+const (
+	// madScale converts a median absolute deviation into a standard deviation estimate for normal data
+	madScale = 1.4826
+	// minOutlierDeviation is the smallest relative deviation treated as an outlier, so that
+	// a handful of identical quotes does not turn every other quote into an outlier
+	minOutlierDeviation = 0.001
+	// minQuotesForOutlierFilter is the number of quotes needed before outliers can be identified
+	minQuotesForOutlierFilter = 3
+	// confidenceZ is the number of standard errors either side of the price covering 95% of a normal distribution
+	confidenceZ = 1.96
+)
+
+// AggregationConfig controls how quotes are aggregated
+type AggregationConfig struct {
+	Method        AggregationMethod
+	OutlierFilter OutlierFilter
+	// OutlierThreshold is the number of scaled MADs or IQRs beyond which a quote is discarded
+	OutlierThreshold float64
+	// Weights holds per-source weights for the weighted median; sources not listed weigh 1
+	Weights map[string]float64
+	// HealthWeights scales each source by its health score in every method; sources not listed weigh 1
+	HealthWeights map[string]float64
+}
+
+// DefaultAggregationConfig returns a median aggregation with MAD outlier rejection
+func DefaultAggregationConfig() AggregationConfig {
+	return AggregationConfig{
+		Method:           AggregationMedian,
+		OutlierFilter:    OutlierFilterMAD,
+		OutlierThreshold: 3,
+	}
+}
+
+// Validate checks that the aggregation config is usable
+func (c AggregationConfig) Validate() error {
+	switch c.Method {
+	case AggregationMean, AggregationMedian, AggregationWeightedMedian:
+	default:
+		return fmt.Errorf("unknown aggregation method %q", c.Method)
+	}
+
+	switch c.OutlierFilter {
+	case OutlierFilterNone, OutlierFilterMAD, OutlierFilterIQR:
+	default:
+		return fmt.Errorf("unknown outlier filter %q", c.OutlierFilter)
+	}
+
+	if c.OutlierFilter != OutlierFilterNone && c.OutlierThreshold <= 0 {
+		return fmt.Errorf("outlier threshold must be positive, got: %f", c.OutlierThreshold)
+	}
+
+	for source, weight := range c.Weights {
+		if weight < 0 {
+			return fmt.Errorf("weight for source %s must not be negative, got: %f", source, weight)
+		}
+	}
+	for source, weight := range c.HealthWeights {
+		if weight < 0 {
+			return fmt.Errorf("health weight for source %s must not be negative, got: %f", source, weight)
+		}
+	}
+
+	return nil
+}
+
+// weight returns the weight of a source: its configured weight in the weighted median, scaled by its health
+func (c AggregationConfig) weight(source string) float64 {
+	weight := 1.0
+	if configured, ok := c.Weights[source]; ok && c.Method == AggregationWeightedMedian {
+		weight = configured
+	}
+	if health, ok := c.HealthWeights[source]; ok {
+		weight *= health
+	}
+	return weight
+}
+
+// DiscardedQuote is a quote left out of the aggregate, with the reason why
+type DiscardedQuote struct {
+	Quote  Quote  `json:"quote"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+// AggregateResult is the outcome of aggregating a set of quotes
+type AggregateResult struct {
+	Price float64 `json:"price"`
+	// Exact is the median exactly as the sources reported it, or zero when the price was averaged
+	Exact     fixedpoint.Price  `json:"-"`
+	Method    AggregationMethod `json:"method"`
+	Quotes    []Quote           `json:"quotes"`
+	Discarded []DiscardedQuote  `json:"discarded,omitempty"`
+	// Sources is the number of quotes that contributed to the price
+	Sources int `json:"sources"`
+	// Spread is the difference between the highest and lowest contributing quote
+	Spread float64 `json:"spread"`
+	// SpreadRatio is the spread relative to the aggregated price
+	SpreadRatio float64 `json:"spread_ratio"`
+	// Confidence is the band the price is expected to lie in
+	Confidence ConfidenceBand `json:"confidence"`
+}
+
+// ConfidenceBand bounds how uncertain an aggregated price is
+// The band covers both the range of the contributing quotes and a 95% interval of the standard error
+// around the price, so it is never narrower than the disagreement between sources
+type ConfidenceBand struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	// StdError is the standard error of the mean of the contributing quotes, zero for a single quote
+	StdError float64 `json:"std_error"`
+}
+
+// Width returns the distance between the bounds of the band
+func (b ConfidenceBand) Width() float64 {
+	return b.Upper - b.Lower
+}
+
+// WidthRatio returns the width of the band relative to price, zero when the price is not positive
+func (b ConfidenceBand) WidthRatio(price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	return b.Width() / price
+}
+
+// Aggregate combines quotes into a single price, discarding outliers according to config
+func Aggregate(quotes []Quote, config AggregationConfig) (*AggregateResult, error) {
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("no quotes to aggregate")
+	}
+
+	result := &AggregateResult{Method: config.Method}
+
+	// Drop sources that have been weighted out entirely, by configuration or by their health
+	var candidates []Quote
+	for _, quote := range quotes {
+		if config.weight(quote.Source) == 0 {
+			detail := "source weight is zero"
+			if health, ok := config.HealthWeights[quote.Source]; ok && health == 0 {
+				detail = "source health score is zero"
+			}
+			result.Discarded = append(result.Discarded, DiscardedQuote{
+				Quote:  quote,
+				Reason: DiscardReasonZeroWeight,
+				Detail: detail,
+			})
+			continue
+		}
+		candidates = append(candidates, quote)
+	}
+
+	contributing, discarded := filterOutliers(candidates, config)
+	result.Discarded = append(result.Discarded, discarded...)
+
+	if len(contributing) == 0 {
+		return nil, fmt.Errorf("all %d quotes were discarded", len(quotes))
+	}
+
+	prices := quotePrices(contributing)
+	weights := make([]float64, len(contributing))
+	for i, quote := range contributing {
+		weights[i] = config.weight(quote.Source)
+	}
+	switch config.Method {
+	case AggregationMean:
+		result.Price = weightedMean(prices, weights)
+	default:
+		// With equal weights the weighted median is the plain median; it is taken over the exact prices,
+		// which floats may not tell apart
+		exact := make([]fixedpoint.Price, len(contributing))
+		for i, quote := range contributing {
+			exact[i] = quote.ExactPrice()
+		}
+		lower, upper := weightedMedianIndices(weights, func(i, j int) bool { return exact[i].Cmp(exact[j]) < 0 })
+		result.Exact = exact[lower].Midpoint(exact[upper])
+		result.Price = result.Exact.Float64()
+	}
+
+	sort.Float64s(prices)
+	result.Quotes = contributing
+	result.Sources = len(contributing)
+	result.Spread = prices[len(prices)-1] - prices[0]
+	if result.Price > 0 {
+		result.SpreadRatio = result.Spread / result.Price
+	}
+	result.Confidence = confidenceBand(result.Price, prices)
+
+	return result, nil
+}
+
+// ExactPrice returns the exact aggregated price, falling back to the float's shortest decimal
+func (r *AggregateResult) ExactPrice() fixedpoint.Price {
+	if r.Exact.Sign() > 0 {
+		return r.Exact
+	}
+	exact, err := fixedpoint.FromFloatExact(r.Price)
+	if err != nil {
+		return fixedpoint.Price{}
+	}
+	return exact
+}
+
+// confidenceBand returns the band around price covering the sorted contributing prices and a 95% interval
+// of their standard error
+func confidenceBand(price float64, sorted []float64) ConfidenceBand {
+	band := ConfidenceBand{
+		Lower:    math.Min(price, sorted[0]),
+		Upper:    math.Max(price, sorted[len(sorted)-1]),
+		StdError: standardError(sorted),
+	}
+	band.Lower = math.Min(band.Lower, price-confidenceZ*band.StdError)
+	band.Upper = math.Max(band.Upper, price+confidenceZ*band.StdError)
+	return band
+}
+
+// standardError returns the standard error of the mean of values, using the sample standard deviation
+func standardError(values []float64) float64 {
+	n := len(values)
+	if n < 2 {
+		return 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(n)
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(squares/float64(n-1)) / math.Sqrt(float64(n))
+}
+
+// filterOutliers splits quotes into those that pass the configured outlier test and those that do not
+func filterOutliers(quotes []Quote, config AggregationConfig) ([]Quote, []DiscardedQuote) {
+	if config.OutlierFilter == OutlierFilterNone || len(quotes) < minQuotesForOutlierFilter {
+		return quotes, nil
+	}
+
+	prices := quotePrices(quotes)
+	center := median(prices)
+	floor := center * minOutlierDeviation
+
+	var lower, upper float64
+	var reason string
+	switch config.OutlierFilter {
+	case OutlierFilterIQR:
+		q1, q3 := quartiles(prices)
+		iqr := math.Max(q3-q1, floor)
+		lower, upper = q1-config.OutlierThreshold*iqr, q3+config.OutlierThreshold*iqr
+		reason = DiscardReasonOutlierIQR
+	default:
+		deviations := make([]float64, len(prices))
+		for i, price := range prices {
+			deviations[i] = math.Abs(price - center)
+		}
+		scaledMAD := math.Max(madScale*median(deviations), floor)
+		lower, upper = center-config.OutlierThreshold*scaledMAD, center+config.OutlierThreshold*scaledMAD
+		reason = DiscardReasonOutlierMAD
+	}
+
+	var kept []Quote
+	var discarded []DiscardedQuote
+	for _, quote := range quotes {
+		if quote.Price >= lower && quote.Price <= upper {
+			kept = append(kept, quote)
+			continue
+		}
+		discarded = append(discarded, DiscardedQuote{
+			Quote:  quote,
+			Reason: reason,
+			Detail: fmt.Sprintf("price %.8f deviates %.2f%% from median %.8f, outside [%.8f, %.8f]",
+				quote.Price, math.Abs(quote.Price-center)/center*100, center, lower, upper),
+		})
+	}
+
+	return kept, discarded
+}
+
+// quotePrices extracts the prices of a set of quotes
+func quotePrices(quotes []Quote) []float64 {
+	prices := make([]float64, len(quotes))
+	for i, quote := range quotes {
+		prices[i] = quote.Price
+	}
+	return prices
+}
+
+// median returns the median of values without modifying the input
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// quartiles returns the first and third quartiles using linear interpolation
+func quartiles(values []float64) (float64, float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 0.25), percentile(sorted, 0.75)
+}
+
+// percentile returns the p-th percentile of already sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// weightedMean returns the mean of values weighted by weights, or their plain mean when no weight is positive
+func weightedMean(values, weights []float64) float64 {
+	var sum, total float64
+	for i, value := range values {
+		sum += value * weights[i]
+		total += weights[i]
+	}
+	if total <= 0 {
+		return AggregatePrices(values)
+	}
+	return sum / total
+}
+
+// weightedMedianIndices returns the indices of the values the weighted median is the midpoint of, with
+// values ordered by less; both are the same index unless the halfway point falls exactly between two values
+// Without positive weights it returns those of the plain median
+func weightedMedianIndices(weights []float64, less func(i, j int) bool) (int, int) {
+	order := make([]int, len(weights))
+	var total float64
+	for i, weight := range weights {
+		order[i] = i
+		total += weight
+	}
+	sort.SliceStable(order, func(i, j int) bool { return less(order[i], order[j]) })
+
+	n := len(order)
+	if total <= 0 {
+		if n%2 == 1 {
+			return order[n/2], order[n/2]
+		}
+		return order[n/2-1], order[n/2]
+	}
+
+	half := total / 2
+	var cumulative float64
+	for i, index := range order {
+		cumulative += weights[index]
+		if cumulative > half {
+			return index, index
+		}
+		if cumulative == half && i+1 < n {
+			return index, order[i+1]
+		}
+	}
+
+	return order[n-1], order[n-1]
+}
@@ -0,0 +1,223 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// quotesOf builds one quote per source at the given prices
+func quotesOf(prices map[string]float64) []Quote {
+	var quotes []Quote
+	for source, price := range prices {
+		quotes = append(quotes, Quote{Source: source, Price: price})
+	}
+	return quotes
+}
+
+func TestAggregateAppliesHealthWeightsInEveryMethod(t *testing.T) {
+	quotes := quotesOf(map[string]float64{"a": 100, "b": 101, "c": 110})
+	health := map[string]float64{"a": 0.1, "b": 0.1, "c": 1}
+
+	tests := []struct {
+		method AggregationMethod
+		price  float64
+	}{
+		// c carries most of the weight, so it becomes the median
+		{method: AggregationMedian, price: 110},
+		{method: AggregationWeightedMedian, price: 110},
+		{method: AggregationMean, price: (100*0.1 + 101*0.1 + 110) / 1.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			config := AggregationConfig{Method: tt.method, OutlierFilter: OutlierFilterNone, HealthWeights: health}
+			result, err := Aggregate(quotes, config)
+			if err != nil {
+				t.Fatalf("Aggregate() error = %v", err)
+			}
+			if diff := result.Price - tt.price; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Price = %v, want %v", result.Price, tt.price)
+			}
+		})
+	}
+}
+
+func TestAggregateWithoutHealthWeights(t *testing.T) {
+	quotes := quotesOf(map[string]float64{"a": 100, "b": 101, "c": 110, "d": 111})
+
+	tests := []struct {
+		method AggregationMethod
+		price  float64
+	}{
+		{method: AggregationMedian, price: 105.5},
+		{method: AggregationMean, price: 105.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			// Configured weights only apply to the weighted median
+			config := AggregationConfig{
+				Method:        tt.method,
+				OutlierFilter: OutlierFilterNone,
+				Weights:       map[string]float64{"a": 10},
+			}
+			result, err := Aggregate(quotes, config)
+			if err != nil {
+				t.Fatalf("Aggregate() error = %v", err)
+			}
+			if result.Price != tt.price {
+				t.Errorf("Price = %v, want %v", result.Price, tt.price)
+			}
+		})
+	}
+}
+
+func TestAggregateDiscardsZeroHealthSources(t *testing.T) {
+	quotes := quotesOf(map[string]float64{"a": 100, "b": 102, "c": 150})
+	config := AggregationConfig{
+		Method:        AggregationMedian,
+		OutlierFilter: OutlierFilterNone,
+		HealthWeights: map[string]float64{"c": 0},
+	}
+
+	result, err := Aggregate(quotes, config)
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if result.Price != 101 {
+		t.Errorf("Price = %v, want 101", result.Price)
+	}
+	if len(result.Discarded) != 1 || result.Discarded[0].Quote.Source != "c" || result.Discarded[0].Reason != DiscardReasonZeroWeight {
+		t.Errorf("Discarded = %+v, want c discarded for zero weight", result.Discarded)
+	}
+}
+
+func TestAggregateMedianIsExact(t *testing.T) {
+	exactQuote := func(source, price string) Quote {
+		exact, err := fixedpoint.ParseExact(price)
+		if err != nil {
+			t.Fatalf("ParseExact(%q) error = %v", price, err)
+		}
+		return Quote{Source: source, Price: exact.Float64(), Exact: exact}
+	}
+
+	tests := []struct {
+		name   string
+		quotes []Quote
+		method AggregationMethod
+		exact  string
+	}{
+		{
+			// The float midpoint of 0.1 and 0.2 is 0.15000000000000002
+			name:   "midpoint of two quotes",
+			quotes: []Quote{exactQuote("a", "0.1"), exactQuote("b", "0.2")},
+			method: AggregationMedian,
+			exact:  "0.15",
+		},
+		{
+			name:   "middle quote",
+			quotes: []Quote{exactQuote("a", "1999.99999999999999999"), exactQuote("b", "1999.99999999999999998"), exactQuote("c", "2000.1")},
+			method: AggregationMedian,
+			exact:  "1999.99999999999999999",
+		},
+		{
+			name:   "weighted median",
+			quotes: []Quote{exactQuote("a", "3412.57"), exactQuote("b", "3412.58")},
+			method: AggregationWeightedMedian,
+			exact:  "3412.575",
+		},
+		{
+			// Quotes without an exact price count at their float's shortest decimal
+			name:   "float quotes",
+			quotes: []Quote{{Source: "a", Price: 3412.57}, exactQuote("b", "3412.58")},
+			method: AggregationMedian,
+			exact:  "3412.575",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Aggregate(tt.quotes, AggregationConfig{Method: tt.method, OutlierFilter: OutlierFilterNone})
+			if err != nil {
+				t.Fatalf("Aggregate() error = %v", err)
+			}
+			if exact := result.ExactPrice().String(); exact != tt.exact {
+				t.Errorf("ExactPrice() = %s, want %s", exact, tt.exact)
+			}
+		})
+	}
+
+	// A mean is not exact, so its exact price is the float's
+	result, err := Aggregate([]Quote{exactQuote("a", "0.1"), exactQuote("b", "0.2")}, AggregationConfig{Method: AggregationMean, OutlierFilter: OutlierFilterNone})
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if result.Exact.Sign() != 0 || result.ExactPrice().Float64() != result.Price {
+		t.Errorf("mean ExactPrice() = %s, want the float price %v", result.ExactPrice(), result.Price)
+	}
+}
+
+// fixedSource returns the same price, or error, on every fetch
+type fixedSource struct {
+	name  string
+	price float64
+	err   error
+}
+
+func (s *fixedSource) Name() string {
+	return s.name
+}
+
+func (s *fixedSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Quote{Source: s.name, Price: s.price, Timestamp: time.Now()}, nil
+}
+
+func TestFetcherWeightsMedianBySourceHealth(t *testing.T) {
+	flaky := &fixedSource{name: "flaky", price: 90}
+	sources := []PriceSource{
+		flaky,
+		&fixedSource{name: "steady", price: 100},
+	}
+	f := NewFetcherWithSources(sources, time.Second)
+	config := DefaultBreakerConfig()
+	config.FailureThreshold = 100
+	if err := f.EnableCircuitBreakers(config); err != nil {
+		t.Fatalf("EnableCircuitBreakers() error = %v", err)
+	}
+	if err := f.SetAggregationConfig(AggregationConfig{Method: AggregationMedian, OutlierFilter: OutlierFilterNone}); err != nil {
+		t.Fatalf("SetAggregationConfig() error = %v", err)
+	}
+
+	// Healthy sources weigh the same, so the median is their midpoint
+	report, _ := f.FetchQuotes(context.Background())
+	result, err := f.Aggregate(report.Quotes())
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if result.Price != 95 {
+		t.Fatalf("Price = %v, want 95", result.Price)
+	}
+
+	// Failures lower the health of the flaky source, so the steady source decides the median
+	flaky.err = errors.New("unavailable")
+	for range 3 {
+		f.FetchQuotes(context.Background())
+	}
+	flaky.err = nil
+
+	report, _ = f.FetchQuotes(context.Background())
+	result, err = f.Aggregate(report.Quotes())
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if result.Price != 100 {
+		t.Errorf("Price = %v, want 100 once the flaky source lost health", result.Price)
+	}
+}
@@ -0,0 +1,158 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultAggregatorV3MaxAge is how old a reference round may be unless the source configures otherwise
+const DefaultAggregatorV3MaxAge = time.Hour
+
+// aggregatorV3ABI is the subset of the AggregatorV3Interface the reader uses
+const aggregatorV3ABI = `[
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"latestRoundData","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},
+	            {"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]}
+]`
+
+var aggregatorV3ContractABI = mustParseABI(aggregatorV3ABI)
+
+// AggregatorV3Round is the latest round reported by an AggregatorV3-compatible feed
+type AggregatorV3Round struct {
+	RoundID         *big.Int  `json:"round_id"`
+	Answer          *big.Int  `json:"answer"`
+	Decimals        int       `json:"decimals"`
+	Price           float64   `json:"price"`
+	StartedAt       time.Time `json:"started_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	AnsweredInRound *big.Int  `json:"answered_in_round"`
+}
+
+// AggregatorV3Source reads the latest round of an AggregatorV3-compatible reference feed
+// such as a Chainlink price feed; quotes are observed at the round's updatedAt
+type AggregatorV3Source struct {
+	name     string
+	feed     common.Address
+	maxAge   time.Duration
+	contract *bind.BoundContract
+
+	mu       sync.Mutex
+	decimals *int
+}
+
+// NewAggregatorV3Source creates a reader for the feed at address that rejects rounds older than maxAge
+func NewAggregatorV3Source(name string, feed common.Address, caller bind.ContractCaller, maxAge time.Duration) (*AggregatorV3Source, error) {
+	if feed == (common.Address{}) {
+		return nil, fmt.Errorf("feed address is required")
+	}
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("max age must be positive, got: %v", maxAge)
+	}
+
+	return &AggregatorV3Source{
+		name:     name,
+		feed:     feed,
+		maxAge:   maxAge,
+		contract: bind.NewBoundContract(feed, aggregatorV3ContractABI, caller, nil, nil),
+	}, nil
+}
+
+// Name returns the source name
+func (s *AggregatorV3Source) Name() string {
+	return s.name
+}
+
+// FetchPrice returns the answer of the latest round, rejecting incomplete and stale rounds
+func (s *AggregatorV3Source) FetchPrice(ctx context.Context) (*Quote, error) {
+	round, err := s.LatestRound(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if round.UpdatedAt.IsZero() || round.AnsweredInRound.Cmp(round.RoundID) < 0 {
+		return nil, newSourceError(ErrorClassStale, 0, fmt.Errorf("round %s of %s is incomplete", round.RoundID, s.name))
+	}
+	if age := time.Since(round.UpdatedAt); age > s.maxAge {
+		return nil, newSourceError(ErrorClassStale, 0, fmt.Errorf("latest round of %s is %v old", s.name, age.Round(time.Second)))
+	}
+	if round.Price <= 0 {
+		return nil, newSourceError(ErrorClassInvalidPrice, 0, fmt.Errorf("invalid price received from %s: %f", s.name, round.Price))
+	}
+
+	return &Quote{
+		Source:     s.name,
+		Price:      round.Price,
+		Exact:      fixedpoint.New(round.Answer, round.Decimals),
+		Timestamp:  time.Now(),
+		ObservedAt: round.UpdatedAt,
+	}, nil
+}
+
+// LatestRound reads latestRoundData() and scales the answer by the feed's decimals
+func (s *AggregatorV3Source) LatestRound(ctx context.Context) (*AggregatorV3Round, error) {
+	decimals, err := s.feedDecimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []interface{}
+	if err := s.contract.Call(&bind.CallOpts{Context: ctx}, &out, "latestRoundData"); err != nil {
+		return nil, contractCallError(s.name, "latestRoundData", err)
+	}
+	if len(out) != 5 {
+		return nil, newSourceError(ErrorClassDecode, 0, fmt.Errorf("unexpected latestRoundData() result from %s", s.name))
+	}
+
+	answer := *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	startedAt := *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	updatedAt := *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+
+	price, _ := new(big.Float).Quo(new(big.Float).SetInt(answer), big.NewFloat(math.Pow10(decimals))).Float64()
+
+	return &AggregatorV3Round{
+		RoundID:         *abi.ConvertType(out[0], new(*big.Int)).(**big.Int),
+		Answer:          answer,
+		Decimals:        decimals,
+		Price:           price,
+		StartedAt:       unixTime(startedAt),
+		UpdatedAt:       unixTime(updatedAt),
+		AnsweredInRound: *abi.ConvertType(out[4], new(*big.Int)).(**big.Int),
+	}, nil
+}
+
+// feedDecimals reads the feed's decimals once and caches them
+func (s *AggregatorV3Source) feedDecimals(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.decimals != nil {
+		return *s.decimals, nil
+	}
+
+	var out []interface{}
+	if err := s.contract.Call(&bind.CallOpts{Context: ctx}, &out, "decimals"); err != nil {
+		return 0, contractCallError(s.name, "decimals", err)
+	}
+	decimals := int(*abi.ConvertType(out[0], new(uint8)).(*uint8))
+
+	s.decimals = &decimals
+	return decimals, nil
+}
+
+// unixTime converts an on-chain timestamp to a time, leaving zero as the zero time
+func unixTime(seconds *big.Int) time.Time {
+	if seconds == nil || seconds.Sign() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds.Int64(), 0)
+}
@@ -0,0 +1,192 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var testFeed = common.HexToAddress("0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419")
+
+// feedCaller fakes an AggregatorV3 feed with the given decimals and latest round
+func feedCaller(decimals uint8, roundID int64, answer *big.Int, updatedAt time.Time, answeredInRound int64) *fakeCaller {
+	var updated int64
+	if !updatedAt.IsZero() {
+		updated = updatedAt.Unix()
+	}
+	return &fakeCaller{contracts: map[common.Address]*fakeContract{
+		testFeed: {
+			abi: aggregatorV3ContractABI,
+			results: map[string][]interface{}{
+				"decimals": {decimals},
+				"latestRoundData": {
+					big.NewInt(roundID), answer, big.NewInt(updated), big.NewInt(updated), big.NewInt(answeredInRound),
+				},
+			},
+		},
+	}}
+}
+
+// tokenAmount returns whole * 10^decimals + fraction
+func tokenAmount(whole int64, fraction int64, decimals int) *big.Int {
+	amount := new(big.Int).Mul(big.NewInt(whole), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	return amount.Add(amount, big.NewInt(fraction))
+}
+
+func TestAggregatorV3SourceScalesByDecimals(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		decimals uint8
+		answer   *big.Int
+		price    float64
+	}{
+		{name: "8 decimals", decimals: 8, answer: big.NewInt(341257000000), price: 3412.57},
+		{name: "18 decimals", decimals: 18, answer: tokenAmount(3412, 570000000000000000, 18), price: 3412.57},
+		{name: "no decimals", decimals: 0, answer: big.NewInt(3412), price: 3412},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewAggregatorV3Source("chainlink", testFeed, feedCaller(tt.decimals, 7, tt.answer, updatedAt, 7), time.Hour)
+			if err != nil {
+				t.Fatalf("NewAggregatorV3Source() error = %v", err)
+			}
+
+			quote, err := source.FetchPrice(context.Background())
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			if quote.Price != tt.price {
+				t.Errorf("Price = %v, want %v", quote.Price, tt.price)
+			}
+			if !quote.ObservedAt.Equal(updatedAt) {
+				t.Errorf("ObservedAt = %v, want the round's updatedAt %v", quote.ObservedAt, updatedAt)
+			}
+		})
+	}
+}
+
+func TestAggregatorV3SourceRejectsUnusableRounds(t *testing.T) {
+	now := time.Now()
+	answer := big.NewInt(341257000000)
+
+	tests := []struct {
+		name            string
+		roundID         int64
+		answer          *big.Int
+		updatedAt       time.Time
+		answeredInRound int64
+		class           ErrorClass
+	}{
+		{name: "stale round", roundID: 7, answer: answer, updatedAt: now.Add(-2 * time.Hour), answeredInRound: 7, class: ErrorClassStale},
+		{name: "carried over answer", roundID: 7, answer: answer, updatedAt: now, answeredInRound: 6, class: ErrorClassStale},
+		{name: "round never updated", roundID: 7, answer: answer, answeredInRound: 7, class: ErrorClassStale},
+		{name: "zero answer", roundID: 7, answer: big.NewInt(0), updatedAt: now, answeredInRound: 7, class: ErrorClassInvalidPrice},
+		{name: "negative answer", roundID: 7, answer: big.NewInt(-1), updatedAt: now, answeredInRound: 7, class: ErrorClassInvalidPrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller := feedCaller(8, tt.roundID, tt.answer, tt.updatedAt, tt.answeredInRound)
+			source, err := NewAggregatorV3Source("chainlink", testFeed, caller, time.Hour)
+			if err != nil {
+				t.Fatalf("NewAggregatorV3Source() error = %v", err)
+			}
+
+			quote, err := source.FetchPrice(context.Background())
+			if err == nil {
+				t.Fatalf("FetchPrice() = %+v, want an error", quote)
+			}
+			if class, _ := ClassifyError(err); class != tt.class {
+				t.Errorf("error class = %q, want %q (error: %v)", class, tt.class, err)
+			}
+		})
+	}
+}
+
+func TestAggregatorV3SourceAcceptsRoundsAnsweredLater(t *testing.T) {
+	// answeredInRound above roundId happens on proxies and is not a carried over answer
+	source, err := NewAggregatorV3Source("chainlink", testFeed, feedCaller(8, 7, big.NewInt(341257000000), time.Now(), 8), time.Hour)
+	if err != nil {
+		t.Fatalf("NewAggregatorV3Source() error = %v", err)
+	}
+	if _, err := source.FetchPrice(context.Background()); err != nil {
+		t.Errorf("FetchPrice() error = %v", err)
+	}
+}
+
+func TestAggregatorV3SourceCachesDecimals(t *testing.T) {
+	caller := feedCaller(8, 7, big.NewInt(341257000000), time.Now(), 7)
+	source, err := NewAggregatorV3Source("chainlink", testFeed, caller, time.Hour)
+	if err != nil {
+		t.Fatalf("NewAggregatorV3Source() error = %v", err)
+	}
+	if _, err := source.FetchPrice(context.Background()); err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+
+	// A later change of the faked decimals is not read again
+	caller.contracts[testFeed].results["decimals"] = []interface{}{uint8(18)}
+	quote, err := source.FetchPrice(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	if quote.Price != 3412.57 {
+		t.Errorf("Price = %v, want 3412.57 with the cached decimals", quote.Price)
+	}
+
+	caller.contracts[testFeed].err = errors.New("connection refused")
+	_, err = source.FetchPrice(context.Background())
+	if class, _ := ClassifyError(err); class != ErrorClassRPC {
+		t.Errorf("error class = %q, want %q (error: %v)", class, ErrorClassRPC, err)
+	}
+}
+
+func TestReferenceGuardChecksAgainstFeed(t *testing.T) {
+	source, err := NewAggregatorV3Source("chainlink", testFeed, feedCaller(8, 7, big.NewInt(300000000000), time.Now(), 7), time.Hour)
+	if err != nil {
+		t.Fatalf("NewAggregatorV3Source() error = %v", err)
+	}
+	guard, err := NewReferenceGuard(source, 0.02)
+	if err != nil {
+		t.Fatalf("NewReferenceGuard() error = %v", err)
+	}
+
+	tests := []struct {
+		price   float64
+		deviant bool
+	}{
+		{price: 3000, deviant: false},
+		{price: 3050, deviant: false},
+		{price: 3100, deviant: true},
+		{price: 2900, deviant: true},
+	}
+	for _, tt := range tests {
+		check, err := guard.Check(context.Background(), tt.price)
+		var deviation *ReferenceDeviationError
+		if deviant := errors.As(err, &deviation); deviant != tt.deviant {
+			t.Errorf("Check(%v) error = %v, want deviant %v", tt.price, err, tt.deviant)
+		}
+		if check == nil || check.Reference != 3000 {
+			t.Errorf("Check(%v) = %+v, want the 3000 reference", tt.price, check)
+		}
+	}
+
+	// An unreadable reference is reported as such, without a check
+	stale, err := NewAggregatorV3Source("chainlink", testFeed, feedCaller(8, 7, big.NewInt(300000000000), time.Now().Add(-2*time.Hour), 7), time.Hour)
+	if err != nil {
+		t.Fatalf("NewAggregatorV3Source() error = %v", err)
+	}
+	guard, _ = NewReferenceGuard(stale, 0.02)
+	check, err := guard.Check(context.Background(), 3000)
+	var deviation *ReferenceDeviationError
+	if err == nil || errors.As(err, &deviation) || check != nil {
+		t.Errorf("Check() against a stale reference = %+v, %v, want a read error", check, err)
+	}
+}
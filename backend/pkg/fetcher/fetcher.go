@@ -1,117 +1,305 @@
 package fetcher
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
 )
 
-// PriceResponse represents the response structure from CoinGecko API
-type PriceResponse struct {
-	Ethereum struct {
-		USD float64 `json:"usd"`
-	} `json:"ethereum"`
-}
+// PriceResponse represents the response structure from CoinGecko API, keyed by coin ID and then
+// by quote currency, e.g. {"ethereum": {"usd": 3000}}; numbers are kept as written so prices stay exact
+type PriceResponse map[string]map[string]json.Number
 
-// Fetcher handles fetching ETH/USD prices from external APIs
+// Fetcher handles fetching the price of one pair from external APIs
 type Fetcher struct {
-	client  *http.Client
-	apiURL  string
-	timeout time.Duration
+	pair        pair.Pair
+	client      *http.Client
+	apiURL      string
+	timeout     time.Duration
+	sources     []PriceSource
+	aggregation AggregationConfig
+	quorum      int
 }
 
-// NewFetcher creates a new fetcher instance
+// NewFetcher creates a new fetcher instance backed by a single CoinGecko endpoint
 func NewFetcher(apiURL string, timeout time.Duration) *Fetcher {
+	client := &http.Client{
+		Timeout: timeout,
+	}
+
+	return &Fetcher{
+		pair:        pair.ETHUSD,
+		client:      client,
+		apiURL:      apiURL,
+		timeout:     timeout,
+		sources:     []PriceSource{NewCoinGeckoSource("coingecko", apiURL, client)},
+		aggregation: DefaultAggregationConfig(),
+		quorum:      1,
+	}
+}
+
+// NewFetcherWithSources creates an ETH/USD fetcher that queries the given price sources
+func NewFetcherWithSources(sources []PriceSource, timeout time.Duration) *Fetcher {
+	return NewFetcherForPair(pair.ETHUSD, sources, timeout)
+}
+
+// NewFetcherForPair creates a fetcher that queries the given price sources for p
+func NewFetcherForPair(p pair.Pair, sources []PriceSource, timeout time.Duration) *Fetcher {
 	return &Fetcher{
+		pair: p,
 		client: &http.Client{
 			Timeout: timeout,
 		},
-		apiURL:  apiURL,
-		timeout: timeout,
+		timeout:     timeout,
+		sources:     sources,
+		aggregation: DefaultAggregationConfig(),
+		quorum:      1,
 	}
 }
 
-// FetchETHUSDPrice fetches the latest ETH/USD price from the configured API
+// FetchETHUSDPrice fetches the latest ETH/USD price from the configured CoinGecko API
 func (f *Fetcher) FetchETHUSDPrice() (float64, error) {
-	req, err := http.NewRequest("GET", f.apiURL, nil)
+	return f.FetchETHUSDPriceWithContext(context.Background())
+}
+
+// FetchETHUSDPriceWithContext fetches the latest ETH/USD price, aborting the request when ctx is done
+func (f *Fetcher) FetchETHUSDPriceWithContext(ctx context.Context) (float64, error) {
+	quote, err := NewCoinGeckoSource("coingecko", f.apiURL, f.client).FetchPrice(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return 0, err
 	}
 
-	// Set headers for better API compatibility
-	req.Header.Set("User-Agent", "DeFiOraclePipeline/1.0")
-	req.Header.Set("Accept", "application/json")
+	return quote.Price, nil
+}
 
-	resp, err := f.client.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("failed to fetch price: %w", err)
+// FetchQuotes fetches a quote from every configured source concurrently
+// The report lists the outcome of every source; a *QuorumError is returned alongside it
+// when fewer sources than the configured quorum returned a quote
+func (f *Fetcher) FetchQuotes(ctx context.Context) (*FetchReport, error) {
+	if len(f.sources) == 0 {
+		return nil, fmt.Errorf("no price sources configured")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("API returned status %d", resp.StatusCode)
+	report := &FetchReport{
+		Results:  fetchAll(ctx, f.sources),
+		Required: f.quorum,
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	if !report.QuorumMet() {
+		return report, &QuorumError{
+			Succeeded: report.Succeeded(),
+			Required:  report.Required,
+			Total:     len(report.Results),
+			Skipped:   len(report.Skipped()),
+			Failures:  report.Failed(),
+		}
+	}
+
+	return report, nil
+}
+
+// SetQuorum sets the minimum number of sources that must return a quote
+func (f *Fetcher) SetQuorum(quorum int) error {
+	if quorum < 1 || quorum > len(f.sources) {
+		return fmt.Errorf("quorum must be between 1 and %d, got: %d", len(f.sources), quorum)
+	}
+	f.quorum = quorum
+	return nil
+}
+
+// GetQuorum returns the minimum number of sources that must return a quote
+func (f *Fetcher) GetQuorum() int {
+	return f.quorum
+}
+
+// Aggregate combines quotes into a single price using the fetcher's aggregation config
+// Sources are weighted by their health scores in every method when circuit breakers are enabled,
+// and each source's deviation from the resulting consensus is fed back into its health score
+func (f *Fetcher) Aggregate(quotes []Quote) (*AggregateResult, error) {
+	config := f.aggregation
+
+	guarded := f.guardedSources()
+	if len(guarded) > 0 {
+		health := make(map[string]float64, len(guarded))
+		for name, source := range guarded {
+			health[name] = source.HealthScore()
+		}
+		config.HealthWeights = health
+	}
+
+	result, err := Aggregate(quotes, config)
 	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	var priceResp PriceResponse
-	if err := json.Unmarshal(body, &priceResp); err != nil {
-		return 0, fmt.Errorf("failed to unmarshal response: %w", err)
+	for _, quote := range quotes {
+		if source, ok := guarded[quote.Source]; ok && result.Price > 0 {
+			source.RecordDeviation((quote.Price - result.Price) / result.Price)
+		}
 	}
 
-	if priceResp.Ethereum.USD <= 0 {
-		return 0, fmt.Errorf("invalid price received: %f", priceResp.Ethereum.USD)
+	return result, nil
+}
+
+// EnableCircuitBreakers wraps every source in a circuit breaker with health scoring
+func (f *Fetcher) EnableCircuitBreakers(config BreakerConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid circuit breaker config: %w", err)
 	}
 
-	return priceResp.Ethereum.USD, nil
+	for i, source := range f.sources {
+		if guarded, ok := source.(*GuardedSource); ok {
+			source = guarded.Unwrap()
+		}
+		f.sources[i] = NewGuardedSource(source, config)
+	}
+	return nil
 }
 
-// FetchMultiplePrices fetches prices from multiple sources and aggregates them
-func (f *Fetcher) FetchMultiplePrices(apiURLs []string) ([]float64, error) {
-	var prices []float64
-	var errors []error
+// SetMaxQuoteAge rejects quotes observed more than maxAge before they were fetched; zero removes the limit
+// The limit sits inside any circuit breaker, so stale quotes count against the source's health
+func (f *Fetcher) SetMaxQuoteAge(maxAge time.Duration) error {
+	if maxAge < 0 {
+		return fmt.Errorf("max quote age must not be negative, got: %v", maxAge)
+	}
 
-	// Create a channel to collect results
-	type result struct {
-		price float64
-		err   error
+	for i, source := range f.sources {
+		guarded, isGuarded := source.(*GuardedSource)
+		if isGuarded {
+			source = guarded.source
+		}
+		if limited, ok := source.(*AgeLimitedSource); ok {
+			source = limited.source
+		}
+		if maxAge > 0 {
+			source = NewAgeLimitedSource(source, maxAge)
+		}
+
+		if isGuarded {
+			guarded.source = source
+		} else {
+			f.sources[i] = source
+		}
 	}
+	return nil
+}
+
+// Start starts every streaming source in the background until ctx is done
+// Polling sources need no start, so this is a no-op for fetchers without streaming sources
+func (f *Fetcher) Start(ctx context.Context) {
+	for _, source := range f.sources {
+		if streamer, ok := unwrapSource(source).(Streamer); ok {
+			streamer.Start(ctx)
+		}
+	}
+}
 
-	results := make(chan result, len(apiURLs))
+// SourceStatuses returns the breaker state and health of every source
+// Sources without a circuit breaker are reported as closed with full health
+func (f *Fetcher) SourceStatuses() []SourceStatus {
+	statuses := make([]SourceStatus, len(f.sources))
+	for i, source := range f.sources {
+		if guarded, ok := source.(*GuardedSource); ok {
+			statuses[i] = guarded.Status()
+		} else {
+			statuses[i] = SourceStatus{
+				Name:        source.Name(),
+				State:       CircuitClosed,
+				HealthScore: 1,
+			}
+		}
 
-	// Fetch from each API concurrently
-	for _, url := range apiURLs {
-		go func(apiURL string) {
-			fetcher := NewFetcher(apiURL, f.timeout)
-			price, err := fetcher.FetchETHUSDPrice()
-			results <- result{price: price, err: err}
-		}(url)
+		if stream, ok := unwrapSource(source).(*StreamSource); ok {
+			streamStatus := stream.Status()
+			statuses[i].Stream = &streamStatus
+		}
 	}
+	return statuses
+}
 
-	// Collect results
-	for i := 0; i < len(apiURLs); i++ {
-		res := <-results
-		if res.err != nil {
-			errors = append(errors, res.err)
-			continue
+// unwrapSource returns the source behind any circuit breakers and recorders wrapping it
+func unwrapSource(source PriceSource) PriceSource {
+	for {
+		wrapper, ok := source.(interface{ Unwrap() PriceSource })
+		if !ok {
+			return source
 		}
-		prices = append(prices, res.price)
+		source = wrapper.Unwrap()
 	}
+}
 
-	if len(prices) == 0 {
-		return nil, fmt.Errorf("failed to fetch from any API: %v", errors)
+// guardedSources returns the sources wrapped in circuit breakers, keyed by name
+func (f *Fetcher) guardedSources() map[string]*GuardedSource {
+	guarded := make(map[string]*GuardedSource)
+	for _, source := range f.sources {
+		if g, ok := source.(*GuardedSource); ok {
+			guarded[g.Name()] = g
+		}
+	}
+	return guarded
+}
+
+// SetAggregationConfig changes how fetched quotes are aggregated
+func (f *Fetcher) SetAggregationConfig(config AggregationConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid aggregation config: %w", err)
+	}
+	f.aggregation = config
+	return nil
+}
+
+// Pair returns the pair the fetcher prices
+func (f *Fetcher) Pair() pair.Pair {
+	return f.pair
+}
+
+// Sources returns the configured price sources
+func (f *Fetcher) Sources() []PriceSource {
+	return f.sources
+}
+
+// FetchMultiplePrices fetches prices from multiple CoinGecko-compatible URLs concurrently
+// Failures are reported per URL; an error is only returned if no URL answered
+func (f *Fetcher) FetchMultiplePrices(apiURLs []string) ([]float64, error) {
+	return f.FetchMultiplePricesWithContext(context.Background(), apiURLs)
+}
+
+// FetchMultiplePricesWithContext is FetchMultiplePrices with requests aborted when ctx is done
+func (f *Fetcher) FetchMultiplePricesWithContext(ctx context.Context, apiURLs []string) ([]float64, error) {
+	sources := make([]PriceSource, len(apiURLs))
+	for i, url := range apiURLs {
+		sources[i] = NewCoinGeckoSource(url, url, f.client)
+	}
+
+	report := &FetchReport{
+		Results:  fetchAll(ctx, sources),
+		Required: 1,
+	}
+
+	if !report.QuorumMet() {
+		return nil, &QuorumError{
+			Succeeded: 0,
+			Required:  1,
+			Total:     len(apiURLs),
+			Skipped:   len(report.Skipped()),
+			Failures:  report.Failed(),
+		}
+	}
+
+	var prices []float64
+	for _, quote := range report.Quotes() {
+		prices = append(prices, quote.Price)
 	}
 
 	return prices, nil
 }
 
 // AggregatePrices combines multiple exchange prices into a single normalized value
-// Uses simple average for now, but could be enhanced with weighted averages
+// Uses a simple average; see Aggregate for median-based aggregation with outlier rejection
 func AggregatePrices(prices []float64) float64 {
 	if len(prices) == 0 {
 		return 0
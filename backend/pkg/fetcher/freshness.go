@@ -0,0 +1,49 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AgeLimitedSource rejects quotes the provider observed too long before they were fetched
+// Quotes without a provider time cannot be judged and are passed through
+type AgeLimitedSource struct {
+	source PriceSource
+	maxAge time.Duration
+}
+
+// NewAgeLimitedSource wraps source so quotes older than maxAge are rejected as stale
+func NewAgeLimitedSource(source PriceSource, maxAge time.Duration) *AgeLimitedSource {
+	return &AgeLimitedSource{
+		source: source,
+		maxAge: maxAge,
+	}
+}
+
+// Name returns the name of the wrapped source
+func (s *AgeLimitedSource) Name() string {
+	return s.source.Name()
+}
+
+// Unwrap returns the wrapped source
+func (s *AgeLimitedSource) Unwrap() PriceSource {
+	return s.source
+}
+
+// FetchPrice fetches from the wrapped source and rejects the quote if it is older than the max age
+func (s *AgeLimitedSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	quote, err := s.source.FetchPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.ObservedAt.IsZero() {
+		return quote, nil
+	}
+	if age := quote.Timestamp.Sub(quote.ObservedAt); age > s.maxAge {
+		return nil, newSourceError(ErrorClassStale, quote.StatusCode,
+			fmt.Errorf("quote from %s was observed %v before it was fetched, above the %v limit", s.source.Name(), age.Round(time.Millisecond), s.maxAge))
+	}
+	return quote, nil
+}
@@ -0,0 +1,79 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// datedSource returns one quote fetched at fetchedAt and observed by the provider at observedAt
+type datedSource struct {
+	fetchedAt  time.Time
+	observedAt time.Time
+}
+
+func (s *datedSource) Name() string {
+	return "dated"
+}
+
+func (s *datedSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	return &Quote{Source: "dated", Price: 3412.57, Timestamp: s.fetchedAt, ObservedAt: s.observedAt, StatusCode: 200}, nil
+}
+
+func TestAgeLimitedSource(t *testing.T) {
+	fetchedAt := time.Date(2024, 6, 10, 16, 0, 0, 0, time.UTC)
+	maxAge := 30 * time.Second
+
+	tests := []struct {
+		name       string
+		observedAt time.Time
+		stale      bool
+		// observed is the time the quote counts as observed at
+		observed time.Time
+	}{
+		{name: "fresh", observedAt: fetchedAt.Add(-5 * time.Second), observed: fetchedAt.Add(-5 * time.Second)},
+		{name: "exactly max age", observedAt: fetchedAt.Add(-maxAge), observed: fetchedAt.Add(-maxAge)},
+		{name: "stale", observedAt: fetchedAt.Add(-maxAge - time.Millisecond), stale: true},
+		{name: "observed after the fetch", observedAt: fetchedAt.Add(time.Second), observed: fetchedAt.Add(time.Second)},
+		{name: "no provider timestamp", observed: fetchedAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewAgeLimitedSource(&datedSource{fetchedAt: fetchedAt, observedAt: tt.observedAt}, maxAge)
+			quote, err := source.FetchPrice(context.Background())
+
+			if tt.stale {
+				var sourceErr *SourceError
+				if !errors.As(err, &sourceErr) || sourceErr.Class != ErrorClassStale {
+					t.Fatalf("FetchPrice() error = %v, want %s", err, ErrorClassStale)
+				}
+				if sourceErr.StatusCode != 200 {
+					t.Errorf("stale error status code = %d, want the response's 200", sourceErr.StatusCode)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			// Without a provider timestamp the fetch time stands in for the observation
+			if observed := quote.ObservedTime(); !observed.Equal(tt.observed) {
+				t.Errorf("ObservedTime() = %v, want %v", observed, tt.observed)
+			}
+		})
+	}
+}
+
+func TestAgeLimitedSourcePassesErrorsThrough(t *testing.T) {
+	want := newSourceError(ErrorClassNetwork, 0, errors.New("connection refused"))
+	source := NewAgeLimitedSource(&fixedSource{name: "down", err: want}, time.Minute)
+
+	if source.Name() != "down" {
+		t.Errorf("Name() = %q, want the wrapped source's", source.Name())
+	}
+	if _, err := source.FetchPrice(context.Background()); !errors.Is(err, want) {
+		t.Errorf("FetchPrice() error = %v, want %v", err, want)
+	}
+}
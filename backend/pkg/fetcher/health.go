@@ -0,0 +1,275 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a source's circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects requests until the open timeout has passed
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through to decide whether to close again
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// ErrorClassCircuitOpen is reported for sources skipped because their circuit is open
+const ErrorClassCircuitOpen ErrorClass = "circuit_open"
+
+// BreakerConfig controls the circuit breaker and health scoring of a source
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a probe is allowed
+	OpenTimeout time.Duration
+	// Window is the number of recent fetches the health score is computed over
+	Window int
+	// LatencyTarget is the average latency above which the latency score starts to drop
+	LatencyTarget time.Duration
+	// DeviationTolerance is the relative deviation from consensus above which the deviation score starts to drop
+	DeviationTolerance float64
+}
+
+// DefaultBreakerConfig returns the default circuit breaker settings
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold:   3,
+		OpenTimeout:        2 * time.Minute,
+		Window:             20,
+		LatencyTarget:      2 * time.Second,
+		DeviationTolerance: 0.005,
+	}
+}
+
+// Validate checks that the breaker config is usable
+func (c BreakerConfig) Validate() error {
+	if c.FailureThreshold < 1 {
+		return fmt.Errorf("failure threshold must be at least 1, got: %d", c.FailureThreshold)
+	}
+	if c.OpenTimeout <= 0 {
+		return fmt.Errorf("open timeout must be positive, got: %v", c.OpenTimeout)
+	}
+	if c.Window < 1 {
+		return fmt.Errorf("health window must be at least 1, got: %d", c.Window)
+	}
+	if c.LatencyTarget <= 0 {
+		return fmt.Errorf("latency target must be positive, got: %v", c.LatencyTarget)
+	}
+	if c.DeviationTolerance <= 0 {
+		return fmt.Errorf("deviation tolerance must be positive, got: %f", c.DeviationTolerance)
+	}
+	return nil
+}
+
+// SourceStatus is a snapshot of a source's breaker state and health
+type SourceStatus struct {
+	Name                string        `json:"name"`
+	State               CircuitState  `json:"state"`
+	HealthScore         float64       `json:"health_score"`
+	ErrorRate           float64       `json:"error_rate"`
+	AverageLatency      time.Duration `json:"average_latency"`
+	AverageDeviation    float64       `json:"average_deviation"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	Samples             int           `json:"samples"`
+	RetryAt             *time.Time    `json:"retry_at,omitempty"`
+	// Stream is set for streaming sources
+	Stream *StreamStatus `json:"stream,omitempty"`
+}
+
+// fetchOutcome is a single fetch recorded in the rolling health window
+type fetchOutcome struct {
+	success bool
+	latency time.Duration
+}
+
+// GuardedSource wraps a price source with a circuit breaker and a rolling health score
+type GuardedSource struct {
+	source PriceSource
+	config BreakerConfig
+
+	mu                  sync.Mutex
+	state               CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool
+	outcomes            []fetchOutcome
+	deviations          []float64
+}
+
+// NewGuardedSource wraps source with a circuit breaker
+func NewGuardedSource(source PriceSource, config BreakerConfig) *GuardedSource {
+	return &GuardedSource{
+		source: source,
+		config: config,
+		state:  CircuitClosed,
+	}
+}
+
+// Name returns the name of the wrapped source
+func (g *GuardedSource) Name() string {
+	return g.source.Name()
+}
+
+// Unwrap returns the wrapped source
+func (g *GuardedSource) Unwrap() PriceSource {
+	return g.source
+}
+
+// FetchPrice fetches from the wrapped source unless its circuit is open
+func (g *GuardedSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	if !g.allow() {
+		return nil, newSourceError(ErrorClassCircuitOpen, 0, fmt.Errorf("circuit open for %s", g.Name()))
+	}
+
+	start := time.Now()
+	quote, err := g.source.FetchPrice(ctx)
+	g.record(err, time.Since(start))
+
+	return quote, err
+}
+
+// allow reports whether a request may be sent, moving an expired open circuit to half-open
+func (g *GuardedSource) allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.state {
+	case CircuitOpen:
+		if time.Since(g.openedAt) < g.config.OpenTimeout {
+			return false
+		}
+		g.state = CircuitHalfOpen
+		g.probing = true
+		return true
+	case CircuitHalfOpen:
+		// Only one probe at a time
+		if g.probing {
+			return false
+		}
+		g.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker state and health window with the outcome of a fetch
+func (g *GuardedSource) record(err error, latency time.Duration) {
+	if class, _ := ClassifyError(err); !countsAsFailure(class) {
+		g.mu.Lock()
+		g.probing = false
+		g.mu.Unlock()
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.probing = false
+	g.outcomes = appendWindow(g.outcomes, fetchOutcome{success: err == nil, latency: latency}, g.config.Window)
+
+	if err == nil {
+		g.consecutiveFailures = 0
+		g.state = CircuitClosed
+		return
+	}
+
+	g.consecutiveFailures++
+	if g.state == CircuitHalfOpen || g.consecutiveFailures >= g.config.FailureThreshold {
+		g.state = CircuitOpen
+		g.openedAt = time.Now()
+	}
+}
+
+// countsAsFailure reports whether a fetch failing with class reflects on the health of the source
+// A fetch aborted by our own cancellation, held back or refused by a rate limit, or refused after the
+// venue answered because the stablecoin it quotes in has no fresh rate or lost its peg says nothing about it
+func countsAsFailure(class ErrorClass) bool {
+	switch class {
+	case ErrorClassCanceled, ErrorClassBackoff, ErrorClassRateLimited, ErrorClassNoRate, ErrorClassDepegged:
+		return false
+	}
+	return true
+}
+
+// RecordDeviation records how far the source's latest quote was from the consensus price
+func (g *GuardedSource) RecordDeviation(deviation float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.deviations = appendWindow(g.deviations, math.Abs(deviation), g.config.Window)
+}
+
+// HealthScore returns the source's health in [0, 1]; an open circuit scores zero
+func (g *GuardedSource) HealthScore() float64 {
+	return g.Status().HealthScore
+}
+
+// Status returns a snapshot of the source's breaker state and health
+func (g *GuardedSource) Status() SourceStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status := SourceStatus{
+		Name:                g.source.Name(),
+		State:               g.state,
+		ConsecutiveFailures: g.consecutiveFailures,
+		Samples:             len(g.outcomes),
+	}
+
+	var failures int
+	var totalLatency time.Duration
+	for _, outcome := range g.outcomes {
+		if !outcome.success {
+			failures++
+		}
+		totalLatency += outcome.latency
+	}
+	if len(g.outcomes) > 0 {
+		status.ErrorRate = float64(failures) / float64(len(g.outcomes))
+		status.AverageLatency = totalLatency / time.Duration(len(g.outcomes))
+	}
+
+	var totalDeviation float64
+	for _, deviation := range g.deviations {
+		totalDeviation += deviation
+	}
+	if len(g.deviations) > 0 {
+		status.AverageDeviation = totalDeviation / float64(len(g.deviations))
+	}
+
+	if g.state == CircuitOpen {
+		retryAt := g.openedAt.Add(g.config.OpenTimeout)
+		status.RetryAt = &retryAt
+		return status
+	}
+
+	errorScore := 1 - status.ErrorRate
+	latencyScore := 1.0
+	if status.AverageLatency > g.config.LatencyTarget {
+		latencyScore = float64(g.config.LatencyTarget) / float64(status.AverageLatency)
+	}
+	deviationScore := 1.0
+	if status.AverageDeviation > g.config.DeviationTolerance {
+		deviationScore = g.config.DeviationTolerance / status.AverageDeviation
+	}
+	status.HealthScore = errorScore * latencyScore * deviationScore
+
+	return status
+}
+
+// appendWindow appends value and drops the oldest entries beyond size
+func appendWindow[T any](window []T, value T, size int) []T {
+	window = append(window, value)
+	if len(window) > size {
+		window = window[len(window)-size:]
+	}
+	return window
+}
@@ -0,0 +1,199 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// ErrorClassRateLimited is reported when a source answered with a rate limit response
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// ErrorClassBackoff is reported for sources skipped because they asked us to back off
+	// or their request budget is spent; these are not counted as failures
+	ErrorClassBackoff ErrorClass = "backoff"
+)
+
+const (
+	// defaultRateLimitBackoff is used when a rate limit response does not say how long to wait
+	defaultRateLimitBackoff = time.Minute
+	// budgetWindow is the period request budgets are enforced over
+	budgetWindow = time.Minute
+	// binanceWeightLimit is Binance's default request weight limit per minute
+	binanceWeightLimit = 6000
+)
+
+// BackoffError is returned without contacting a source while it is backing off
+type BackoffError struct {
+	Until  time.Time
+	Reason string
+}
+
+// Error implements the error interface
+func (e *BackoffError) Error() string {
+	return fmt.Sprintf("backing off until %s: %s", e.Until.Format(time.RFC3339), e.Reason)
+}
+
+// RateLimitedTransport is an http.RoundTripper that enforces a per-minute request budget
+// and honours Retry-After and provider rate limit headers
+type RateLimitedTransport struct {
+	base   http.RoundTripper
+	budget int
+
+	mu            sync.Mutex
+	windowStart   time.Time
+	used          int
+	backoffUntil  time.Time
+	backoffReason string
+}
+
+// NewRateLimitedTransport wraps base with a budget of requestsPerMinute; zero or less means unlimited
+func NewRateLimitedTransport(base http.RoundTripper, requestsPerMinute int) *RateLimitedTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &RateLimitedTransport{
+		base:   base,
+		budget: requestsPerMinute,
+	}
+}
+
+// RoundTrip sends the request unless the source is backing off or out of budget
+func (t *RateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.acquire(time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.observe(resp, time.Now())
+	return resp, nil
+}
+
+// BackOff stops requests to the source until the given time
+func (t *RateLimitedTransport) BackOff(until time.Time, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until.After(t.backoffUntil) {
+		t.backoffUntil = until
+		t.backoffReason = reason
+	}
+}
+
+// BackoffUntil returns the time until which requests are held back, if any
+func (t *RateLimitedTransport) BackoffUntil() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.backoffUntil
+}
+
+// acquire takes one request from the budget, or returns a *BackoffError
+func (t *RateLimitedTransport) acquire(now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Before(t.backoffUntil) {
+		return &BackoffError{Until: t.backoffUntil, Reason: t.backoffReason}
+	}
+
+	if t.budget <= 0 {
+		return nil
+	}
+
+	if now.Sub(t.windowStart) >= budgetWindow {
+		t.windowStart = now
+		t.used = 0
+	}
+
+	if t.used >= t.budget {
+		return &BackoffError{
+			Until:  t.windowStart.Add(budgetWindow),
+			Reason: fmt.Sprintf("request budget of %d per minute spent", t.budget),
+		}
+	}
+
+	t.used++
+	return nil
+}
+
+// observe inspects a response for rate limit signals and backs off accordingly
+func (t *RateLimitedTransport) observe(resp *http.Response, now time.Time) {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusTeapot, http.StatusServiceUnavailable:
+		// Binance answers 418 once an IP has been banned for ignoring 429s
+		delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+		if !ok {
+			if resp.StatusCode == http.StatusServiceUnavailable {
+				return
+			}
+			delay = defaultRateLimitBackoff
+		}
+		t.BackOff(now.Add(delay), fmt.Sprintf("status %d", resp.StatusCode))
+		return
+	}
+
+	// Generic and IETF draft rate limit headers
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		remaining, err := strconv.Atoi(resp.Header.Get(prefix + "Remaining"))
+		if err != nil || remaining > 0 {
+			continue
+		}
+		delay, ok := parseReset(resp.Header.Get(prefix+"Reset"), now)
+		if !ok {
+			delay = defaultRateLimitBackoff
+		}
+		t.BackOff(now.Add(delay), "provider rate limit exhausted")
+		return
+	}
+
+	// Binance reports the request weight used in the current minute
+	if used, err := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil && used >= binanceWeightLimit {
+		t.BackOff(now.Truncate(time.Minute).Add(time.Minute), "Binance request weight exhausted")
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// parseReset parses a rate limit reset header given either as seconds to wait or as a Unix timestamp
+func parseReset(value string, now time.Time) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || reset < 0 {
+		return 0, false
+	}
+
+	// Values this large can only be Unix timestamps
+	if reset > 1_000_000_000 {
+		if delay := time.Unix(reset, 0).Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+
+	return time.Duration(reset) * time.Second, true
+}
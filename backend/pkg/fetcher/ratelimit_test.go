@@ -0,0 +1,213 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 10, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{name: "delta seconds", value: "120", delay: 2 * time.Minute, ok: true},
+		{name: "zero seconds", value: "0", delay: 0, ok: true},
+		{name: "HTTP date", value: now.Add(90 * time.Second).Format(http.TimeFormat), delay: 90 * time.Second, ok: true},
+		{name: "HTTP date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), delay: 0, ok: true},
+		{name: "empty", value: "", ok: false},
+		{name: "negative seconds", value: "-5", ok: false},
+		{name: "garbage", value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.ok || delay != tt.delay {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, delay, ok, tt.delay, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseReset(t *testing.T) {
+	now := time.Unix(1718035200, 0)
+
+	tests := []struct {
+		name  string
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{name: "seconds to wait", value: "30", delay: 30 * time.Second, ok: true},
+		{name: "Unix timestamp", value: strconv.FormatInt(now.Unix()+45, 10), delay: 45 * time.Second, ok: true},
+		{name: "Unix timestamp in the past", value: strconv.FormatInt(now.Unix()-45, 10), delay: 0, ok: true},
+		{name: "empty", value: "", ok: false},
+		{name: "negative", value: "-1", ok: false},
+		{name: "garbage", value: "1.5", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := parseReset(tt.value, now)
+			if ok != tt.ok || delay != tt.delay {
+				t.Errorf("parseReset(%q) = %v, %v, want %v, %v", tt.value, delay, ok, tt.delay, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRateLimitedTransportHonoursProviderHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		// backoff is how long the transport should hold requests back, zero for not at all
+		backoff time.Duration
+		// untilNextMinute expects a back off until the start of the next minute
+		untilNextMinute bool
+	}{
+		{name: "429 with delta seconds", status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "120"}, backoff: 2 * time.Minute},
+		{name: "429 with HTTP date", status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": time.Now().Add(5 * time.Minute).UTC().Format(http.TimeFormat)}, backoff: 5 * time.Minute},
+		{name: "429 without Retry-After", status: http.StatusTooManyRequests, backoff: defaultRateLimitBackoff},
+		{name: "418 ban", status: http.StatusTeapot, headers: map[string]string{"Retry-After": "300"}, backoff: 5 * time.Minute},
+		{name: "503 with Retry-After", status: http.StatusServiceUnavailable, headers: map[string]string{"Retry-After": "30"}, backoff: 30 * time.Second},
+		{name: "503 without Retry-After", status: http.StatusServiceUnavailable},
+		{name: "RateLimit-Remaining 0", status: http.StatusOK, headers: map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "40"}, backoff: 40 * time.Second},
+		{name: "X-RateLimit-Remaining 0 without reset", status: http.StatusOK, headers: map[string]string{"X-RateLimit-Remaining": "0"}, backoff: defaultRateLimitBackoff},
+		{name: "RateLimit-Remaining left", status: http.StatusOK, headers: map[string]string{"RateLimit-Remaining": "3", "RateLimit-Reset": "40"}},
+		{name: "Binance weight exhausted", status: http.StatusOK, headers: map[string]string{"X-MBX-USED-WEIGHT-1M": strconv.Itoa(binanceWeightLimit)}, untilNextMinute: true},
+		{name: "Binance weight left", status: http.StatusOK, headers: map[string]string{"X-MBX-USED-WEIGHT-1M": "1200"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.headers {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			transport := NewRateLimitedTransport(http.DefaultTransport, 0)
+			client := &http.Client{Transport: transport}
+			before := time.Now()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			until := transport.BackoffUntil()
+			switch {
+			case tt.untilNextMinute:
+				if want := before.Truncate(time.Minute).Add(time.Minute); !until.Equal(want) {
+					t.Errorf("BackoffUntil() = %v, want %v", until, want)
+				}
+			case tt.backoff == 0:
+				if !until.IsZero() {
+					t.Errorf("BackoffUntil() = %v, want no back off", until)
+				}
+			default:
+				// HTTP dates only carry whole seconds
+				if delay := until.Sub(before); delay < tt.backoff-time.Second || delay > tt.backoff+time.Second {
+					t.Errorf("backing off for %v, want %v", delay, tt.backoff)
+				}
+			}
+
+			if tt.backoff == 0 && !tt.untilNextMinute {
+				return
+			}
+			// The next request is held back without reaching the provider
+			_, err = client.Get(server.URL)
+			var backoffErr *BackoffError
+			if !errors.As(err, &backoffErr) {
+				t.Errorf("Get() while backing off error = %v, want a *BackoffError", err)
+			}
+		})
+	}
+}
+
+func TestRateLimitedTransportSkipsSourcesOutOfBudget(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"symbol":"ETHUSDT","price":"3411.99"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: NewRateLimitedTransport(http.DefaultTransport, 2)}
+	sources := []PriceSource{NewBinanceSource("binance", server.URL, client)}
+
+	for i := range 3 {
+		results := fetchAll(context.Background(), sources)
+		result := results[0]
+		if i < 2 {
+			if !result.OK() {
+				t.Fatalf("fetch %d = %+v, want a quote within the budget", i+1, result)
+			}
+			continue
+		}
+
+		var backoffErr *BackoffError
+		if !errors.As(result.Err, &backoffErr) {
+			t.Fatalf("fetch %d error = %v, want a *BackoffError", i+1, result.Err)
+		}
+		if !result.Skipped() || result.ErrorClass != ErrorClassBackoff {
+			t.Errorf("fetch %d = %s, want it skipped as %s", i+1, result.ErrorClass, ErrorClassBackoff)
+		}
+		report := &FetchReport{Results: results, Required: 1}
+		if len(report.Failed()) != 0 || len(report.Skipped()) != 1 {
+			t.Errorf("report has %d failed and %d skipped, want the source skipped only", len(report.Failed()), len(report.Skipped()))
+		}
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("provider received %d requests, want 2", got)
+	}
+}
+
+func TestGuardedSourceIgnoresRateLimits(t *testing.T) {
+	config := DefaultBreakerConfig()
+	config.FailureThreshold = 1
+
+	tests := []struct {
+		name string
+		err  error
+		open bool
+	}{
+		{name: "backing off", err: &BackoffError{Until: time.Now().Add(time.Minute), Reason: "status 429"}, open: false},
+		{name: "rate limited", err: newSourceError(ErrorClassRateLimited, http.StatusTooManyRequests, fmt.Errorf("API rate limited us with status 429")), open: false},
+		{name: "canceled", err: context.Canceled, open: false},
+		{name: "failing", err: newSourceError(ErrorClassHTTPStatus, http.StatusBadGateway, fmt.Errorf("API returned status 502")), open: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guarded := NewGuardedSource(&fixedSource{name: "binance", err: tt.err}, config)
+			for range 3 {
+				guarded.FetchPrice(context.Background())
+			}
+
+			status := guarded.Status()
+			if open := status.State == CircuitOpen; open != tt.open {
+				t.Errorf("circuit state = %s, want open %v", status.State, tt.open)
+			}
+			if !tt.open && status.ConsecutiveFailures != 0 {
+				t.Errorf("ConsecutiveFailures = %d, want 0", status.ConsecutiveFailures)
+			}
+		})
+	}
+}
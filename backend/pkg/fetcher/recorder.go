@@ -0,0 +1,102 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+)
+
+// Recorder appends quotes to a JSONL recording file that a Replayer can play back
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewRecorder opens path for appending, creating it if needed
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+
+	return &Recorder{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// Record appends a quote of p to the recording
+func (r *Recorder) Record(p pair.Pair, quote *Quote) error {
+	recording := Recording{
+		Timestamp:  quote.Timestamp,
+		Pair:       p.String(),
+		Source:     quote.Source,
+		Price:      quote.Price,
+		ObservedAt: quote.ObservedAt,
+		Raw:        string(quote.Raw),
+	}
+	if quote.Exact.Sign() > 0 {
+		recording.ExactPrice = quote.Exact.String()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.encoder.Encode(recording); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the recording file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
+
+// RecordingSource records every quote returned by the source it wraps
+type RecordingSource struct {
+	source   PriceSource
+	pair     pair.Pair
+	recorder *Recorder
+}
+
+// NewRecordingSource wraps source so its quotes of p are written to recorder
+func NewRecordingSource(source PriceSource, p pair.Pair, recorder *Recorder) *RecordingSource {
+	return &RecordingSource{
+		source:   source,
+		pair:     p,
+		recorder: recorder,
+	}
+}
+
+// Name returns the name of the wrapped source
+func (s *RecordingSource) Name() string {
+	return s.source.Name()
+}
+
+// Unwrap returns the wrapped source
+func (s *RecordingSource) Unwrap() PriceSource {
+	return s.source
+}
+
+// FetchPrice fetches from the wrapped source and records the quote; recording failures are only logged
+func (s *RecordingSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	quote, err := s.source.FetchPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recorder.Record(s.pair, quote); err != nil {
+		log.Printf("Failed to record quote from %s: %v", s.source.Name(), err)
+	}
+	return quote, nil
+}
@@ -0,0 +1,82 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultReferenceMaxDeviation is the largest relative deviation from the reference accepted by default
+const DefaultReferenceMaxDeviation = 0.02
+
+// ReferenceCheck is the outcome of comparing a price with the reference feed
+type ReferenceCheck struct {
+	Price     float64   `json:"price"`
+	Reference float64   `json:"reference"`
+	Timestamp time.Time `json:"timestamp"`
+	// Deviation is the relative difference between the price and the reference
+	Deviation float64 `json:"deviation"`
+}
+
+// ReferenceDeviationError is returned when a price strays too far from the reference feed
+type ReferenceDeviationError struct {
+	Check        ReferenceCheck
+	MaxDeviation float64
+}
+
+func (e *ReferenceDeviationError) Error() string {
+	return fmt.Sprintf("price %.8g deviates %.2f%% from reference %.8g, above the %.2f%% bound",
+		e.Check.Price, e.Check.Deviation*100, e.Check.Reference, e.MaxDeviation*100)
+}
+
+// ReferenceGuard cross-checks prices against an independent reference source, typically
+// an on-chain AggregatorV3 feed, before they are published
+type ReferenceGuard struct {
+	source       PriceSource
+	maxDeviation float64
+}
+
+// NewReferenceGuard creates a guard that rejects prices deviating from source by more than maxDeviation
+func NewReferenceGuard(source PriceSource, maxDeviation float64) (*ReferenceGuard, error) {
+	if maxDeviation <= 0 {
+		return nil, fmt.Errorf("max deviation must be positive, got: %f", maxDeviation)
+	}
+
+	return &ReferenceGuard{
+		source:       source,
+		maxDeviation: maxDeviation,
+	}, nil
+}
+
+// Name returns the name of the reference source
+func (g *ReferenceGuard) Name() string {
+	return g.source.Name()
+}
+
+// MaxDeviation returns the largest accepted relative deviation
+func (g *ReferenceGuard) MaxDeviation() float64 {
+	return g.maxDeviation
+}
+
+// Check compares price with the reference
+// A *ReferenceDeviationError is returned alongside the check when the deviation exceeds the bound;
+// any other error means the reference could not be read and nothing was checked
+func (g *ReferenceGuard) Check(ctx context.Context, price float64) (*ReferenceCheck, error) {
+	quote, err := g.source.FetchPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference %s: %w", g.source.Name(), err)
+	}
+
+	check := &ReferenceCheck{
+		Price:     price,
+		Reference: quote.Price,
+		Timestamp: quote.ObservedTime(),
+		Deviation: math.Abs(price-quote.Price) / quote.Price,
+	}
+	if check.Deviation > g.maxDeviation {
+		return check, &ReferenceDeviationError{Check: *check, MaxDeviation: g.maxDeviation}
+	}
+
+	return check, nil
+}
@@ -0,0 +1,336 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SourceFactory builds a price source with the given name for the given endpoint
+type SourceFactory func(name, url string, client *http.Client) PriceSource
+
+// StreamFactory builds a streaming price source with the given name for the given endpoint
+// and venue symbol, which streaming adapters send in their subscribe messages
+type StreamFactory func(name, url, symbol string, config StreamConfig) PriceSource
+
+// ChainFactory builds an on-chain price source for the given pair that reads contracts through caller
+// params holds the adapter-specific settings of the source, such as the pool address
+type ChainFactory func(name string, p pair.Pair, caller bind.ContractCaller, params map[string]string) (PriceSource, error)
+
+// SyntheticFactory builds a price source that generates its prices locally from its params
+type SyntheticFactory func(name string, params map[string]string) (PriceSource, error)
+
+// registeredSource describes an adapter known to the registry; exactly one factory is set
+type registeredSource struct {
+	defaultURL    string
+	defaultBudget int
+	symbol        SymbolFunc
+	factory       SourceFactory
+	streamFactory StreamFactory
+	chainFactory  ChainFactory
+	// syntheticFactory builds sources that make no requests at all
+	syntheticFactory SyntheticFactory
+}
+
+// SourceSpec describes a source to build from the registry
+type SourceSpec struct {
+	Name string
+	Kind string
+	// Pair is the pair to quote; it defaults to ETH/USD
+	Pair pair.Pair
+	// URL overrides the adapter's default endpoint template when set
+	URL string
+	// RequestsPerMinute caps the request rate; zero uses the adapter default and a negative value disables the cap
+	RequestsPerMinute int
+	// Params holds adapter-specific settings, e.g. the pool address of on-chain sources
+	Params map[string]string
+	// QuoteAsset is the asset the source quotes the pair in; empty uses the adapter default
+	// On-chain sources price the pair against it, e.g. a WETH/USDC pool quotes ETH/USD as ETH/USDC
+	QuoteAsset string
+}
+
+// Registry maps adapter kinds to the factories that build them
+type Registry struct {
+	sources      map[string]registeredSource
+	streamConfig StreamConfig
+	// rpcURL is the node on-chain sources read from unless they set their own URL
+	rpcURL string
+	// quoteAssets maps adapter kinds that quote in a stablecoin to the asset they quote a pair in
+	quoteAssets map[string]SymbolFunc
+}
+
+// NewRegistry creates a registry with the built-in exchange adapters registered
+func NewRegistry() *Registry {
+	r := &Registry{
+		sources:      make(map[string]registeredSource),
+		streamConfig: DefaultStreamConfig(),
+		quoteAssets:  make(map[string]SymbolFunc),
+	}
+
+	// Default budgets stay below each provider's documented public rate limit
+	r.Register("coingecko", DefaultCoinGeckoURL, 30, CoinGeckoSymbol, func(name, url string, client *http.Client) PriceSource {
+		return NewCoinGeckoSource(name, url, client)
+	})
+	r.Register("binance", DefaultBinanceURL, 200, BinanceSymbol, func(name, url string, client *http.Client) PriceSource {
+		return NewBinanceSource(name, url, client)
+	})
+	r.Register("coinbase", DefaultCoinbaseURL, 300, CoinbaseSymbol, func(name, url string, client *http.Client) PriceSource {
+		return NewCoinbaseSource(name, url, client)
+	})
+	r.Register("kraken", DefaultKrakenURL, 60, KrakenSymbol, func(name, url string, client *http.Client) PriceSource {
+		return NewKrakenSource(name, url, client)
+	})
+
+	r.RegisterStream("binance_ws", DefaultBinanceStreamURL, BinanceSymbol, func(name, url, symbol string, config StreamConfig) PriceSource {
+		return NewBinanceStreamSource(name, url, config)
+	})
+	r.RegisterStream("coinbase_ws", DefaultCoinbaseStreamURL, CoinbaseSymbol, func(name, url, symbol string, config StreamConfig) PriceSource {
+		return NewCoinbaseStreamSource(name, url, symbol, config)
+	})
+	r.RegisterStream("kraken_ws", DefaultKrakenStreamURL, KrakenStreamSymbol, func(name, url, symbol string, config StreamConfig) PriceSource {
+		return NewKrakenStreamSource(name, url, symbol, config)
+	})
+
+	// On-chain sources read from the node at the source URL, which defaults to the registry's RPC URL
+	r.RegisterChain("uniswap_v3", 120, newUniswapV3SourceFromParams)
+	r.RegisterChain("aggregator_v3", 120, newAggregatorV3SourceFromParams)
+	r.RegisterSynthetic("simulator", newSimulatorSourceFromParams)
+
+	// Binance has no USD markets and quotes them in USDT instead
+	r.SetQuoteAsset("binance", BinanceQuoteAsset)
+	r.SetQuoteAsset("binance_ws", BinanceQuoteAsset)
+	// Uniswap pools hold tokens rather than currencies, so USD pairs are read from USDC pools
+	r.SetQuoteAsset("uniswap_v3", UniswapV3QuoteAsset)
+
+	return r
+}
+
+// newUniswapV3SourceFromParams builds a Uniswap V3 source from its "pool" and "twap_window" params
+func newUniswapV3SourceFromParams(name string, p pair.Pair, caller bind.ContractCaller, params map[string]string) (PriceSource, error) {
+	pool := params["pool"]
+	if !common.IsHexAddress(pool) {
+		return nil, fmt.Errorf("invalid pool address %q", pool)
+	}
+
+	window := DefaultUniswapV3TWAPWindow
+	if value := params["twap_window"]; value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TWAP window %q: %w", value, err)
+		}
+		window = parsed
+	}
+
+	return NewUniswapV3Source(name, p, caller, UniswapV3Config{
+		Pool:   common.HexToAddress(pool),
+		Window: window,
+	})
+}
+
+// Register adds or replaces an adapter kind with its default endpoint template, requests-per-minute
+// budget and venue symbol mapping
+func (r *Registry) Register(kind, defaultURL string, defaultBudget int, symbol SymbolFunc, factory SourceFactory) {
+	r.sources[kind] = registeredSource{
+		defaultURL:    defaultURL,
+		defaultBudget: defaultBudget,
+		symbol:        symbol,
+		factory:       factory,
+	}
+}
+
+// RegisterStream adds or replaces a streaming adapter kind with its default endpoint template
+// and venue symbol mapping
+func (r *Registry) RegisterStream(kind, defaultURL string, symbol SymbolFunc, factory StreamFactory) {
+	r.sources[kind] = registeredSource{
+		defaultURL:    defaultURL,
+		symbol:        symbol,
+		streamFactory: factory,
+	}
+}
+
+// RegisterChain adds or replaces an on-chain adapter kind with its default requests-per-minute budget
+// On-chain sources have no default endpoint; their URL is the RPC endpoint of the node to read from,
+// falling back to the registry's RPC URL
+func (r *Registry) RegisterChain(kind string, defaultBudget int, factory ChainFactory) {
+	r.sources[kind] = registeredSource{
+		defaultBudget: defaultBudget,
+		chainFactory:  factory,
+	}
+}
+
+// RegisterSynthetic adds or replaces a synthetic adapter kind, which has no endpoint or request budget
+func (r *Registry) RegisterSynthetic(kind string, factory SyntheticFactory) {
+	r.sources[kind] = registeredSource{
+		syntheticFactory: factory,
+	}
+}
+
+// SetQuoteAsset sets the asset a registered adapter kind quotes pairs in, for venues that quote
+// in a stablecoin rather than the pair's quote currency
+func (r *Registry) SetQuoteAsset(kind string, quoteAsset SymbolFunc) {
+	r.quoteAssets[kind] = quoteAsset
+}
+
+// QuoteAsset returns the asset sources of kind quote p in, which is the pair's quote currency
+// unless the adapter quotes in a stablecoin
+func (r *Registry) QuoteAsset(kind string, p pair.Pair) string {
+	quoteAsset, ok := r.quoteAssets[kind]
+	if !ok {
+		return p.Quote
+	}
+	return quoteAsset(p)
+}
+
+// newAggregatorV3SourceFromParams builds an AggregatorV3 reader from its "feed" and "max_age" params
+func newAggregatorV3SourceFromParams(name string, p pair.Pair, caller bind.ContractCaller, params map[string]string) (PriceSource, error) {
+	feed := params["feed"]
+	if !common.IsHexAddress(feed) {
+		return nil, fmt.Errorf("invalid feed address %q", feed)
+	}
+
+	maxAge := DefaultAggregatorV3MaxAge
+	if value := params["max_age"]; value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max age %q: %w", value, err)
+		}
+		maxAge = parsed
+	}
+
+	return NewAggregatorV3Source(name, common.HexToAddress(feed), caller, maxAge)
+}
+
+// SetRPCURL sets the default node endpoint of on-chain sources
+func (r *Registry) SetRPCURL(url string) {
+	r.rpcURL = url
+}
+
+// SetStreamConfig changes the settings streaming sources are built with
+func (r *Registry) SetStreamConfig(config StreamConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid stream config: %w", err)
+	}
+	r.streamConfig = config
+	return nil
+}
+
+// Kinds returns the registered adapter kinds in alphabetical order
+func (r *Registry) Kinds() []string {
+	kinds := make([]string, 0, len(r.sources))
+	for kind := range r.sources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Build creates the source described by spec for its pair, falling back to the adapter's defaults
+// Each source gets its own rate limited HTTP client so budgets and backoffs are tracked per source
+func (r *Registry) Build(spec SourceSpec, timeout time.Duration) (PriceSource, error) {
+	registered, ok := r.sources[spec.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown price source kind %q (available: %v)", spec.Kind, r.Kinds())
+	}
+
+	p := spec.Pair
+	if p.IsZero() {
+		p = pair.ETHUSD
+	}
+	var symbol string
+	if registered.symbol != nil {
+		symbol = registered.symbol(p)
+	}
+
+	url := spec.URL
+	if url == "" {
+		url = registered.defaultURL
+	}
+	url = ExpandURL(url, p, symbol)
+
+	name := spec.Name
+	if name == "" {
+		name = spec.Kind
+	}
+
+	// Synthetic sources make no requests
+	if registered.syntheticFactory != nil {
+		source, err := registered.syntheticFactory(name, spec.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure price source %s: %w", name, err)
+		}
+		return source, nil
+	}
+
+	// Streaming sources hold their own connection and have no request budget
+	if registered.streamFactory != nil {
+		source := registered.streamFactory(name, url, symbol, r.streamConfig)
+		if kind := spec.Params["fallback"]; kind != "" {
+			if err := r.setStreamFallback(source, name, kind, p, timeout); err != nil {
+				return nil, fmt.Errorf("failed to configure price source %s: %w", name, err)
+			}
+		}
+		return source, nil
+	}
+
+	budget := spec.RequestsPerMinute
+	if budget == 0 {
+		budget = registered.defaultBudget
+	}
+
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: NewRateLimitedTransport(http.DefaultTransport, budget),
+	}
+
+	// On-chain sources share the rate limited client through the RPC connection
+	if registered.chainFactory != nil {
+		if url == "" {
+			url = r.rpcURL
+		}
+		if url == "" {
+			return nil, fmt.Errorf("price source %s needs an RPC URL", name)
+		}
+		rpcClient, err := rpc.DialOptions(context.Background(), url, rpc.WithHTTPClient(client))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Ethereum client for %s: %w", name, err)
+		}
+		quoteAsset := spec.QuoteAsset
+		if quoteAsset == "" {
+			quoteAsset = r.QuoteAsset(spec.Kind, p)
+		}
+		source, err := registered.chainFactory(name, pair.New(p.Base, quoteAsset), ethclient.NewClient(rpcClient), spec.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure price source %s: %w", name, err)
+		}
+		return source, nil
+	}
+
+	return registered.factory(name, url, client), nil
+}
+
+// setStreamFallback gives a streaming source a REST source of kind, at its default endpoint and budget,
+// to poll while the stream is stale
+func (r *Registry) setStreamFallback(source PriceSource, name, kind string, p pair.Pair, timeout time.Duration) error {
+	stream, ok := source.(*StreamSource)
+	if !ok {
+		return fmt.Errorf("source does not support a fallback")
+	}
+	if registered, ok := r.sources[kind]; !ok || registered.factory == nil {
+		return fmt.Errorf("fallback %q is not a REST source kind", kind)
+	}
+
+	fallback, err := r.Build(SourceSpec{Name: name + "_fallback", Kind: kind, Pair: p}, timeout)
+	if err != nil {
+		return err
+	}
+	stream.SetFallback(fallback)
+	return nil
+}
@@ -0,0 +1,209 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// Recording is one recorded quote, stored as a line of a JSONL recording file
+type Recording struct {
+	Timestamp time.Time `json:"timestamp"`
+	Pair      string    `json:"pair"`
+	Source    string    `json:"source"`
+	Price     float64   `json:"price"`
+	// ExactPrice is the price exactly as the source reported it, if it reported one
+	ExactPrice string `json:"exact_price,omitempty"`
+	// ObservedAt is the provider's time of the quote, if it reported one
+	ObservedAt time.Time `json:"observed_at,omitempty"`
+	// Raw is the upstream response the quote was read from, if the source kept it
+	Raw string `json:"raw,omitempty"`
+}
+
+// ReadRecordings reads a JSONL recording file; blank lines are skipped
+func ReadRecordings(path string) ([]Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	var recordings []Recording
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var recording Recording
+		if err := json.Unmarshal(scanner.Bytes(), &recording); err != nil {
+			return nil, fmt.Errorf("failed to parse recording line %d: %w", line, err)
+		}
+		recordings = append(recordings, recording)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	return recordings, nil
+}
+
+// Replayer plays recorded quotes back on a virtual clock
+// The clock starts at the first recorded timestamp when the first quote is requested and then
+// advances speed times faster than real time
+type Replayer struct {
+	speed  float64
+	origin time.Time
+	end    time.Time
+	// series holds the recordings of each pair and source, sorted by timestamp
+	series map[pair.Pair]map[string][]Recording
+
+	startOnce sync.Once
+	started   time.Time
+}
+
+// NewReplayer creates a replayer for recordings played back at speed (1 is real time)
+func NewReplayer(recordings []Recording, speed float64) (*Replayer, error) {
+	if len(recordings) == 0 {
+		return nil, fmt.Errorf("recording is empty")
+	}
+	if speed <= 0 {
+		return nil, fmt.Errorf("replay speed must be positive, got: %f", speed)
+	}
+
+	r := &Replayer{
+		speed:  speed,
+		series: make(map[pair.Pair]map[string][]Recording),
+	}
+	for _, recording := range recordings {
+		p, err := pair.Parse(recording.Pair)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording from %s: %w", recording.Source, err)
+		}
+		if r.series[p] == nil {
+			r.series[p] = make(map[string][]Recording)
+		}
+		r.series[p][recording.Source] = append(r.series[p][recording.Source], recording)
+
+		if r.origin.IsZero() || recording.Timestamp.Before(r.origin) {
+			r.origin = recording.Timestamp
+		}
+		if recording.Timestamp.After(r.end) {
+			r.end = recording.Timestamp
+		}
+	}
+	for _, sources := range r.series {
+		for _, series := range sources {
+			sort.SliceStable(series, func(i, j int) bool {
+				return series[i].Timestamp.Before(series[j].Timestamp)
+			})
+		}
+	}
+
+	return r, nil
+}
+
+// LoadReplayer reads a recording file and creates a replayer for it
+func LoadReplayer(path string, speed float64) (*Replayer, error) {
+	recordings, err := ReadRecordings(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(recordings, speed)
+}
+
+// Now returns the current time on the replay clock, starting the clock on first use
+func (r *Replayer) Now() time.Time {
+	r.startOnce.Do(func() {
+		r.started = time.Now()
+	})
+
+	elapsed := time.Duration(float64(time.Since(r.started)) * r.speed)
+	return r.origin.Add(elapsed)
+}
+
+// Finished reports whether the replay clock has passed the last recording
+func (r *Replayer) Finished() bool {
+	return r.Now().After(r.end)
+}
+
+// Sources returns one replay source per source recorded for p, in alphabetical order
+func (r *Replayer) Sources(p pair.Pair) []PriceSource {
+	names := make([]string, 0, len(r.series[p]))
+	for name := range r.series[p] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sources := make([]PriceSource, len(names))
+	for i, name := range names {
+		sources[i] = &ReplaySource{
+			name:     name,
+			replayer: r,
+			series:   r.series[p][name],
+		}
+	}
+	return sources
+}
+
+// ReplaySource returns the latest recorded quote of one source as of the replay clock
+type ReplaySource struct {
+	name     string
+	replayer *Replayer
+	series   []Recording
+}
+
+// Name returns the recorded source name
+func (s *ReplaySource) Name() string {
+	return s.name
+}
+
+// FetchPrice returns the latest recording at or before the replay clock, dated by its recorded timestamp
+func (s *ReplaySource) FetchPrice(ctx context.Context) (*Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if s.replayer.Finished() {
+		return nil, newSourceError(ErrorClassStale, 0, fmt.Errorf("replay of %s has finished", s.name))
+	}
+
+	now := s.replayer.Now()
+	next := sort.Search(len(s.series), func(i int) bool {
+		return s.series[i].Timestamp.After(now)
+	})
+	if next == 0 {
+		return nil, newSourceError(ErrorClassStale, 0, fmt.Errorf("no recorded quote from %s yet", s.name))
+	}
+
+	recording := s.series[next-1]
+	if recording.Price <= 0 {
+		return nil, newSourceError(ErrorClassInvalidPrice, 0, fmt.Errorf("invalid price received from %s: %f", s.name, recording.Price))
+	}
+
+	quote := &Quote{
+		Source:     s.name,
+		Price:      recording.Price,
+		Timestamp:  recording.Timestamp,
+		ObservedAt: recording.ObservedAt,
+	}
+	if recording.Raw != "" {
+		quote.Raw = []byte(recording.Raw)
+	}
+	if recording.ExactPrice != "" {
+		exact, err := fixedpoint.ParseExact(recording.ExactPrice)
+		if err != nil {
+			return nil, newSourceError(ErrorClassDecode, 0, fmt.Errorf("invalid exact price recorded from %s: %w", s.name, err))
+		}
+		quote.Exact = exact
+	}
+	return quote, nil
+}
@@ -0,0 +1,195 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// scriptedSource returns the given quotes in turn
+type scriptedSource struct {
+	name   string
+	quotes []Quote
+}
+
+func (s *scriptedSource) Name() string {
+	return s.name
+}
+
+func (s *scriptedSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	quote := s.quotes[0]
+	s.quotes = s.quotes[1:]
+	return &quote, nil
+}
+
+// exactPrice parses an exact price, failing the test on error
+func exactPrice(t *testing.T, s string) fixedpoint.Price {
+	t.Helper()
+
+	price, err := fixedpoint.ParseExact(s)
+	if err != nil {
+		t.Fatalf("ParseExact(%q) error = %v", s, err)
+	}
+	return price
+}
+
+// recordSession records quotes of ETH/USD from binance and kraken spaced step apart, starting at origin
+func recordSession(t *testing.T, origin time.Time, step time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+
+	binance := NewRecordingSource(&scriptedSource{name: "binance", quotes: []Quote{
+		{Source: "binance", Price: 3412.01, Exact: exactPrice(t, "3412.01"), Timestamp: origin, ObservedAt: origin.Add(-time.Millisecond), Raw: []byte(`{"price":"3412.01"}`)},
+		{Source: "binance", Price: 3413.5, Exact: exactPrice(t, "3413.50"), Timestamp: origin.Add(step)},
+		{Source: "binance", Price: 3414.25, Timestamp: origin.Add(2 * step)},
+	}}, pair.ETHUSD, recorder)
+	kraken := NewRecordingSource(&scriptedSource{name: "kraken", quotes: []Quote{
+		{Source: "kraken", Price: 3412.58, Timestamp: origin.Add(step / 2)},
+	}}, pair.ETHUSD, recorder)
+
+	for range 3 {
+		if _, err := binance.FetchPrice(context.Background()); err != nil {
+			t.Fatalf("FetchPrice() error = %v", err)
+		}
+	}
+	if _, err := kraken.FetchPrice(context.Background()); err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+// replaySources returns the binance and kraken replay sources of ETH/USD
+func replaySources(t *testing.T, replayer *Replayer) (PriceSource, PriceSource) {
+	t.Helper()
+
+	sources := replayer.Sources(pair.ETHUSD)
+	if len(sources) != 2 || sources[0].Name() != "binance" || sources[1].Name() != "kraken" {
+		t.Fatalf("Sources() = %v, want binance and kraken", sources)
+	}
+	return sources[0], sources[1]
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	origin := time.Date(2024, 6, 10, 16, 0, 0, 123456789, time.UTC)
+	recordings, err := ReadRecordings(recordSession(t, origin, time.Second))
+	if err != nil {
+		t.Fatalf("ReadRecordings() error = %v", err)
+	}
+	if len(recordings) != 4 {
+		t.Fatalf("ReadRecordings() returned %d recordings, want 4", len(recordings))
+	}
+
+	first := recordings[0]
+	if first.Pair != "ETH/USD" || first.Source != "binance" || first.Price != 3412.01 || first.ExactPrice != "3412.01" {
+		t.Errorf("first recording = %+v, want binance ETH/USD at 3412.01", first)
+	}
+	if !first.Timestamp.Equal(origin) || !first.ObservedAt.Equal(origin.Add(-time.Millisecond)) {
+		t.Errorf("first recording times = %v, %v, want %v, %v", first.Timestamp, first.ObservedAt, origin, origin.Add(-time.Millisecond))
+	}
+	if first.Raw != `{"price":"3412.01"}` {
+		t.Errorf("first recording raw = %q, want the upstream response", first.Raw)
+	}
+	// Quotes without an exact price record none
+	if recordings[2].ExactPrice != "" {
+		t.Errorf("third recording exact price = %q, want none", recordings[2].ExactPrice)
+	}
+}
+
+func TestReplayInRealTime(t *testing.T) {
+	origin := time.Date(2024, 6, 10, 16, 0, 0, 0, time.UTC)
+	step := 200 * time.Millisecond
+	replayer, err := LoadReplayer(recordSession(t, origin, step), 1)
+	if err != nil {
+		t.Fatalf("LoadReplayer() error = %v", err)
+	}
+	binance, kraken := replaySources(t, replayer)
+	ctx := context.Background()
+
+	// The clock starts at the first recording
+	quote, err := binance.FetchPrice(ctx)
+	if err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	if quote.Price != 3412.01 || !quote.Timestamp.Equal(origin) || quote.Exact.String() != "3412.01" || string(quote.Raw) != `{"price":"3412.01"}` {
+		t.Errorf("first replayed quote = %+v, want the first recording", quote)
+	}
+	// Kraken's first quote lies half a step ahead
+	_, err = kraken.FetchPrice(ctx)
+	var sourceErr *SourceError
+	if !errors.As(err, &sourceErr) || sourceErr.Class != ErrorClassStale {
+		t.Errorf("FetchPrice() before kraken's first recording error = %v, want %s", err, ErrorClassStale)
+	}
+
+	time.Sleep(step + step/4)
+	if quote, err := binance.FetchPrice(ctx); err != nil || quote.Price != 3413.5 || quote.Exact.String() != "3413.5" {
+		t.Errorf("FetchPrice() after one step = %+v, %v, want 3413.5", quote, err)
+	}
+	if quote, err := kraken.FetchPrice(ctx); err != nil || quote.Price != 3412.58 {
+		t.Errorf("kraken FetchPrice() after one step = %+v, %v, want 3412.58", quote, err)
+	}
+
+	time.Sleep(step + step/4)
+	if !replayer.Finished() {
+		t.Errorf("Finished() = false at %v, want the replay past its last recording", replayer.Now())
+	}
+	if _, err := binance.FetchPrice(ctx); !errors.As(err, &sourceErr) || sourceErr.Class != ErrorClassStale {
+		t.Errorf("FetchPrice() after the replay finished error = %v, want %s", err, ErrorClassStale)
+	}
+}
+
+func TestReplayAccelerated(t *testing.T) {
+	origin := time.Date(2024, 6, 10, 16, 0, 0, 0, time.UTC)
+	step := time.Minute
+	// A minute of recording passes in 60ms
+	replayer, err := LoadReplayer(recordSession(t, origin, step), 1000)
+	if err != nil {
+		t.Fatalf("LoadReplayer() error = %v", err)
+	}
+	binance, _ := replaySources(t, replayer)
+	ctx := context.Background()
+
+	if quote, err := binance.FetchPrice(ctx); err != nil || quote.Price != 3412.01 {
+		t.Fatalf("first replayed quote = %+v, %v, want 3412.01", quote, err)
+	}
+
+	time.Sleep(75 * time.Millisecond)
+	quote, err := binance.FetchPrice(ctx)
+	if err != nil || quote.Price != 3413.5 {
+		t.Fatalf("FetchPrice() after a replayed minute = %+v, %v, want 3413.5", quote, err)
+	}
+	// Quotes keep their recorded timestamps, not the wall clock
+	if !quote.Timestamp.Equal(origin.Add(step)) {
+		t.Errorf("replayed timestamp = %v, want %v", quote.Timestamp, origin.Add(step))
+	}
+	if now := replayer.Now(); now.Before(origin.Add(step)) || now.After(origin.Add(2*step)) {
+		t.Errorf("Now() = %v, want between one and two replayed minutes in", now)
+	}
+}
+
+func TestNewReplayerRejectsInvalidInput(t *testing.T) {
+	recording := Recording{Timestamp: time.Now(), Pair: "ETH/USD", Source: "binance", Price: 3412.01}
+
+	if _, err := NewReplayer(nil, 1); err == nil {
+		t.Error("NewReplayer() of an empty recording succeeded")
+	}
+	if _, err := NewReplayer([]Recording{recording}, 0); err == nil {
+		t.Error("NewReplayer() with speed 0 succeeded")
+	}
+	recording.Pair = "ETHUSD"
+	if _, err := NewReplayer([]Recording{recording}, 1); err == nil {
+		t.Error("NewReplayer() of a recording with an invalid pair succeeded")
+	}
+}
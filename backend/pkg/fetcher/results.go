@@ -0,0 +1,211 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrorClass classifies why a source failed to return a quote
+type ErrorClass string
+
+const (
+	ErrorClassNone         ErrorClass = ""
+	ErrorClassTimeout      ErrorClass = "timeout"
+	ErrorClassCanceled     ErrorClass = "canceled"
+	ErrorClassNetwork      ErrorClass = "network"
+	ErrorClassHTTPStatus   ErrorClass = "http_status"
+	ErrorClassDecode       ErrorClass = "decode"
+	ErrorClassInvalidPrice ErrorClass = "invalid_price"
+	ErrorClassUnknown      ErrorClass = "unknown"
+)
+
+// SourceError is a classified failure returned by a price source
+type SourceError struct {
+	Class      ErrorClass
+	StatusCode int
+	Err        error
+}
+
+// Error implements the error interface
+func (e *SourceError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// newSourceError wraps err with a classification
+func newSourceError(class ErrorClass, statusCode int, err error) *SourceError {
+	return &SourceError{Class: class, StatusCode: statusCode, Err: err}
+}
+
+// ClassifyError returns the error class and HTTP status code (if any) of a source failure
+func ClassifyError(err error) (ErrorClass, int) {
+	if err == nil {
+		return ErrorClassNone, 0
+	}
+
+	var backoffErr *BackoffError
+	if errors.As(err, &backoffErr) {
+		return ErrorClassBackoff, 0
+	}
+
+	var sourceErr *SourceError
+	if errors.As(err, &sourceErr) {
+		return sourceErr.Class, sourceErr.StatusCode
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout, 0
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled, 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout, 0
+		}
+		return ErrorClassNetwork, 0
+	}
+
+	return ErrorClassUnknown, 0
+}
+
+// SourceResult is the outcome of fetching a single source
+type SourceResult struct {
+	Source     string        `json:"source"`
+	Quote      *Quote        `json:"quote,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	StatusCode int           `json:"status_code,omitempty"`
+	ErrorClass ErrorClass    `json:"error_class,omitempty"`
+	Err        error         `json:"-"`
+}
+
+// OK reports whether the source returned a quote
+func (r SourceResult) OK() bool {
+	return r.Err == nil && r.Quote != nil
+}
+
+// Skipped reports whether the source was not contacted because it is backing off or its circuit is open
+func (r SourceResult) Skipped() bool {
+	return r.ErrorClass == ErrorClassBackoff || r.ErrorClass == ErrorClassCircuitOpen
+}
+
+// FetchReport collects the per-source results of one fetch round
+type FetchReport struct {
+	Results []SourceResult
+	// Required is the minimum number of successful sources for the round to count
+	Required int
+}
+
+// Quotes returns the quotes of all successful sources
+func (r *FetchReport) Quotes() []Quote {
+	var quotes []Quote
+	for _, result := range r.Results {
+		if result.OK() {
+			quotes = append(quotes, *result.Quote)
+		}
+	}
+	return quotes
+}
+
+// Succeeded returns the number of sources that returned a quote
+func (r *FetchReport) Succeeded() int {
+	count := 0
+	for _, result := range r.Results {
+		if result.OK() {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed returns the results of sources that were contacted but did not return a quote
+func (r *FetchReport) Failed() []SourceResult {
+	var failed []SourceResult
+	for _, result := range r.Results {
+		if !result.OK() && !result.Skipped() {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Skipped returns the results of sources that were not contacted because they are backing off or their circuit is open
+func (r *FetchReport) Skipped() []SourceResult {
+	var skipped []SourceResult
+	for _, result := range r.Results {
+		if result.Skipped() {
+			skipped = append(skipped, result)
+		}
+	}
+	return skipped
+}
+
+// QuorumMet reports whether enough sources succeeded
+func (r *FetchReport) QuorumMet() bool {
+	return r.Succeeded() >= r.Required
+}
+
+// QuorumError is returned when fewer sources than required returned a quote
+type QuorumError struct {
+	Succeeded int
+	Required  int
+	Total     int
+	Skipped   int
+	Failures  []SourceResult
+}
+
+// Error implements the error interface
+func (e *QuorumError) Error() string {
+	var failures []string
+	for _, failure := range e.Failures {
+		failures = append(failures, fmt.Sprintf("%s: %s: %v", failure.Source, failure.ErrorClass, failure.Err))
+	}
+
+	return fmt.Sprintf("quorum not met: %d of %d sources succeeded, %d required, %d skipped [%s]",
+		e.Succeeded, e.Total, e.Required, e.Skipped, strings.Join(failures, "; "))
+}
+
+// fetchAll fetches every source concurrently and returns the results in source order
+// Sources still in flight when ctx is done are aborted and reported as failures
+func fetchAll(ctx context.Context, sources []PriceSource) []SourceResult {
+	results := make([]SourceResult, len(sources))
+	done := make(chan struct{}, len(sources))
+
+	for i, source := range sources {
+		go func(i int, source PriceSource) {
+			defer func() { done <- struct{}{} }()
+
+			start := time.Now()
+			quote, err := source.FetchPrice(ctx)
+			result := SourceResult{
+				Source:  source.Name(),
+				Latency: time.Since(start),
+			}
+
+			if err != nil {
+				result.Err = err
+				result.ErrorClass, result.StatusCode = ClassifyError(err)
+			} else {
+				result.Quote = quote
+				result.StatusCode = quote.StatusCode
+			}
+			results[i] = result
+		}(i, source)
+	}
+
+	for range sources {
+		<-done
+	}
+
+	return results
+}
@@ -0,0 +1,134 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// slowSource returns its price after a delay
+type slowSource struct {
+	fixedSource
+	delay time.Duration
+}
+
+func (s *slowSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.fixedSource.FetchPrice(ctx)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestFetchReportQuorumMet(t *testing.T) {
+	ok := SourceResult{Source: "ok", Quote: &Quote{Source: "ok", Price: 100}}
+	failed := SourceResult{Source: "failed", ErrorClass: ErrorClassNetwork, Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		results  []SourceResult
+		required int
+		want     bool
+	}{
+		{name: "all succeeded", results: []SourceResult{ok, ok}, required: 2, want: true},
+		{name: "enough succeeded", results: []SourceResult{ok, failed, ok}, required: 2, want: true},
+		{name: "too few succeeded", results: []SourceResult{ok, failed, failed}, required: 2, want: false},
+		{name: "none succeeded", results: []SourceResult{failed}, required: 1, want: false},
+		{name: "no results", results: nil, required: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &FetchReport{Results: tt.results, Required: tt.required}
+			if got := report.QuorumMet(); got != tt.want {
+				t.Errorf("QuorumMet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchQuotesQuorumError(t *testing.T) {
+	sources := []PriceSource{
+		&fixedSource{name: "binance", price: 3412.57},
+		&fixedSource{name: "kraken", err: newSourceError(ErrorClassHTTPStatus, 503, fmt.Errorf("unexpected status code: 503"))},
+		&fixedSource{name: "coinbase", err: &BackoffError{Until: time.Now().Add(time.Minute), Reason: "budget exhausted"}},
+		&fixedSource{name: "bitstamp", err: newSourceError(ErrorClassCircuitOpen, 0, fmt.Errorf("circuit open for bitstamp"))},
+	}
+	f := NewFetcherWithSources(sources, time.Second)
+	if err := f.SetQuorum(2); err != nil {
+		t.Fatalf("SetQuorum() error = %v", err)
+	}
+
+	report, err := f.FetchQuotes(context.Background())
+	var quorumErr *QuorumError
+	if !errors.As(err, &quorumErr) {
+		t.Fatalf("FetchQuotes() error = %v, want a *QuorumError", err)
+	}
+	if report == nil || len(report.Results) != len(sources) {
+		t.Fatalf("FetchQuotes() report = %+v, want a result for every source", report)
+	}
+
+	if quorumErr.Succeeded != 1 || quorumErr.Required != 2 || quorumErr.Total != 4 {
+		t.Errorf("QuorumError = %d of %d succeeded, %d required, want 1 of 4, 2 required", quorumErr.Succeeded, quorumErr.Total, quorumErr.Required)
+	}
+	// Neither the source backing off nor the one with an open circuit was contacted
+	if quorumErr.Skipped != 2 {
+		t.Errorf("QuorumError.Skipped = %d, want 2", quorumErr.Skipped)
+	}
+	if len(quorumErr.Failures) != 1 {
+		t.Fatalf("QuorumError.Failures = %+v, want only kraken", quorumErr.Failures)
+	}
+	failure := quorumErr.Failures[0]
+	if failure.Source != "kraken" || failure.ErrorClass != ErrorClassHTTPStatus || failure.StatusCode != 503 {
+		t.Errorf("QuorumError.Failures[0] = %s %s %d, want kraken %s 503", failure.Source, failure.ErrorClass, failure.StatusCode, ErrorClassHTTPStatus)
+	}
+
+	skipped := report.Skipped()
+	if len(skipped) != 2 || skipped[0].ErrorClass != ErrorClassBackoff || skipped[1].ErrorClass != ErrorClassCircuitOpen {
+		t.Errorf("Skipped() = %+v, want coinbase backing off and bitstamp with an open circuit", skipped)
+	}
+}
+
+func TestFetchAllWaitsForSlowSources(t *testing.T) {
+	sources := []PriceSource{
+		&slowSource{fixedSource: fixedSource{name: "slow", price: 3412.57}, delay: 50 * time.Millisecond},
+		&fixedSource{name: "fast", price: 3413.01},
+	}
+
+	start := time.Now()
+	results := fetchAll(context.Background(), sources)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("fetchAll() returned after %v, before the slow source answered", elapsed)
+	}
+
+	// Results keep the source order, whichever answered first
+	if len(results) != 2 || results[0].Source != "slow" || results[1].Source != "fast" {
+		t.Fatalf("fetchAll() = %+v, want slow then fast", results)
+	}
+	if !results[0].OK() || results[0].Quote.Price != 3412.57 {
+		t.Errorf("slow result = %+v, want its quote", results[0])
+	}
+	if results[0].Latency < 50*time.Millisecond {
+		t.Errorf("slow latency = %v, want at least 50ms", results[0].Latency)
+	}
+}
+
+func TestFetchAllAbortsSourcesWhenContextDone(t *testing.T) {
+	sources := []PriceSource{
+		&slowSource{fixedSource: fixedSource{name: "slow", price: 3412.57}, delay: time.Minute},
+		&fixedSource{name: "fast", price: 3413.01},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	results := fetchAll(ctx, sources)
+	if results[0].OK() || results[0].ErrorClass != ErrorClassTimeout {
+		t.Errorf("slow result = %+v, want a %s failure", results[0], ErrorClassTimeout)
+	}
+	if !results[1].OK() {
+		t.Errorf("fast result = %+v, want its quote", results[1])
+	}
+}
@@ -0,0 +1,281 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SimulationModel selects how a simulator source moves its price
+type SimulationModel string
+
+const (
+	// SimulationGBM follows geometric Brownian motion
+	SimulationGBM SimulationModel = "gbm"
+	// SimulationJump adds random log-normal jumps to the GBM path
+	SimulationJump SimulationModel = "jump"
+	// SimulationFlashCrash drops the GBM path by a fixed depth and then recovers linearly
+	SimulationFlashCrash SimulationModel = "flash_crash"
+	// SimulationFrozen follows the GBM path and then keeps returning the same quote
+	SimulationFrozen SimulationModel = "frozen"
+	// SimulationDrift follows the GBM path while diverging from it a little more every step
+	SimulationDrift SimulationModel = "drift"
+)
+
+// secondsPerYear converts steps to the annualised time unit of volatility and drift
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// SimulatorConfig configures the price path of a simulator source
+// Simulator sources with the same seed and GBM settings share the same underlying path, so a
+// drifting or crashing source can be set up against otherwise agreeing sources
+type SimulatorConfig struct {
+	Model SimulationModel
+	// Seed makes the path reproducible
+	Seed int64
+	// StartPrice is the price before the first step
+	StartPrice float64
+	// Volatility and Drift are the annualised volatility and drift of the GBM path
+	Volatility float64
+	Drift      float64
+	// Step is the simulated time between two fetches
+	Step time.Duration
+	// Noise is the relative standard deviation of the source's own noise around the path
+	Noise float64
+
+	// JumpProbability is the chance of a jump at each step and JumpSize the standard deviation of its log size
+	JumpProbability float64
+	JumpSize        float64
+	// CrashAfter is the step the flash crash happens at, CrashDepth the relative drop and
+	// CrashRecovery the number of steps back to the path
+	CrashAfter    int
+	CrashDepth    float64
+	CrashRecovery int
+	// FreezeAfter is the step after which a frozen source stops updating
+	FreezeAfter int
+	// Divergence is the relative amount a drifting source moves away from the path per step
+	Divergence float64
+}
+
+// DefaultSimulatorConfig returns a GBM simulation with crypto-like volatility around an ETH/USD-like price
+func DefaultSimulatorConfig() SimulatorConfig {
+	return SimulatorConfig{
+		Model:           SimulationGBM,
+		Seed:            1,
+		StartPrice:      3000,
+		Volatility:      0.8,
+		Step:            30 * time.Second,
+		JumpProbability: 0.01,
+		JumpSize:        0.05,
+		CrashAfter:      20,
+		CrashDepth:      0.5,
+		CrashRecovery:   10,
+		FreezeAfter:     20,
+		Divergence:      0.001,
+	}
+}
+
+// Validate checks if the simulator configuration is valid
+func (c SimulatorConfig) Validate() error {
+	switch c.Model {
+	case SimulationGBM, SimulationJump, SimulationFlashCrash, SimulationFrozen, SimulationDrift:
+	default:
+		return fmt.Errorf("unknown simulation model %q", c.Model)
+	}
+	if c.StartPrice <= 0 {
+		return fmt.Errorf("start price must be positive, got: %f", c.StartPrice)
+	}
+	if c.Volatility < 0 || c.Noise < 0 || c.JumpSize < 0 {
+		return fmt.Errorf("volatility, noise and jump size must not be negative")
+	}
+	if c.Step <= 0 {
+		return fmt.Errorf("step must be positive, got: %v", c.Step)
+	}
+	if c.JumpProbability < 0 || c.JumpProbability > 1 {
+		return fmt.Errorf("jump probability must be between 0 and 1, got: %f", c.JumpProbability)
+	}
+	if c.CrashDepth < 0 || c.CrashDepth >= 1 {
+		return fmt.Errorf("crash depth must be between 0 and 1, got: %f", c.CrashDepth)
+	}
+	if c.CrashAfter < 0 || c.CrashRecovery < 0 || c.FreezeAfter < 0 {
+		return fmt.Errorf("crash and freeze steps must not be negative")
+	}
+	return nil
+}
+
+// SimulatorSource generates prices from a seeded market model, one step per fetch
+type SimulatorSource struct {
+	name   string
+	config SimulatorConfig
+
+	mu sync.Mutex
+	// path drives the shared GBM path and jumps, noise the source's own noise
+	path  *rand.Rand
+	noise *rand.Rand
+	step  int
+	price float64
+	// frozen is the quote a frozen source keeps returning once it has stopped
+	frozen *Quote
+}
+
+// NewSimulatorSource creates a simulator source following config
+func NewSimulatorSource(name string, config SimulatorConfig) (*SimulatorSource, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid simulator config: %w", err)
+	}
+
+	// The noise is seeded by name as well, so sources sharing a path still disagree slightly
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+
+	return &SimulatorSource{
+		name:   name,
+		config: config,
+		path:   rand.New(rand.NewSource(config.Seed)),
+		noise:  rand.New(rand.NewSource(config.Seed ^ int64(hash.Sum64()))),
+		price:  config.StartPrice,
+	}, nil
+}
+
+// Name returns the source name
+func (s *SimulatorSource) Name() string {
+	return s.name
+}
+
+// FetchPrice advances the simulation by one step and returns the resulting price
+func (s *SimulatorSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A frozen feed keeps answering with its last price and observation time
+	if s.frozen != nil {
+		quote := *s.frozen
+		quote.Timestamp = time.Now()
+		return &quote, nil
+	}
+
+	s.advance()
+	price := s.shape(s.price)
+	if s.config.Noise > 0 {
+		price *= 1 + s.config.Noise*s.noise.NormFloat64()
+	}
+	if price <= 0 || math.IsInf(price, 0) || math.IsNaN(price) {
+		return nil, newSourceError(ErrorClassInvalidPrice, 0, fmt.Errorf("invalid price received from %s: %f", s.name, price))
+	}
+
+	now := time.Now()
+	quote := &Quote{
+		Source:     s.name,
+		Price:      price,
+		Timestamp:  now,
+		ObservedAt: now,
+	}
+	if s.config.Model == SimulationFrozen && s.step >= s.config.FreezeAfter {
+		frozen := *quote
+		s.frozen = &frozen
+	}
+	return quote, nil
+}
+
+// advance moves the underlying path one step
+func (s *SimulatorSource) advance() {
+	s.step++
+
+	dt := s.config.Step.Seconds() / secondsPerYear
+	sigma := s.config.Volatility
+	s.price *= math.Exp((s.config.Drift-sigma*sigma/2)*dt + sigma*math.Sqrt(dt)*s.path.NormFloat64())
+
+	// Jumps are drawn for every model so that sources sharing a seed stay on the same path
+	jump := s.path.Float64() < s.config.JumpProbability
+	jumpSize := s.config.JumpSize * s.path.NormFloat64()
+	if s.config.Model == SimulationJump && jump {
+		s.price *= math.Exp(jumpSize)
+	}
+}
+
+// shape applies the model's deviation from the underlying path at the current step
+func (s *SimulatorSource) shape(price float64) float64 {
+	switch s.config.Model {
+	case SimulationFlashCrash:
+		since := s.step - s.config.CrashAfter
+		if since < 0 || since > s.config.CrashRecovery {
+			return price
+		}
+		recovered := 1.0
+		if s.config.CrashRecovery > 0 {
+			recovered = float64(since) / float64(s.config.CrashRecovery)
+		}
+		return price * (1 - s.config.CrashDepth*(1-recovered))
+	case SimulationDrift:
+		return price * math.Pow(1+s.config.Divergence, float64(s.step))
+	}
+	return price
+}
+
+// newSimulatorSourceFromParams builds a simulator source, overriding the defaults with its params
+func newSimulatorSourceFromParams(name string, params map[string]string) (PriceSource, error) {
+	config := DefaultSimulatorConfig()
+	if value := params["model"]; value != "" {
+		config.Model = SimulationModel(value)
+	}
+
+	floats := map[string]*float64{
+		"start_price":      &config.StartPrice,
+		"volatility":       &config.Volatility,
+		"drift":            &config.Drift,
+		"noise":            &config.Noise,
+		"jump_probability": &config.JumpProbability,
+		"jump_size":        &config.JumpSize,
+		"crash_depth":      &config.CrashDepth,
+		"divergence":       &config.Divergence,
+	}
+	for key, target := range floats {
+		if value := params[key]; value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
+			*target = parsed
+		}
+	}
+
+	ints := map[string]*int{
+		"crash_after":    &config.CrashAfter,
+		"crash_recovery": &config.CrashRecovery,
+		"freeze_after":   &config.FreezeAfter,
+	}
+	for key, target := range ints {
+		if value := params[key]; value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
+			*target = parsed
+		}
+	}
+
+	if value := params["seed"]; value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q: %w", value, err)
+		}
+		config.Seed = seed
+	}
+	if value := params["step"]; value != "" {
+		step, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %q: %w", value, err)
+		}
+		config.Step = step
+	}
+
+	return NewSimulatorSource(name, config)
+}
@@ -0,0 +1,202 @@
+package fetcher
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// simulate fetches steps prices from a new simulator source
+func simulate(t *testing.T, name string, config SimulatorConfig, steps int) []*Quote {
+	t.Helper()
+
+	source, err := NewSimulatorSource(name, config)
+	if err != nil {
+		t.Fatalf("NewSimulatorSource() error = %v", err)
+	}
+	quotes := make([]*Quote, steps)
+	for i := range quotes {
+		quote, err := source.FetchPrice(context.Background())
+		if err != nil {
+			t.Fatalf("FetchPrice() at step %d error = %v", i+1, err)
+		}
+		quotes[i] = quote
+	}
+	return quotes
+}
+
+// calmConfig returns a noiseless simulation of model that only moves where the model says so
+func calmConfig(model SimulationModel) SimulatorConfig {
+	config := DefaultSimulatorConfig()
+	config.Model = model
+	config.Volatility = 0
+	return config
+}
+
+// closeTo reports whether a is within a relative 1e-9 of b
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Abs(b)
+}
+
+func TestSimulatorIsDeterministic(t *testing.T) {
+	config := DefaultSimulatorConfig()
+	config.Noise = 0.001
+
+	first := simulate(t, "sim", config, 50)
+	second := simulate(t, "sim", config, 50)
+	for i := range first {
+		if first[i].Price != second[i].Price {
+			t.Fatalf("step %d price = %v then %v, want the same path for the same seed", i+1, first[i].Price, second[i].Price)
+		}
+	}
+
+	config.Seed = 2
+	other := simulate(t, "sim", config, 50)
+	if other[49].Price == first[49].Price {
+		t.Errorf("seeds 1 and 2 ended at the same price %v", first[49].Price)
+	}
+}
+
+func TestSimulatorGBM(t *testing.T) {
+	// Without volatility the path grows at exactly the drift
+	config := calmConfig(SimulationGBM)
+	config.Drift = 0.5
+	quotes := simulate(t, "sim", config, 10)
+	dt := config.Step.Seconds() / secondsPerYear
+	for i, quote := range quotes {
+		if want := config.StartPrice * math.Exp(config.Drift*dt*float64(i+1)); !closeTo(quote.Price, want) {
+			t.Errorf("step %d price = %v, want %v", i+1, quote.Price, want)
+		}
+	}
+
+	// Sources sharing a seed follow the same path and only their noise sets them apart
+	config = DefaultSimulatorConfig()
+	config.Noise = 0.001
+	a := simulate(t, "a", config, 50)
+	b := simulate(t, "b", config, 50)
+	for i := range a {
+		if a[i].Price == b[i].Price {
+			t.Errorf("step %d: sources a and b agree exactly at %v, want their own noise", i+1, a[i].Price)
+		}
+		if math.Abs(a[i].Price/b[i].Price-1) > 0.01 {
+			t.Errorf("step %d: sources a and b are %v and %v, want them on the same path", i+1, a[i].Price, b[i].Price)
+		}
+	}
+}
+
+func TestSimulatorJump(t *testing.T) {
+	config := calmConfig(SimulationJump)
+	config.JumpProbability = 1
+	jumps := simulate(t, "sim", config, 20)
+
+	// The same seed without jumps stays at the start price
+	config.Model = SimulationGBM
+	path := simulate(t, "sim", config, 20)
+
+	moved := 0
+	for i := range jumps {
+		if path[i].Price != config.StartPrice {
+			t.Fatalf("step %d GBM price = %v, want the start price without volatility", i+1, path[i].Price)
+		}
+		previous := config.StartPrice
+		if i > 0 {
+			previous = jumps[i-1].Price
+		}
+		if jumps[i].Price != previous {
+			moved++
+		}
+	}
+	if moved != len(jumps) {
+		t.Errorf("%d of %d steps jumped, want every step with a jump probability of 1", moved, len(jumps))
+	}
+
+	config.Model = SimulationJump
+	config.JumpProbability = 0
+	for i, quote := range simulate(t, "sim", config, 20) {
+		if quote.Price != config.StartPrice {
+			t.Errorf("step %d price = %v, want no jumps with a jump probability of 0", i+1, quote.Price)
+		}
+	}
+}
+
+func TestSimulatorFlashCrash(t *testing.T) {
+	config := calmConfig(SimulationFlashCrash)
+	config.CrashAfter = 5
+	config.CrashDepth = 0.5
+	config.CrashRecovery = 4
+	quotes := simulate(t, "sim", config, 12)
+
+	// The crash halves the price at step 5 and recovers linearly over the next 4 steps
+	want := []float64{3000, 3000, 3000, 3000, 1500, 1875, 2250, 2625, 3000, 3000, 3000, 3000}
+	for i, quote := range quotes {
+		if !closeTo(quote.Price, want[i]) {
+			t.Errorf("step %d price = %v, want %v", i+1, quote.Price, want[i])
+		}
+	}
+}
+
+func TestSimulatorFreeze(t *testing.T) {
+	config := DefaultSimulatorConfig()
+	config.Model = SimulationFrozen
+	config.FreezeAfter = 3
+	quotes := simulate(t, "sim", config, 6)
+
+	if quotes[0].Price == quotes[1].Price || quotes[1].Price == quotes[2].Price {
+		t.Errorf("prices before the freeze = %v, %v, %v, want them moving", quotes[0].Price, quotes[1].Price, quotes[2].Price)
+	}
+	frozen := quotes[2]
+	for i, quote := range quotes[3:] {
+		if quote.Price != frozen.Price || !quote.ObservedAt.Equal(frozen.ObservedAt) {
+			t.Errorf("step %d = %v observed at %v, want the frozen %v observed at %v", i+4, quote.Price, quote.ObservedAt, frozen.Price, frozen.ObservedAt)
+		}
+		// The feed still answers, so only the observation time gives it away
+		if quote.Timestamp.Before(frozen.Timestamp) {
+			t.Errorf("step %d fetched at %v, before the frozen quote", i+4, quote.Timestamp)
+		}
+	}
+}
+
+func TestSimulatorDivergence(t *testing.T) {
+	config := DefaultSimulatorConfig()
+	config.Model = SimulationDrift
+	config.Divergence = 0.01
+	drifting := simulate(t, "sim", config, 10)
+
+	config.Model = SimulationGBM
+	path := simulate(t, "sim", config, 10)
+
+	// The drifting source moves a compounding 1% further from the shared path every step
+	for i := range drifting {
+		if want := math.Pow(1.01, float64(i+1)); !closeTo(drifting[i].Price/path[i].Price, want) {
+			t.Errorf("step %d divergence = %v, want %v", i+1, drifting[i].Price/path[i].Price, want)
+		}
+	}
+}
+
+func TestSimulatorConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*SimulatorConfig)
+	}{
+		{name: "unknown model", modify: func(c *SimulatorConfig) { c.Model = "random_walk" }},
+		{name: "zero start price", modify: func(c *SimulatorConfig) { c.StartPrice = 0 }},
+		{name: "negative volatility", modify: func(c *SimulatorConfig) { c.Volatility = -0.1 }},
+		{name: "zero step", modify: func(c *SimulatorConfig) { c.Step = 0 }},
+		{name: "jump probability above 1", modify: func(c *SimulatorConfig) { c.JumpProbability = 1.5 }},
+		{name: "total crash", modify: func(c *SimulatorConfig) { c.CrashDepth = 1 }},
+		{name: "negative freeze step", modify: func(c *SimulatorConfig) { c.FreezeAfter = -1 }},
+	}
+
+	if err := DefaultSimulatorConfig().Validate(); err != nil {
+		t.Errorf("DefaultSimulatorConfig().Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultSimulatorConfig()
+			tt.modify(&config)
+			if err := config.Validate(); err == nil {
+				t.Error("Validate() succeeded, want an error")
+			}
+		})
+	}
+}
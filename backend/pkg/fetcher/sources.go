@@ -0,0 +1,371 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+// Quote is a single price observation returned by a price source
+type Quote struct {
+	Source string  `json:"source"`
+	Price  float64 `json:"price"`
+	// Exact is the price exactly as the source reported it, or zero when the source only has a float
+	Exact fixedpoint.Price `json:"-"`
+	// Timestamp is when the quote was fetched
+	Timestamp time.Time `json:"timestamp"`
+	// ObservedAt is when the provider last updated the price, or zero if it does not say
+	ObservedAt time.Time `json:"observed_at"`
+	// StatusCode is the HTTP status of the response the quote was read from, if any
+	StatusCode int `json:"status_code,omitempty"`
+	// Header holds the headers of the HTTP response the quote was read from, if any
+	Header http.Header `json:"-"`
+	// Raw is the upstream response or message the quote was read from, if any
+	Raw []byte `json:"-"`
+	// QuoteAsset is the stablecoin the venue quoted in and ConversionRate the rate the price was converted
+	// at; both are empty for quotes in the pair's own quote currency
+	QuoteAsset     string  `json:"quote_asset,omitempty"`
+	ConversionRate float64 `json:"conversion_rate,omitempty"`
+	// Depegged flags a quote converted from a stablecoin that was off its peg
+	Depegged bool `json:"depegged,omitempty"`
+}
+
+// ObservedTime returns when the price was observed, falling back to the fetch time when the provider does not say
+func (q Quote) ObservedTime() time.Time {
+	if q.ObservedAt.IsZero() {
+		return q.Timestamp
+	}
+	return q.ObservedAt
+}
+
+// ExactPrice returns the exact price the source reported, falling back to the float's shortest decimal
+func (q Quote) ExactPrice() fixedpoint.Price {
+	if q.Exact.Sign() > 0 {
+		return q.Exact
+	}
+	exact, err := fixedpoint.FromFloatExact(q.Price)
+	if err != nil {
+		return fixedpoint.Price{}
+	}
+	return exact
+}
+
+// PriceSource is implemented by every upstream price provider
+type PriceSource interface {
+	// Name returns the unique name of the source, used in logs and metrics
+	Name() string
+	// FetchPrice fetches the latest quote for the source's pair, aborting when ctx is done
+	FetchPrice(ctx context.Context) (*Quote, error)
+}
+
+// Default ticker endpoints for the built-in adapters; placeholders are expanded by ExpandURL
+const (
+	DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3/simple/price?ids={symbol}&vs_currencies={quote_lower}&include_last_updated_at=true"
+	DefaultBinanceURL   = "https://api.binance.com/api/v3/trades?symbol={symbol}&limit=1"
+	DefaultCoinbaseURL  = "https://api.exchange.coinbase.com/products/{symbol}/ticker"
+	DefaultKrakenURL    = "https://api.kraken.com/0/public/Ticker?pair={symbol}"
+)
+
+// httpSource holds the shared plumbing of the REST ticker adapters
+type httpSource struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// Name returns the source name
+func (s *httpSource) Name() string {
+	return s.name
+}
+
+// upstreamResponse is the status, headers and body of a successful upstream response
+type upstreamResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// getJSON performs a GET request against the source URL and decodes the JSON body into out
+// It returns the response it decoded; failures are returned as *SourceError
+func (s *httpSource) getJSON(ctx context.Context, out interface{}) (*upstreamResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return nil, newSourceError(ErrorClassUnknown, 0, fmt.Errorf("failed to create request: %w", err))
+	}
+
+	// Set headers for better API compatibility
+	req.Header.Set("User-Agent", "DeFiOraclePipeline/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch price: %w", err)
+		class, _ := ClassifyError(err)
+		if class == ErrorClassUnknown {
+			class = ErrorClassNetwork
+		}
+		return nil, newSourceError(class, 0, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		return nil, newSourceError(ErrorClassRateLimited, resp.StatusCode, fmt.Errorf("API rate limited us with status %d", resp.StatusCode))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newSourceError(ErrorClassHTTPStatus, resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newSourceError(ErrorClassNetwork, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, newSourceError(ErrorClassDecode, resp.StatusCode, fmt.Errorf("failed to unmarshal response: %w", err))
+	}
+
+	return &upstreamResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       body,
+	}, nil
+}
+
+// backOff holds back requests to the source when its client enforces rate limits
+func (s *httpSource) backOff(delay time.Duration, reason string) {
+	if transport, ok := s.client.Transport.(*RateLimitedTransport); ok {
+		transport.BackOff(time.Now().Add(delay), reason)
+	}
+}
+
+// newQuote builds a quote stamped with the current time after checking the price is positive
+// observedAt is the provider's own time of the price, or zero when the response carries none
+func (s *httpSource) newQuote(price fixedpoint.Price, observedAt time.Time, response *upstreamResponse) (*Quote, error) {
+	if price.Sign() <= 0 {
+		return nil, newSourceError(ErrorClassInvalidPrice, response.statusCode, fmt.Errorf("invalid price received from %s: %s", s.name, price))
+	}
+
+	return &Quote{
+		Source:     s.name,
+		Price:      price.Float64(),
+		Exact:      price,
+		Timestamp:  time.Now(),
+		ObservedAt: observedAt,
+		StatusCode: response.statusCode,
+		Header:     response.header,
+		Raw:        response.body,
+	}, nil
+}
+
+// coinGeckoLastUpdatedKey is the key CoinGecko adds next to the prices when asked to include_last_updated_at
+const coinGeckoLastUpdatedKey = "last_updated_at"
+
+// CoinGeckoSource reads the CoinGecko simple price endpoint
+type CoinGeckoSource struct {
+	httpSource
+}
+
+// NewCoinGeckoSource creates a CoinGecko adapter for the given endpoint
+func NewCoinGeckoSource(name, url string, client *http.Client) *CoinGeckoSource {
+	return &CoinGeckoSource{httpSource{name: name, url: url, client: client}}
+}
+
+// FetchPrice fetches the latest price from CoinGecko
+// The endpoint is expected to ask for a single coin in a single quote currency; the quote is dated
+// by last_updated_at when the endpoint includes it
+func (s *CoinGeckoSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	var resp PriceResponse
+	response, err := s.getJSON(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, prices := range resp {
+		var observedAt time.Time
+		if updatedAt, err := prices[coinGeckoLastUpdatedKey].Int64(); err == nil && updatedAt > 0 {
+			observedAt = time.Unix(updatedAt, 0)
+		}
+		for currency, value := range prices {
+			if currency == coinGeckoLastUpdatedKey {
+				continue
+			}
+			price, err := fixedpoint.ParseExact(value.String())
+			if err != nil {
+				return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to parse CoinGecko price %q: %w", value, err))
+			}
+			return s.newQuote(price, observedAt, response)
+		}
+	}
+
+	return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("CoinGecko returned no price data"))
+}
+
+// BinanceTickerResponse represents the Binance /api/v3/ticker/price response, which carries no time
+type BinanceTickerResponse struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// BinanceTrade represents an entry of the Binance /api/v3/trades response
+type BinanceTrade struct {
+	ID    int64  `json:"id"`
+	Price string `json:"price"`
+	// Time is the trade time in milliseconds since the epoch
+	Time int64 `json:"time"`
+}
+
+// BinanceSource reads the latest Binance trade, or the symbol price ticker when pointed at it
+type BinanceSource struct {
+	httpSource
+}
+
+// NewBinanceSource creates a Binance adapter for the given endpoint
+func NewBinanceSource(name, url string, client *http.Client) *BinanceSource {
+	return &BinanceSource{httpSource{name: name, url: url, client: client}}
+}
+
+// FetchPrice fetches the latest price from Binance, dated by the trade time when reading trades
+func (s *BinanceSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	var resp json.RawMessage
+	response, err := s.getJSON(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var value string
+	var observedAt time.Time
+	if strings.HasPrefix(strings.TrimSpace(string(resp)), "[") {
+		var trades []BinanceTrade
+		if err := json.Unmarshal(resp, &trades); err != nil {
+			return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to unmarshal Binance trades: %w", err))
+		}
+		if len(trades) == 0 {
+			return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("Binance returned no trades"))
+		}
+		latest := trades[len(trades)-1]
+		value, observedAt = latest.Price, time.UnixMilli(latest.Time)
+	} else {
+		var ticker BinanceTickerResponse
+		if err := json.Unmarshal(resp, &ticker); err != nil {
+			return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to unmarshal Binance ticker: %w", err))
+		}
+		value = ticker.Price
+	}
+
+	price, err := fixedpoint.ParseExact(value)
+	if err != nil {
+		return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to parse Binance price %q: %w", value, err))
+	}
+
+	return s.newQuote(price, observedAt, response)
+}
+
+// CoinbaseTickerResponse represents the Coinbase Exchange product ticker response
+type CoinbaseTickerResponse struct {
+	TradeID int64  `json:"trade_id"`
+	Price   string `json:"price"`
+	Bid     string `json:"bid"`
+	Ask     string `json:"ask"`
+	Volume  string `json:"volume"`
+	Time    string `json:"time"`
+}
+
+// CoinbaseSource reads the Coinbase Exchange product ticker
+type CoinbaseSource struct {
+	httpSource
+}
+
+// NewCoinbaseSource creates a Coinbase adapter for the given endpoint
+func NewCoinbaseSource(name, url string, client *http.Client) *CoinbaseSource {
+	return &CoinbaseSource{httpSource{name: name, url: url, client: client}}
+}
+
+// FetchPrice fetches the latest trade price from Coinbase
+func (s *CoinbaseSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	var resp CoinbaseTickerResponse
+	response, err := s.getJSON(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := fixedpoint.ParseExact(resp.Price)
+	if err != nil {
+		return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to parse Coinbase price %q: %w", resp.Price, err))
+	}
+
+	var observedAt time.Time
+	if resp.Time != "" {
+		observedAt, err = time.Parse(time.RFC3339Nano, resp.Time)
+		if err != nil {
+			return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to parse Coinbase time %q: %w", resp.Time, err))
+		}
+	}
+
+	return s.newQuote(price, observedAt, response)
+}
+
+// KrakenTicker holds the fields of a Kraken ticker entry that we use
+type KrakenTicker struct {
+	Ask       []string `json:"a"`
+	Bid       []string `json:"b"`
+	LastTrade []string `json:"c"`
+}
+
+// KrakenTickerResponse represents the Kraken /0/public/Ticker response
+type KrakenTickerResponse struct {
+	Error  []string                `json:"error"`
+	Result map[string]KrakenTicker `json:"result"`
+}
+
+// KrakenSource reads the Kraken public ticker
+type KrakenSource struct {
+	httpSource
+}
+
+// NewKrakenSource creates a Kraken adapter for the given endpoint
+func NewKrakenSource(name, url string, client *http.Client) *KrakenSource {
+	return &KrakenSource{httpSource{name: name, url: url, client: client}}
+}
+
+// FetchPrice fetches the last trade price from Kraken
+// Kraken's ticker carries no time, so the quote is dated by the fetch alone
+func (s *KrakenSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	var resp KrakenTickerResponse
+	response, err := s.getJSON(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Error) > 0 {
+		// Kraken reports rate limiting in the body of a 200 response
+		for _, message := range resp.Error {
+			if strings.Contains(message, "Rate limit exceeded") || strings.Contains(message, "Too many requests") {
+				s.backOff(defaultRateLimitBackoff, message)
+				return nil, newSourceError(ErrorClassRateLimited, response.statusCode, fmt.Errorf("Kraken rate limited us: %v", resp.Error))
+			}
+		}
+		return nil, newSourceError(ErrorClassHTTPStatus, response.statusCode, fmt.Errorf("Kraken returned errors: %v", resp.Error))
+	}
+
+	// Kraken keys the result by its own pair name (e.g. XETHZUSD), so take the single entry
+	for _, ticker := range resp.Result {
+		if len(ticker.LastTrade) == 0 {
+			return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("Kraken ticker has no last trade"))
+		}
+
+		price, err := fixedpoint.ParseExact(ticker.LastTrade[0])
+		if err != nil {
+			return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("failed to parse Kraken price %q: %w", ticker.LastTrade[0], err))
+		}
+
+		return s.newQuote(price, time.Time{}, response)
+	}
+
+	return nil, newSourceError(ErrorClassDecode, response.statusCode, fmt.Errorf("Kraken returned no ticker data"))
+}
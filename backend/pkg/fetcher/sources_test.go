@@ -0,0 +1,212 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveFixture serves the recorded payload in testdata/name with the given status
+func serveFixture(t *testing.T, status int, name string) *httptest.Server {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	return serveBody(t, status, string(body))
+}
+
+// serveBody serves body with the given status to every request
+func serveBody(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// sourceKinds builds each REST adapter for a test server URL
+var sourceKinds = map[string]func(url string) PriceSource{
+	"coingecko": func(url string) PriceSource { return NewCoinGeckoSource("coingecko", url, http.DefaultClient) },
+	"binance":   func(url string) PriceSource { return NewBinanceSource("binance", url, http.DefaultClient) },
+	"coinbase":  func(url string) PriceSource { return NewCoinbaseSource("coinbase", url, http.DefaultClient) },
+	"kraken":    func(url string) PriceSource { return NewKrakenSource("kraken", url, http.DefaultClient) },
+}
+
+func TestSourcesDecodeRecordedPayloads(t *testing.T) {
+	tests := []struct {
+		kind       string
+		fixture    string
+		price      float64
+		exact      string
+		observedAt time.Time
+	}{
+		{kind: "coingecko", fixture: "coingecko_simple_price.json", price: 3412.57, exact: "3412.57", observedAt: time.Unix(1718035200, 0)},
+		{kind: "binance", fixture: "binance_trades.json", price: 3412.01, exact: "3412.01", observedAt: time.UnixMilli(1718035199123)},
+		{kind: "binance", fixture: "binance_ticker_price.json", price: 3411.99, exact: "3411.99"},
+		{kind: "coinbase", fixture: "coinbase_ticker.json", price: 3412.66, exact: "3412.66", observedAt: time.Date(2024, 6, 10, 16, 0, 0, 123456000, time.UTC)},
+		{kind: "kraken", fixture: "kraken_ticker.json", price: 3412.58, exact: "3412.58"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			server := serveFixture(t, http.StatusOK, tt.fixture)
+			source := sourceKinds[tt.kind](server.URL)
+
+			quote, err := source.FetchPrice(context.Background())
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			if quote.Source != tt.kind {
+				t.Errorf("Source = %q, want %q", quote.Source, tt.kind)
+			}
+			if quote.Price != tt.price {
+				t.Errorf("Price = %v, want %v", quote.Price, tt.price)
+			}
+			if exact := quote.Exact.String(); exact != tt.exact {
+				t.Errorf("Exact = %s, want %s", exact, tt.exact)
+			}
+			if !quote.ObservedAt.Equal(tt.observedAt) {
+				t.Errorf("ObservedAt = %v, want %v", quote.ObservedAt, tt.observedAt)
+			}
+			if quote.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, want %d", quote.StatusCode, http.StatusOK)
+			}
+			if len(quote.Raw) == 0 {
+				t.Error("Raw is empty, want the recorded payload")
+			}
+		})
+	}
+}
+
+func TestSourcesKeepPricesExact(t *testing.T) {
+	tests := []struct {
+		kind  string
+		body  string
+		exact string
+	}{
+		// More digits than a float64 holds
+		{kind: "coingecko", body: `{"ethereum":{"usd":1999.999999999999999999,"last_updated_at":1718035200}}`, exact: "1999.999999999999999999"},
+		{kind: "binance", body: `{"symbol":"ETHUSDT","price":"1999.99999999"}`, exact: "1999.99999999"},
+		{kind: "coinbase", body: `{"price":"0.000000123456789123456789","time":"2024-06-10T16:00:00Z"}`, exact: "0.000000123456789123456789"},
+		{kind: "kraken", body: `{"error":[],"result":{"XETHZUSD":{"c":["3412.580000001","0.1"]}}}`, exact: "3412.580000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			server := serveBody(t, http.StatusOK, tt.body)
+
+			quote, err := sourceKinds[tt.kind](server.URL).FetchPrice(context.Background())
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			if exact := quote.Exact.String(); exact != tt.exact {
+				t.Errorf("Exact = %s, want %s", exact, tt.exact)
+			}
+		})
+	}
+}
+
+func TestSourcesRejectMalformedPayloads(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  string
+		body  string
+		class ErrorClass
+	}{
+		{name: "coingecko invalid JSON", kind: "coingecko", body: `{"ethereum":`, class: ErrorClassDecode},
+		{name: "coingecko empty", kind: "coingecko", body: `{}`, class: ErrorClassDecode},
+		{name: "coingecko zero price", kind: "coingecko", body: `{"ethereum":{"usd":0}}`, class: ErrorClassInvalidPrice},
+		{name: "binance hex price", kind: "binance", body: `{"symbol":"ETHUSDT","price":"0x10"}`, class: ErrorClassDecode},
+		{name: "binance no trades", kind: "binance", body: `[]`, class: ErrorClassDecode},
+		{name: "binance non-numeric price", kind: "binance", body: `{"symbol":"ETHUSDT","price":"n/a"}`, class: ErrorClassDecode},
+		{name: "binance negative price", kind: "binance", body: `{"symbol":"ETHUSDT","price":"-1"}`, class: ErrorClassInvalidPrice},
+		{name: "coinbase non-numeric price", kind: "coinbase", body: `{"price":"","time":"2024-06-10T16:00:00Z"}`, class: ErrorClassDecode},
+		{name: "coinbase invalid time", kind: "coinbase", body: `{"price":"3412.66","time":"yesterday"}`, class: ErrorClassDecode},
+		{name: "kraken no result", kind: "kraken", body: `{"error":[],"result":{}}`, class: ErrorClassDecode},
+		{name: "kraken no last trade", kind: "kraken", body: `{"error":[],"result":{"XETHZUSD":{"c":[]}}}`, class: ErrorClassDecode},
+		{name: "kraken error in body", kind: "kraken", body: `{"error":["EQuery:Unknown asset pair"]}`, class: ErrorClassHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveBody(t, http.StatusOK, tt.body)
+			source := sourceKinds[tt.kind](server.URL)
+
+			quote, err := source.FetchPrice(context.Background())
+			if err == nil {
+				t.Fatalf("FetchPrice() = %+v, want an error", quote)
+			}
+			if class, _ := ClassifyError(err); class != tt.class {
+				t.Errorf("error class = %q, want %q (error: %v)", class, tt.class, err)
+			}
+		})
+	}
+}
+
+func TestSourcesClassifyErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		class  ErrorClass
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, class: ErrorClassRateLimited},
+		{name: "banned", status: http.StatusTeapot, class: ErrorClassRateLimited},
+		{name: "server error", status: http.StatusInternalServerError, class: ErrorClassHTTPStatus},
+		{name: "not found", status: http.StatusNotFound, class: ErrorClassHTTPStatus},
+	}
+
+	for kind, build := range sourceKinds {
+		for _, tt := range tests {
+			t.Run(kind+" "+tt.name, func(t *testing.T) {
+				server := serveBody(t, tt.status, `{}`)
+
+				_, err := build(server.URL).FetchPrice(context.Background())
+				class, status := ClassifyError(err)
+				if class != tt.class {
+					t.Errorf("error class = %q, want %q (error: %v)", class, tt.class, err)
+				}
+				if status != tt.status {
+					t.Errorf("status = %d, want %d", status, tt.status)
+				}
+			})
+		}
+	}
+}
+
+func TestKrakenRateLimitInBody(t *testing.T) {
+	server := serveFixture(t, http.StatusOK, "kraken_rate_limited.json")
+	transport := NewRateLimitedTransport(http.DefaultTransport, 60)
+	source := NewKrakenSource("kraken", server.URL, &http.Client{Transport: transport})
+
+	_, err := source.FetchPrice(context.Background())
+	if class, _ := ClassifyError(err); class != ErrorClassRateLimited {
+		t.Fatalf("error class = %q, want %q (error: %v)", class, ErrorClassRateLimited, err)
+	}
+
+	// The source backs off, so the next request does not reach Kraken
+	_, err = source.FetchPrice(context.Background())
+	if class, _ := ClassifyError(err); class != ErrorClassBackoff {
+		t.Errorf("error class after rate limit = %q, want %q (error: %v)", class, ErrorClassBackoff, err)
+	}
+}
+
+func TestSourcesHonourContextCancellation(t *testing.T) {
+	server := serveFixture(t, http.StatusOK, "coinbase_ticker.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewCoinbaseSource("coinbase", server.URL, http.DefaultClient).FetchPrice(ctx)
+	if class, _ := ClassifyError(err); class != ErrorClassCanceled {
+		t.Errorf("error class = %q, want %q (error: %v)", class, ErrorClassCanceled, err)
+	}
+}
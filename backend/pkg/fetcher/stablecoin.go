@@ -0,0 +1,170 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+)
+
+const (
+	// ErrorClassDepegged is reported when a quote is refused because its stablecoin lost its peg
+	ErrorClassDepegged ErrorClass = "depegged"
+	// ErrorClassNoRate is reported when a quote cannot be converted for lack of a fresh conversion rate
+	ErrorClassNoRate ErrorClass = "no_conversion_rate"
+)
+
+// DepegAction selects what happens to quotes converted from a stablecoin that lost its peg
+type DepegAction string
+
+const (
+	// DepegRefuse rejects the quote, so it counts as a failed fetch
+	DepegRefuse DepegAction = "refuse"
+	// DepegFlag converts the quote at the tracked rate and marks it as depegged
+	DepegFlag DepegAction = "flag"
+)
+
+// ConversionConfig controls how stablecoin-quoted prices are converted
+type ConversionConfig struct {
+	// DepegThreshold is the largest relative distance of a stablecoin from its peg before it counts as depegged
+	DepegThreshold float64
+	Action         DepegAction
+	// MaxRateAge is how long before a quote the conversion rate may have been observed
+	MaxRateAge time.Duration
+}
+
+// DefaultConversionConfig refuses conversions from stablecoins more than 1% off their peg
+func DefaultConversionConfig() ConversionConfig {
+	return ConversionConfig{
+		DepegThreshold: 0.01,
+		Action:         DepegRefuse,
+		MaxRateAge:     5 * time.Minute,
+	}
+}
+
+// Validate checks if the conversion configuration is valid
+func (c ConversionConfig) Validate() error {
+	if c.DepegThreshold <= 0 {
+		return fmt.Errorf("depeg threshold must be positive, got: %f", c.DepegThreshold)
+	}
+	switch c.Action {
+	case DepegRefuse, DepegFlag:
+	default:
+		return fmt.Errorf("unknown depeg action %q", c.Action)
+	}
+	if c.MaxRateAge <= 0 {
+		return fmt.Errorf("max rate age must be positive, got: %v", c.MaxRateAge)
+	}
+	return nil
+}
+
+// ConversionRate is the latest tracked price of a stablecoin in the currency it is pegged to
+type ConversionRate struct {
+	Rate       float64
+	ObservedAt time.Time
+}
+
+// RateBook tracks the stablecoin rates used to convert quotes, e.g. USDT/USD
+// Rates are set by the pipelines serving the stablecoin pairs, so they lag the quotes by up to one tick
+type RateBook struct {
+	mu    sync.RWMutex
+	rates map[pair.Pair]ConversionRate
+}
+
+// NewRateBook creates an empty rate book
+func NewRateBook() *RateBook {
+	return &RateBook{
+		rates: make(map[pair.Pair]ConversionRate),
+	}
+}
+
+// Set records the latest rate of p
+func (b *RateBook) Set(p pair.Pair, rate float64, observedAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rates[p] = ConversionRate{Rate: rate, ObservedAt: observedAt}
+}
+
+// Rate returns the latest rate converting from into to, reporting false if none was recorded
+func (b *RateBook) Rate(from, to string) (ConversionRate, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	rate, ok := b.rates[pair.New(from, to)]
+	return rate, ok
+}
+
+// ConvertedSource converts the quotes of a source quoting in a stablecoin into the currency it is pegged to
+type ConvertedSource struct {
+	source PriceSource
+	from   string
+	to     string
+	rates  *RateBook
+	config ConversionConfig
+}
+
+// NewConvertedSource wraps source so its quotes in the stablecoin from are converted to to at the rate in rates
+func NewConvertedSource(source PriceSource, from, to string, rates *RateBook, config ConversionConfig) (*ConvertedSource, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid conversion config: %w", err)
+	}
+
+	return &ConvertedSource{
+		source: source,
+		from:   from,
+		to:     to,
+		rates:  rates,
+		config: config,
+	}, nil
+}
+
+// Name returns the name of the wrapped source
+func (s *ConvertedSource) Name() string {
+	return s.source.Name()
+}
+
+// Unwrap returns the wrapped source
+func (s *ConvertedSource) Unwrap() PriceSource {
+	return s.source
+}
+
+// FetchPrice fetches from the wrapped source and converts the quote at the latest rate
+// It refuses to convert without a fresh rate, and refuses or flags the quote when the stablecoin is off its peg
+func (s *ConvertedSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	quote, err := s.source.FetchPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, ok := s.rates.Rate(s.from, s.to)
+	if !ok {
+		return nil, newSourceError(ErrorClassNoRate, quote.StatusCode,
+			fmt.Errorf("no %s/%s rate to convert the quote from %s", s.from, s.to, s.source.Name()))
+	}
+	if age := quote.Timestamp.Sub(rate.ObservedAt); age > s.config.MaxRateAge {
+		return nil, newSourceError(ErrorClassNoRate, quote.StatusCode,
+			fmt.Errorf("%s/%s rate is %v old, above the %v limit", s.from, s.to, age.Round(time.Second), s.config.MaxRateAge))
+	}
+
+	converted := *quote
+	if deviation := math.Abs(rate.Rate - 1); deviation > s.config.DepegThreshold {
+		if s.config.Action == DepegRefuse {
+			return nil, newSourceError(ErrorClassDepegged, quote.StatusCode,
+				fmt.Errorf("%s is %.2f%% off its %s peg at %.6f, refusing the quote from %s", s.from, deviation*100, s.to, rate.Rate, s.source.Name()))
+		}
+		converted.Depegged = true
+	}
+
+	converted.Price *= rate.Rate
+	if exactRate, err := fixedpoint.FromFloatExact(rate.Rate); err == nil {
+		converted.Exact = quote.ExactPrice().Mul(exactRate)
+	}
+	converted.QuoteAsset = s.from
+	converted.ConversionRate = rate.Rate
+	return &converted, nil
+}
@@ -0,0 +1,153 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+)
+
+// usdtQuote returns a quote of ETH/USDT at 3412.57 fetched at fetchedAt
+func usdtQuote(t *testing.T, fetchedAt time.Time) Quote {
+	t.Helper()
+
+	return Quote{Source: "binance", Price: 3412.57, Exact: exactPrice(t, "3412.57"), Timestamp: fetchedAt, StatusCode: 200}
+}
+
+// newUSDTSource returns a source converting quote from USDT to USD at the rates in rates
+func newUSDTSource(t *testing.T, quote Quote, rates *RateBook, config ConversionConfig) *ConvertedSource {
+	t.Helper()
+
+	source, err := NewConvertedSource(&scriptedSource{name: "binance", quotes: []Quote{quote}}, "USDT", "USD", rates, config)
+	if err != nil {
+		t.Fatalf("NewConvertedSource() error = %v", err)
+	}
+	return source
+}
+
+func TestConvertedSourceFetchPrice(t *testing.T) {
+	now := time.Now()
+	flag := DefaultConversionConfig()
+	flag.Action = DepegFlag
+
+	tests := []struct {
+		name   string
+		config ConversionConfig
+		// rate is the USDT/USD rate and rateAge how long before the quote it was observed; a zero rate sets none
+		rate    float64
+		rateAge time.Duration
+		class   ErrorClass
+		// exact is the exactly converted price and depegged whether the quote is flagged
+		exact    string
+		depegged bool
+	}{
+		{name: "on peg", config: DefaultConversionConfig(), rate: 0.9995, rateAge: time.Minute, exact: "3410.863715"},
+		{name: "within the threshold", config: DefaultConversionConfig(), rate: 1.009, exact: "3443.28313"},
+		{name: "missing rate", config: DefaultConversionConfig(), class: ErrorClassNoRate},
+		{name: "stale rate", config: DefaultConversionConfig(), rate: 0.9995, rateAge: 6 * time.Minute, class: ErrorClassNoRate},
+		{name: "depegged and refused", config: DefaultConversionConfig(), rate: 0.95, class: ErrorClassDepegged},
+		{name: "depegged and flagged", config: flag, rate: 0.95, exact: "3241.9415", depegged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates := NewRateBook()
+			if tt.rate != 0 {
+				rates.Set(pair.New("USDT", "USD"), tt.rate, now.Add(-tt.rateAge))
+			}
+			quote, err := newUSDTSource(t, usdtQuote(t, now), rates, tt.config).FetchPrice(context.Background())
+
+			if tt.class != ErrorClassNone {
+				var sourceErr *SourceError
+				if !errors.As(err, &sourceErr) || sourceErr.Class != tt.class {
+					t.Fatalf("FetchPrice() error = %v, want %s", err, tt.class)
+				}
+				if sourceErr.StatusCode != 200 {
+					t.Errorf("error status code = %d, want the response's 200", sourceErr.StatusCode)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			// The exact price is the product of the exact quote and rate, not of their floats
+			if got := quote.Exact.String(); got != tt.exact {
+				t.Errorf("Exact = %s, want %s", got, tt.exact)
+			}
+			if want := 3412.57 * tt.rate; quote.Price != want {
+				t.Errorf("Price = %v, want %v", quote.Price, want)
+			}
+			if quote.Depegged != tt.depegged || quote.QuoteAsset != "USDT" || quote.ConversionRate != tt.rate {
+				t.Errorf("quote = %+v, want converted from USDT at %v with depegged %v", quote, tt.rate, tt.depegged)
+			}
+		})
+	}
+}
+
+func TestConvertedSourceConvertsFloatOnlyQuotesExactly(t *testing.T) {
+	now := time.Now()
+	rates := NewRateBook()
+	rates.Set(pair.New("USDT", "USD"), 1.0001, now)
+
+	// Without an exact price the quote's shortest decimal is converted
+	quote := Quote{Source: "binance", Price: 0.1, Timestamp: now}
+	converted, err := newUSDTSource(t, quote, rates, DefaultConversionConfig()).FetchPrice(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	if got := converted.Exact.String(); got != "0.10001" {
+		t.Errorf("Exact = %s, want 0.10001", got)
+	}
+}
+
+func TestGuardedSourceIgnoresConversionRefusals(t *testing.T) {
+	config := DefaultBreakerConfig()
+	config.FailureThreshold = 1
+
+	for _, class := range []ErrorClass{ErrorClassNoRate, ErrorClassDepegged} {
+		rates := NewRateBook()
+		if class == ErrorClassDepegged {
+			rates.Set(pair.New("USDT", "USD"), 0.9, time.Now())
+		}
+		quotes := []Quote{usdtQuote(t, time.Now()), usdtQuote(t, time.Now())}
+		converted, err := NewConvertedSource(&scriptedSource{name: "binance", quotes: quotes}, "USDT", "USD", rates, DefaultConversionConfig())
+		if err != nil {
+			t.Fatalf("NewConvertedSource() error = %v", err)
+		}
+		guarded := NewGuardedSource(converted, config)
+
+		for range 2 {
+			if _, err := guarded.FetchPrice(context.Background()); err == nil {
+				t.Fatalf("FetchPrice() without a usable %s rate succeeded", class)
+			}
+		}
+		// The venue answered, so the circuit stays closed
+		if status := guarded.Status(); status.State != CircuitClosed || status.ConsecutiveFailures != 0 {
+			t.Errorf("after %s refusals circuit = %s with %d failures, want closed with none", class, status.State, status.ConsecutiveFailures)
+		}
+	}
+}
+
+func TestConversionConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ConversionConfig)
+	}{
+		{name: "zero threshold", modify: func(c *ConversionConfig) { c.DepegThreshold = 0 }},
+		{name: "unknown action", modify: func(c *ConversionConfig) { c.Action = "ignore" }},
+		{name: "zero max rate age", modify: func(c *ConversionConfig) { c.MaxRateAge = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConversionConfig()
+			tt.modify(&config)
+			if err := config.Validate(); err == nil {
+				t.Error("Validate() succeeded, want an error")
+			}
+		})
+	}
+}
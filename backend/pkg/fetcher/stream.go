@@ -0,0 +1,317 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrorClassStale is reported when a source's latest data is too old to use
+const ErrorClassStale ErrorClass = "stale"
+
+// StreamConfig controls how streaming sources hold their connections
+type StreamConfig struct {
+	// MaxAge is how old the latest update may be before the source reports stale data;
+	// a connection that stays silent for this long is also dropped and redialled
+	MaxAge time.Duration
+	// MinBackoff is the delay before the first reconnect attempt
+	MinBackoff time.Duration
+	// MaxBackoff caps the exponentially growing delay between reconnect attempts
+	MaxBackoff time.Duration
+	// HandshakeTimeout bounds the WebSocket handshake
+	HandshakeTimeout time.Duration
+}
+
+// DefaultStreamConfig returns the default streaming settings
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		MaxAge:           30 * time.Second,
+		MinBackoff:       time.Second,
+		MaxBackoff:       time.Minute,
+		HandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// Validate checks that the stream config is usable
+func (c StreamConfig) Validate() error {
+	if c.MaxAge <= 0 {
+		return fmt.Errorf("max age must be positive, got: %v", c.MaxAge)
+	}
+	if c.MinBackoff <= 0 {
+		return fmt.Errorf("min backoff must be positive, got: %v", c.MinBackoff)
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("max backoff %v must not be below min backoff %v", c.MaxBackoff, c.MinBackoff)
+	}
+	return nil
+}
+
+// Streamer is implemented by sources that hold a live connection which must be started
+type Streamer interface {
+	// Start connects the source in the background until ctx is done
+	Start(ctx context.Context)
+}
+
+// StreamStatus is a snapshot of a streaming source's connection and latest data
+type StreamStatus struct {
+	Connected  bool      `json:"connected"`
+	Reconnects int       `json:"reconnects"`
+	LastTrade  float64   `json:"last_trade"`
+	Bid        float64   `json:"bid"`
+	Ask        float64   `json:"ask"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// ObservedAt is the venue's time of the latest data, or its arrival time when the venue sends none
+	ObservedAt time.Time `json:"observed_at"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// Mid returns the mid-price of the best bid and ask, or zero if either side is missing
+func (s StreamStatus) Mid() float64 {
+	if s.Bid <= 0 || s.Ask <= 0 {
+		return 0
+	}
+	return (s.Bid + s.Ask) / 2
+}
+
+// streamUpdate is the data carried by one venue message; zero fields leave the snapshot unchanged
+type streamUpdate struct {
+	lastTrade float64
+	bid       float64
+	ask       float64
+	// observedAt is the venue's event time, if the message carries one
+	observedAt time.Time
+}
+
+// streamParser decodes one venue message, reporting whether it carried price data
+type streamParser func(message []byte) (streamUpdate, bool, error)
+
+// StreamSource keeps the latest trade and top of book of a venue WebSocket feed
+// FetchPrice samples the live snapshot without any network round trip
+type StreamSource struct {
+	name      string
+	url       string
+	config    StreamConfig
+	subscribe []interface{}
+	parse     streamParser
+	// fallback is polled instead while the stream has no fresh data, if set
+	fallback PriceSource
+
+	startOnce sync.Once
+
+	mu     sync.RWMutex
+	status StreamStatus
+	// raw is the last message that carried price data
+	raw []byte
+}
+
+// newStreamSource creates a streaming source that sends the subscribe messages after connecting
+func newStreamSource(name, url string, config StreamConfig, subscribe []interface{}, parse streamParser) *StreamSource {
+	return &StreamSource{
+		name:      name,
+		url:       url,
+		config:    config,
+		subscribe: subscribe,
+		parse:     parse,
+	}
+}
+
+// Name returns the source name
+func (s *StreamSource) Name() string {
+	return s.name
+}
+
+// SetFallback makes FetchPrice poll fallback, typically the venue's REST ticker, while the stream has no fresh data
+// Its quotes keep the name of the stream, so they are weighted and scored as the same venue
+func (s *StreamSource) SetFallback(fallback PriceSource) {
+	s.fallback = fallback
+}
+
+// Start connects to the feed in the background and keeps reconnecting until ctx is done
+func (s *StreamSource) Start(ctx context.Context) {
+	s.startOnce.Do(func() {
+		go s.run(ctx)
+	})
+}
+
+// FetchPrice returns the latest mid-price, falling back to the last trade when the book is one-sided
+// While the stream has no fresh data the quote comes from the fallback source, if one is set
+func (s *StreamSource) FetchPrice(ctx context.Context) (*Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	status, raw := s.status, s.raw
+	s.mu.RUnlock()
+
+	var stale error
+	if status.UpdatedAt.IsZero() {
+		stale = fmt.Errorf("no data received from %s yet", s.name)
+	} else if age := time.Since(status.UpdatedAt); age > s.config.MaxAge {
+		stale = fmt.Errorf("latest data from %s is %v old", s.name, age.Round(time.Second))
+	}
+	if stale != nil {
+		if s.fallback == nil {
+			return nil, newSourceError(ErrorClassStale, 0, stale)
+		}
+		quote, err := s.fallback.FetchPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%v, and fallback %s failed: %w", stale, s.fallback.Name(), err)
+		}
+		quote.Source = s.name
+		return quote, nil
+	}
+
+	price := status.Mid()
+	if price == 0 {
+		price = status.LastTrade
+	}
+	if price <= 0 {
+		return nil, newSourceError(ErrorClassInvalidPrice, 0, fmt.Errorf("invalid price received from %s: %f", s.name, price))
+	}
+
+	return &Quote{
+		Source:     s.name,
+		Price:      price,
+		Timestamp:  time.Now(),
+		ObservedAt: status.ObservedAt,
+		Raw:        raw,
+	}, nil
+}
+
+// Status returns a snapshot of the connection and the latest data
+func (s *StreamSource) Status() StreamStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.status
+}
+
+// run holds the connection open, redialling with exponential backoff whenever it drops
+func (s *StreamSource) run(ctx context.Context) {
+	backoff := s.config.MinBackoff
+
+	for {
+		received, err := s.session(ctx)
+
+		s.mu.Lock()
+		s.status.Connected = false
+		if err != nil {
+			s.status.LastError = err.Error()
+		}
+		s.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A session that delivered data was healthy, so start the backoff over
+		if received {
+			backoff = s.config.MinBackoff
+		}
+
+		log.Printf("Stream %s disconnected: %v; reconnecting in %v", s.name, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+
+		s.mu.Lock()
+		s.status.Reconnects++
+		s.mu.Unlock()
+	}
+}
+
+// session dials the feed, subscribes and applies messages until the connection fails
+// It reports whether any price data was received
+func (s *StreamSource) session(ctx context.Context) (bool, error) {
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: s.config.HandshakeTimeout,
+	}
+
+	conn, _, err := dialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Closing the connection is the only way to unblock a pending read
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for _, message := range s.subscribe {
+		if err := conn.WriteJSON(message); err != nil {
+			return false, fmt.Errorf("failed to subscribe: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.status.Connected = true
+	s.mu.Unlock()
+
+	received := false
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(s.config.MaxAge)); err != nil {
+			return received, fmt.Errorf("failed to set read deadline: %w", err)
+		}
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return received, fmt.Errorf("failed to read message: %w", err)
+		}
+
+		update, ok, err := s.parse(message)
+		if err != nil {
+			return received, fmt.Errorf("failed to decode message: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		s.apply(update, message)
+		received = true
+	}
+}
+
+// apply merges an update decoded from message into the snapshot
+func (s *StreamSource) apply(update streamUpdate, message []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update.lastTrade > 0 {
+		s.status.LastTrade = update.lastTrade
+	}
+	if update.bid > 0 {
+		s.status.Bid = update.bid
+	}
+	if update.ask > 0 {
+		s.status.Ask = update.ask
+	}
+	s.status.UpdatedAt = time.Now()
+	s.status.ObservedAt = update.observedAt
+	if update.observedAt.IsZero() {
+		s.status.ObservedAt = s.status.UpdatedAt
+	}
+	s.status.LastError = ""
+	s.raw = message
+}
@@ -0,0 +1,204 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default WebSocket endpoints for the built-in streaming adapters; placeholders are expanded by ExpandURL
+const (
+	DefaultBinanceStreamURL  = "wss://stream.binance.com:9443/stream?streams={symbol_lower}@trade/{symbol_lower}@bookTicker"
+	DefaultCoinbaseStreamURL = "wss://ws-feed.exchange.coinbase.com"
+	DefaultKrakenStreamURL   = "wss://ws.kraken.com/v2"
+)
+
+// BinanceStreamMessage is the envelope of a Binance combined stream message
+type BinanceStreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// BinanceTradeEvent holds the fields of a Binance trade event that we use
+type BinanceTradeEvent struct {
+	Price string `json:"p"`
+	// TradeTime is the trade time in milliseconds since the epoch
+	TradeTime int64 `json:"T"`
+}
+
+// BinanceBookTickerEvent represents a Binance best bid/ask update
+// The quantity fields must be declared so their upper-case keys are not folded into the prices
+type BinanceBookTickerEvent struct {
+	Bid    string `json:"b"`
+	BidQty string `json:"B"`
+	Ask    string `json:"a"`
+	AskQty string `json:"A"`
+}
+
+// NewBinanceStreamSource creates a Binance adapter for a combined trade and book ticker stream
+// The subscription is part of the URL, so nothing is sent after connecting
+func NewBinanceStreamSource(name, url string, config StreamConfig) *StreamSource {
+	return newStreamSource(name, url, config, nil, parseBinanceStreamMessage)
+}
+
+// parseBinanceStreamMessage decodes a Binance combined stream message
+func parseBinanceStreamMessage(message []byte) (streamUpdate, bool, error) {
+	var envelope BinanceStreamMessage
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return streamUpdate{}, false, err
+	}
+
+	switch {
+	case strings.HasSuffix(envelope.Stream, "@trade"):
+		var event BinanceTradeEvent
+		if err := json.Unmarshal(envelope.Data, &event); err != nil {
+			return streamUpdate{}, false, err
+		}
+		price, err := parseStreamPrice(event.Price)
+		if err != nil {
+			return streamUpdate{}, false, err
+		}
+		update := streamUpdate{lastTrade: price}
+		if event.TradeTime > 0 {
+			update.observedAt = time.UnixMilli(event.TradeTime)
+		}
+		return update, true, nil
+	case strings.HasSuffix(envelope.Stream, "@bookTicker"):
+		var event BinanceBookTickerEvent
+		if err := json.Unmarshal(envelope.Data, &event); err != nil {
+			return streamUpdate{}, false, err
+		}
+		bid, err := parseStreamPrice(event.Bid)
+		if err != nil {
+			return streamUpdate{}, false, err
+		}
+		ask, err := parseStreamPrice(event.Ask)
+		if err != nil {
+			return streamUpdate{}, false, err
+		}
+		return streamUpdate{bid: bid, ask: ask}, true, nil
+	}
+
+	return streamUpdate{}, false, nil
+}
+
+// CoinbaseStreamMessage holds the fields of a Coinbase Exchange feed message that we use
+type CoinbaseStreamMessage struct {
+	Type    string `json:"type"`
+	Price   string `json:"price"`
+	BestBid string `json:"best_bid"`
+	BestAsk string `json:"best_ask"`
+	Time    string `json:"time"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+// NewCoinbaseStreamSource creates a Coinbase Exchange adapter for the ticker channel of a product, e.g. ETH-USD
+// The heartbeat channel keeps the connection busy between trades
+func NewCoinbaseStreamSource(name, url, product string, config StreamConfig) *StreamSource {
+	subscribe := map[string]interface{}{
+		"type":        "subscribe",
+		"product_ids": []string{product},
+		"channels":    []string{"ticker", "heartbeat"},
+	}
+	return newStreamSource(name, url, config, []interface{}{subscribe}, parseCoinbaseStreamMessage)
+}
+
+// parseCoinbaseStreamMessage decodes a Coinbase Exchange feed message
+func parseCoinbaseStreamMessage(message []byte) (streamUpdate, bool, error) {
+	var msg CoinbaseStreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return streamUpdate{}, false, err
+	}
+
+	switch msg.Type {
+	case "error":
+		return streamUpdate{}, false, fmt.Errorf("Coinbase returned error: %s %s", msg.Message, msg.Reason)
+	case "ticker":
+		var update streamUpdate
+		var err error
+		if update.lastTrade, err = parseStreamPrice(msg.Price); err != nil {
+			return streamUpdate{}, false, err
+		}
+		if update.bid, err = parseStreamPrice(msg.BestBid); err != nil {
+			return streamUpdate{}, false, err
+		}
+		if update.ask, err = parseStreamPrice(msg.BestAsk); err != nil {
+			return streamUpdate{}, false, err
+		}
+		if msg.Time != "" {
+			if update.observedAt, err = time.Parse(time.RFC3339Nano, msg.Time); err != nil {
+				return streamUpdate{}, false, fmt.Errorf("failed to parse time %q: %w", msg.Time, err)
+			}
+		}
+		return update, true, nil
+	}
+
+	return streamUpdate{}, false, nil
+}
+
+// KrakenStreamTicker holds the fields of a Kraken v2 ticker entry that we use
+type KrakenStreamTicker struct {
+	Symbol string  `json:"symbol"`
+	Bid    float64 `json:"bid"`
+	Ask    float64 `json:"ask"`
+	Last   float64 `json:"last"`
+}
+
+// KrakenStreamMessage holds the fields of a Kraken v2 message that we use
+type KrakenStreamMessage struct {
+	Channel string               `json:"channel"`
+	Method  string               `json:"method"`
+	Success *bool                `json:"success"`
+	Error   string               `json:"error"`
+	Data    []KrakenStreamTicker `json:"data"`
+}
+
+// NewKrakenStreamSource creates a Kraken v2 adapter for the ticker channel of a symbol, e.g. ETH/USD
+func NewKrakenStreamSource(name, url, symbol string, config StreamConfig) *StreamSource {
+	subscribe := map[string]interface{}{
+		"method": "subscribe",
+		"params": map[string]interface{}{
+			"channel": "ticker",
+			"symbol":  []string{symbol},
+		},
+	}
+	return newStreamSource(name, url, config, []interface{}{subscribe}, parseKrakenStreamMessage)
+}
+
+// parseKrakenStreamMessage decodes a Kraken v2 message
+func parseKrakenStreamMessage(message []byte) (streamUpdate, bool, error) {
+	var msg KrakenStreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return streamUpdate{}, false, err
+	}
+
+	if msg.Success != nil && !*msg.Success {
+		return streamUpdate{}, false, fmt.Errorf("Kraken rejected %s: %s", msg.Method, msg.Error)
+	}
+	if msg.Channel != "ticker" {
+		return streamUpdate{}, false, nil
+	}
+
+	if len(msg.Data) == 0 {
+		return streamUpdate{}, false, nil
+	}
+
+	ticker := msg.Data[0]
+	return streamUpdate{lastTrade: ticker.Last, bid: ticker.Bid, ask: ticker.Ask}, true, nil
+}
+
+// parseStreamPrice parses a decimal price string; an empty string means the field was absent
+func parseStreamPrice(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse price %q: %w", value, err)
+	}
+	return price, nil
+}
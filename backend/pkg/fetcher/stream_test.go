@@ -0,0 +1,369 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsStandIn is a local WebSocket server standing in for a venue feed
+// Connections receive the scripted messages, then are closed or held open
+type wsStandIn struct {
+	server   *httptest.Server
+	messages []string
+	// hold keeps connections open after the messages instead of closing them
+	hold bool
+	// once sends the messages on the first connection only
+	once bool
+
+	mu          sync.Mutex
+	connections []time.Time
+	subscribes  []string
+}
+
+// newWSStandIn starts a stand-in feed sending messages on every connection
+func newWSStandIn(t *testing.T, messages []string, hold bool) *wsStandIn {
+	return startWSStandIn(t, &wsStandIn{messages: messages, hold: hold})
+}
+
+// startWSStandIn starts the stand-in feed described by standIn
+func startWSStandIn(t *testing.T, standIn *wsStandIn) *wsStandIn {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	done := make(chan struct{})
+
+	standIn.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		standIn.mu.Lock()
+		standIn.connections = append(standIn.connections, time.Now())
+		messages := standIn.messages
+		if standIn.once && len(standIn.connections) > 1 {
+			messages = nil
+		}
+		standIn.mu.Unlock()
+
+		// Record subscribe messages without blocking the feed
+		go func() {
+			for {
+				_, message, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				standIn.mu.Lock()
+				standIn.subscribes = append(standIn.subscribes, string(message))
+				standIn.mu.Unlock()
+			}
+		}()
+
+		for _, message := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+				return
+			}
+		}
+		if standIn.hold {
+			<-done
+		}
+	}))
+	t.Cleanup(func() {
+		close(done)
+		standIn.server.Close()
+	})
+	return standIn
+}
+
+// url returns the WebSocket URL of the stand-in
+func (s *wsStandIn) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+// connectionTimes returns when each connection was accepted
+func (s *wsStandIn) connectionTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]time.Time(nil), s.connections...)
+}
+
+// subscribeMessages returns the messages clients sent
+func (s *wsStandIn) subscribeMessages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.subscribes...)
+}
+
+// testStreamConfig returns stream settings short enough for tests
+func testStreamConfig() StreamConfig {
+	return StreamConfig{
+		MaxAge:           time.Second,
+		MinBackoff:       20 * time.Millisecond,
+		MaxBackoff:       80 * time.Millisecond,
+		HandshakeTimeout: time.Second,
+	}
+}
+
+// waitFor polls condition until it holds or the deadline passes
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return condition()
+}
+
+func TestStreamSourcesDecodeVenueMessages(t *testing.T) {
+	tests := []struct {
+		name       string
+		messages   []string
+		build      func(url string) *StreamSource
+		subscribe  string
+		status     StreamStatus
+		price      float64
+		observedAt time.Time
+	}{
+		{
+			name: "binance",
+			messages: []string{
+				`{"stream":"ethusdt@bookTicker","data":{"u":400900217,"s":"ETHUSDT","b":"3412.00000000","B":"31.21000000","a":"3412.02000000","A":"40.66000000"}}`,
+				`{"stream":"ethusdt@trade","data":{"e":"trade","E":1718035200001,"s":"ETHUSDT","t":1450632219,"p":"3412.01000000","q":"0.01480000","T":1718035200000,"m":false}}`,
+			},
+			build: func(url string) *StreamSource {
+				return NewBinanceStreamSource("binance_ws", url, testStreamConfig())
+			},
+			status:     StreamStatus{LastTrade: 3412.01, Bid: 3412, Ask: 3412.02},
+			price:      3412.01,
+			observedAt: time.UnixMilli(1718035200000),
+		},
+		{
+			name: "coinbase",
+			messages: []string{
+				`{"type":"subscriptions","channels":[{"name":"ticker","product_ids":["ETH-USD"]}]}`,
+				`{"type":"heartbeat","sequence":90,"last_trade_id":20,"product_id":"ETH-USD","time":"2024-06-10T16:00:00.000000Z"}`,
+				`{"type":"ticker","sequence":37475248783,"product_id":"ETH-USD","price":"3412.66","best_bid":"3412.65","best_ask":"3412.67","side":"buy","time":"2024-06-10T16:00:00.123456Z","trade_id":519825763,"last_size":"0.0262"}`,
+			},
+			build: func(url string) *StreamSource {
+				return NewCoinbaseStreamSource("coinbase_ws", url, "ETH-USD", testStreamConfig())
+			},
+			subscribe:  `"product_ids":["ETH-USD"]`,
+			status:     StreamStatus{LastTrade: 3412.66, Bid: 3412.65, Ask: 3412.67},
+			price:      3412.66,
+			observedAt: time.Date(2024, 6, 10, 16, 0, 0, 123456000, time.UTC),
+		},
+		{
+			name: "kraken",
+			messages: []string{
+				`{"method":"subscribe","result":{"channel":"ticker","symbol":"ETH/USD"},"success":true,"time_in":"2024-06-10T16:00:00.000000Z","time_out":"2024-06-10T16:00:00.000100Z"}`,
+				`{"channel":"heartbeat"}`,
+				`{"channel":"ticker","type":"snapshot","data":[{"symbol":"ETH/USD","bid":3412.57,"bid_qty":1.5,"ask":3412.59,"ask_qty":2.1,"last":3412.58,"volume":2185.55,"vwap":3398.45,"low":3371.19,"high":3425,"change":21.08,"change_pct":0.62}]}`,
+			},
+			build: func(url string) *StreamSource {
+				return NewKrakenStreamSource("kraken_ws", url, "ETH/USD", testStreamConfig())
+			},
+			subscribe: `"symbol":["ETH/USD"]`,
+			status:    StreamStatus{LastTrade: 3412.58, Bid: 3412.57, Ask: 3412.59},
+			price:     3412.58,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn := newWSStandIn(t, tt.messages, true)
+			source := tt.build(standIn.url())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			source.Start(ctx)
+
+			if !waitFor(t, 2*time.Second, func() bool {
+				status := source.Status()
+				return status.Bid == tt.status.Bid && status.Ask == tt.status.Ask && status.LastTrade == tt.status.LastTrade
+			}) {
+				t.Fatalf("Status() = %+v, want last trade %v, bid %v and ask %v",
+					source.Status(), tt.status.LastTrade, tt.status.Bid, tt.status.Ask)
+			}
+			if !source.Status().Connected {
+				t.Error("Status().Connected = false, want true")
+			}
+
+			quote, err := source.FetchPrice(ctx)
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			// The quote is the mid-price of the book
+			if diff := quote.Price - tt.price; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Price = %v, want %v", quote.Price, tt.price)
+			}
+			if !tt.observedAt.IsZero() && !quote.ObservedAt.Equal(tt.observedAt) {
+				t.Errorf("ObservedAt = %v, want %v", quote.ObservedAt, tt.observedAt)
+			}
+
+			if tt.subscribe != "" && !waitFor(t, time.Second, func() bool {
+				subscribes := standIn.subscribeMessages()
+				return len(subscribes) == 1 && strings.Contains(subscribes[0], tt.subscribe)
+			}) {
+				t.Errorf("subscribe messages = %v, want one containing %s", standIn.subscribeMessages(), tt.subscribe)
+			}
+		})
+	}
+}
+
+func TestStreamSourceDropsConnectionOnVenueError(t *testing.T) {
+	standIn := newWSStandIn(t, []string{
+		`{"type":"error","message":"Failed to subscribe","reason":"ETH-XYZ is not a valid product"}`,
+	}, true)
+	source := NewCoinbaseStreamSource("coinbase_ws", standIn.url(), "ETH-XYZ", testStreamConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source.Start(ctx)
+
+	if !waitFor(t, 2*time.Second, func() bool { return strings.Contains(source.Status().LastError, "not a valid product") }) {
+		t.Fatalf("LastError = %q, want the venue's error", source.Status().LastError)
+	}
+	if _, err := source.FetchPrice(ctx); err == nil {
+		t.Error("FetchPrice() succeeded without any price data")
+	}
+}
+
+func TestStreamSourceReconnectsWithBackoff(t *testing.T) {
+	// The feed closes every connection without sending data, so each reconnect doubles the backoff
+	standIn := newWSStandIn(t, nil, false)
+	config := testStreamConfig()
+	source := NewKrakenStreamSource("kraken_ws", standIn.url(), "ETH/USD", config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source.Start(ctx)
+
+	if !waitFor(t, 3*time.Second, func() bool { return len(standIn.connectionTimes()) >= 5 }) {
+		t.Fatalf("got %d connections, want at least 5", len(standIn.connectionTimes()))
+	}
+	cancel()
+
+	connections := standIn.connectionTimes()
+	backoff := config.MinBackoff
+	for i := 1; i < 5; i++ {
+		if gap := connections[i].Sub(connections[i-1]); gap < backoff {
+			t.Errorf("reconnect %d came after %v, want at least %v", i, gap, backoff)
+		}
+		backoff = min(backoff*2, config.MaxBackoff)
+	}
+	if reconnects := source.Status().Reconnects; reconnects < 4 {
+		t.Errorf("Reconnects = %d, want at least 4", reconnects)
+	}
+}
+
+func TestStreamSourceResumesAfterReconnect(t *testing.T) {
+	// Every connection delivers one trade and is then dropped
+	standIn := newWSStandIn(t, []string{
+		`{"stream":"ethusdt@trade","data":{"p":"3412.01000000","T":1718035200000}}`,
+	}, false)
+	source := NewBinanceStreamSource("binance_ws", standIn.url(), testStreamConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source.Start(ctx)
+
+	if !waitFor(t, 2*time.Second, func() bool { return source.Status().Reconnects >= 2 }) {
+		t.Fatalf("Reconnects = %d, want at least 2", source.Status().Reconnects)
+	}
+	// Sessions that delivered data reset the backoff, so reconnects stay at the minimum delay
+	connections := standIn.connectionTimes()
+	if gap := connections[len(connections)-1].Sub(connections[len(connections)-2]); gap > time.Second {
+		t.Errorf("reconnect after a healthy session came after %v", gap)
+	}
+
+	quote, err := source.FetchPrice(ctx)
+	if err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	if quote.Price != 3412.01 {
+		t.Errorf("Price = %v, want 3412.01", quote.Price)
+	}
+}
+
+func TestStreamSourceFallsBackToRESTWhenStale(t *testing.T) {
+	// The feed sends one update and then stays silent, even after the silent connection is redialled
+	standIn := startWSStandIn(t, &wsStandIn{
+		messages: []string{
+			`{"type":"ticker","price":"3400.00","best_bid":"3399.99","best_ask":"3400.01","time":"2024-06-10T16:00:00Z"}`,
+		},
+		hold: true,
+		once: true,
+	})
+	rest := serveFixture(t, http.StatusOK, "coinbase_ticker.json")
+
+	config := testStreamConfig()
+	config.MaxAge = 200 * time.Millisecond
+	source := NewCoinbaseStreamSource("coinbase_ws", standIn.url(), "ETH-USD", config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without a fallback a stream that has not delivered yet is stale
+	if _, err := source.FetchPrice(ctx); err == nil {
+		t.Fatal("FetchPrice() before any data succeeded")
+	} else if class, _ := ClassifyError(err); class != ErrorClassStale {
+		t.Fatalf("error class = %q, want %q", class, ErrorClassStale)
+	}
+
+	source.SetFallback(NewCoinbaseSource("coinbase", rest.URL, http.DefaultClient))
+	source.Start(ctx)
+
+	if !waitFor(t, 2*time.Second, func() bool { return source.Status().LastTrade == 3400 }) {
+		t.Fatalf("Status() = %+v, want the streamed trade", source.Status())
+	}
+	quote, err := source.FetchPrice(ctx)
+	if err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	if quote.Price != 3400 {
+		t.Errorf("Price while fresh = %v, want the streamed 3400", quote.Price)
+	}
+
+	// Once the stream is silent for longer than its max age the REST ticker takes over
+	time.Sleep(config.MaxAge + 50*time.Millisecond)
+	quote, err = source.FetchPrice(ctx)
+	if err != nil {
+		t.Fatalf("FetchPrice() after the stream went stale error = %v", err)
+	}
+	if quote.Price != 3412.66 {
+		t.Errorf("Price while stale = %v, want the REST 3412.66", quote.Price)
+	}
+	if quote.Source != "coinbase_ws" {
+		t.Errorf("Source = %q, want the stream's name", quote.Source)
+	}
+}
+
+func TestRegistryBuildsStreamFallback(t *testing.T) {
+	registry := NewRegistry()
+
+	source, err := registry.Build(SourceSpec{Kind: "binance_ws", Params: map[string]string{"fallback": "binance"}}, time.Second)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if stream, ok := source.(*StreamSource); !ok || stream.fallback == nil || stream.fallback.Name() != "binance_ws_fallback" {
+		t.Errorf("Build() = %#v, want a stream with a binance fallback", source)
+	}
+
+	if _, err := registry.Build(SourceSpec{Kind: "binance_ws", Params: map[string]string{"fallback": "kraken_ws"}}, time.Second); err == nil {
+		t.Error("Build() with a streaming fallback succeeded")
+	}
+}
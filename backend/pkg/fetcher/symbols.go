@@ -0,0 +1,81 @@
+package fetcher
+
+import (
+	"strings"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+)
+
+// SymbolFunc returns the venue's own symbol for a pair
+type SymbolFunc func(p pair.Pair) string
+
+// coinGeckoIDs maps asset symbols to CoinGecko coin IDs; other assets fall back to the lowercase symbol
+var coinGeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"LINK": "chainlink",
+	"SOL":  "solana",
+	"UNI":  "uniswap",
+	"AAVE": "aave",
+	"USDC": "usd-coin",
+	"USDT": "tether",
+	"DAI":  "dai",
+}
+
+// CoinGeckoSymbol returns the CoinGecko coin ID of the pair's base asset
+func CoinGeckoSymbol(p pair.Pair) string {
+	if id, ok := coinGeckoIDs[p.Base]; ok {
+		return id
+	}
+	return strings.ToLower(p.Base)
+}
+
+// BinanceSymbol returns the Binance symbol, e.g. ETHUSDT
+func BinanceSymbol(p pair.Pair) string {
+	return p.Base + BinanceQuoteAsset(p)
+}
+
+// BinanceQuoteAsset returns the asset Binance quotes the pair in; Binance quotes USD markets in USDT
+func BinanceQuoteAsset(p pair.Pair) string {
+	if p.Quote == "USD" {
+		return "USDT"
+	}
+	return p.Quote
+}
+
+// UniswapV3QuoteAsset returns the asset Uniswap V3 pools quote the pair in; USD pairs trade against USDC
+func UniswapV3QuoteAsset(p pair.Pair) string {
+	if p.Quote == "USD" {
+		return "USDC"
+	}
+	return p.Quote
+}
+
+// CoinbaseSymbol returns the Coinbase product ID, e.g. ETH-USD
+func CoinbaseSymbol(p pair.Pair) string {
+	return p.Base + "-" + p.Quote
+}
+
+// KrakenSymbol returns the Kraken REST pair name, e.g. ETHUSD
+func KrakenSymbol(p pair.Pair) string {
+	return p.Base + p.Quote
+}
+
+// KrakenStreamSymbol returns the Kraken WebSocket v2 symbol, e.g. ETH/USD
+func KrakenStreamSymbol(p pair.Pair) string {
+	return p.String()
+}
+
+// ExpandURL fills the pair placeholders of an endpoint template
+// Supported placeholders are {symbol}, {symbol_lower}, {base}, {quote}, {base_lower} and {quote_lower};
+// URLs without placeholders are returned unchanged
+func ExpandURL(template string, p pair.Pair, symbol string) string {
+	return strings.NewReplacer(
+		"{symbol}", symbol,
+		"{symbol_lower}", strings.ToLower(symbol),
+		"{base}", p.Base,
+		"{quote}", p.Quote,
+		"{base_lower}", strings.ToLower(p.Base),
+		"{quote_lower}", strings.ToLower(p.Quote),
+	).Replace(template)
+}
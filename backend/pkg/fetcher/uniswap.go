@@ -0,0 +1,268 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrorClassRPC is reported when an on-chain source's contract call fails
+const ErrorClassRPC ErrorClass = "rpc"
+
+// DefaultUniswapV3TWAPWindow is the TWAP window used unless the source configures one
+const DefaultUniswapV3TWAPWindow = 30 * time.Minute
+
+// uniswapV3PoolABI is the subset of the Uniswap V3 pool interface the source reads
+const uniswapV3PoolABI = `[
+	{"type":"function","name":"observe","stateMutability":"view",
+	 "inputs":[{"name":"secondsAgos","type":"uint32[]"}],
+	 "outputs":[{"name":"tickCumulatives","type":"int56[]"},{"name":"secondsPerLiquidityCumulativeX128s","type":"uint160[]"}]},
+	{"type":"function","name":"slot0","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"},{"name":"observationIndex","type":"uint16"},
+	            {"name":"observationCardinality","type":"uint16"},{"name":"observationCardinalityNext","type":"uint16"},
+	            {"name":"feeProtocol","type":"uint8"},{"name":"unlocked","type":"bool"}]},
+	{"type":"function","name":"token0","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"token1","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+]`
+
+// erc20MetadataABI is the subset of the ERC-20 interface used to identify pool tokens
+const erc20MetadataABI = `[
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]}
+]`
+
+var (
+	uniswapV3PoolContractABI = mustParseABI(uniswapV3PoolABI)
+	erc20MetadataContractABI = mustParseABI(erc20MetadataABI)
+)
+
+// tokenAssets maps wrapped token symbols to the asset they stand for in a pair
+// Stablecoins keep their own symbol, so pools quoting in them are converted like any stablecoin-quoted source
+var tokenAssets = map[string]string{
+	"WETH": "ETH",
+	"WBTC": "BTC",
+}
+
+// mustParseABI parses a static ABI definition, panicking on malformed input
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ABI: %v", err))
+	}
+	return parsed
+}
+
+// UniswapV3Config selects the pool a Uniswap V3 source reads and how it prices it
+type UniswapV3Config struct {
+	// Pool is the address of the Uniswap V3 pool
+	Pool common.Address
+	// Window is the TWAP window read through observe(); zero reads the spot price from slot0()
+	Window time.Duration
+}
+
+// uniswapV3Tokens is the token layout of a pool, discovered on the first fetch
+type uniswapV3Tokens struct {
+	decimals0 int
+	decimals1 int
+	// baseIsToken0 is set when the pair's base asset is token0, so the pool price is used as is
+	baseIsToken0 bool
+}
+
+// UniswapV3Source prices a pair from a Uniswap V3 pool, either as a TWAP over the pool's
+// tick accumulator or as the spot price of slot0
+type UniswapV3Source struct {
+	name   string
+	pair   pair.Pair
+	caller bind.ContractCaller
+	pool   *bind.BoundContract
+	config UniswapV3Config
+
+	mu     sync.Mutex
+	tokens *uniswapV3Tokens
+}
+
+// NewUniswapV3Source creates a Uniswap V3 source quoting p from the configured pool
+func NewUniswapV3Source(name string, p pair.Pair, caller bind.ContractCaller, config UniswapV3Config) (*UniswapV3Source, error) {
+	if config.Pool == (common.Address{}) {
+		return nil, fmt.Errorf("pool address is required")
+	}
+	if config.Window < 0 || config.Window > math.MaxUint32*time.Second {
+		return nil, fmt.Errorf("invalid TWAP window: %v", config.Window)
+	}
+
+	return &UniswapV3Source{
+		name:   name,
+		pair:   p,
+		caller: caller,
+		pool:   bind.NewBoundContract(config.Pool, uniswapV3PoolContractABI, caller, nil, nil),
+		config: config,
+	}, nil
+}
+
+// Name returns the source name
+func (s *UniswapV3Source) Name() string {
+	return s.name
+}
+
+// FetchPrice reads the pool price, as a TWAP when a window is configured and as the spot price otherwise
+func (s *UniswapV3Source) FetchPrice(ctx context.Context) (*Quote, error) {
+	tokens, err := s.poolTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawPrice float64
+	if s.config.Window > 0 {
+		rawPrice, err = s.twapPrice(ctx)
+	} else {
+		rawPrice, err = s.spotPrice(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The pool prices token0 in raw units of token1; scale to whole tokens and orient to the pair
+	price := rawPrice * math.Pow10(tokens.decimals0-tokens.decimals1)
+	if !tokens.baseIsToken0 && price > 0 {
+		price = 1 / price
+	}
+	if price <= 0 || math.IsInf(price, 0) || math.IsNaN(price) {
+		return nil, newSourceError(ErrorClassInvalidPrice, 0, fmt.Errorf("invalid price received from %s: %f", s.name, price))
+	}
+
+	return &Quote{
+		Source:    s.name,
+		Price:     price,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// twapPrice returns the raw token1/token0 price at the mean tick over the window
+func (s *UniswapV3Source) twapPrice(ctx context.Context) (float64, error) {
+	window := uint32(s.config.Window / time.Second)
+
+	var out []interface{}
+	if err := s.pool.Call(&bind.CallOpts{Context: ctx}, &out, "observe", []uint32{window, 0}); err != nil {
+		return 0, contractCallError(s.name, "observe", err)
+	}
+	tickCumulatives, ok := out[0].([]*big.Int)
+	if !ok || len(tickCumulatives) != 2 {
+		return 0, newSourceError(ErrorClassDecode, 0, fmt.Errorf("unexpected observe() result from %s", s.name))
+	}
+
+	// Mean tick rounded towards negative infinity, as Uniswap's OracleLibrary does
+	delta := new(big.Int).Sub(tickCumulatives[1], tickCumulatives[0])
+	seconds := big.NewInt(int64(window))
+	meanTick, remainder := new(big.Int).QuoRem(delta, seconds, new(big.Int))
+	if delta.Sign() < 0 && remainder.Sign() != 0 {
+		meanTick.Sub(meanTick, big.NewInt(1))
+	}
+
+	return math.Pow(1.0001, float64(meanTick.Int64())), nil
+}
+
+// spotPrice returns the raw token1/token0 price of the pool's current sqrt price
+func (s *UniswapV3Source) spotPrice(ctx context.Context) (float64, error) {
+	var out []interface{}
+	if err := s.pool.Call(&bind.CallOpts{Context: ctx}, &out, "slot0"); err != nil {
+		return 0, contractCallError(s.name, "slot0", err)
+	}
+	sqrtPriceX96, ok := out[0].(*big.Int)
+	if !ok {
+		return 0, newSourceError(ErrorClassDecode, 0, fmt.Errorf("unexpected slot0() result from %s", s.name))
+	}
+
+	// price = (sqrtPriceX96 / 2^96)^2
+	sqrtPrice := new(big.Float).Quo(new(big.Float).SetInt(sqrtPriceX96), new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96)))
+	price, _ := new(big.Float).Mul(sqrtPrice, sqrtPrice).Float64()
+	return price, nil
+}
+
+// poolTokens discovers the pool's token decimals and orientation, caching them once known
+func (s *UniswapV3Source) poolTokens(ctx context.Context) (*uniswapV3Tokens, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokens != nil {
+		return s.tokens, nil
+	}
+
+	var symbols [2]string
+	var decimals [2]int
+	for i, method := range []string{"token0", "token1"} {
+		var out []interface{}
+		if err := s.pool.Call(&bind.CallOpts{Context: ctx}, &out, method); err != nil {
+			return nil, contractCallError(s.name, method, err)
+		}
+		token := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+		symbol, tokenDecimals, err := s.tokenMetadata(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		symbols[i], decimals[i] = symbol, tokenDecimals
+	}
+
+	asset0, asset1 := tokenAsset(symbols[0]), tokenAsset(symbols[1])
+	tokens := &uniswapV3Tokens{decimals0: decimals[0], decimals1: decimals[1]}
+	switch {
+	case asset0 == s.pair.Base && asset1 == s.pair.Quote:
+		tokens.baseIsToken0 = true
+	case asset1 == s.pair.Base && asset0 == s.pair.Quote:
+		tokens.baseIsToken0 = false
+	default:
+		return nil, newSourceError(ErrorClassDecode, 0, fmt.Errorf("pool %s holds %s/%s, which does not match %s; set the source's quote asset to the pool's quote token",
+			s.config.Pool.Hex(), symbols[0], symbols[1], s.pair))
+	}
+
+	s.tokens = tokens
+	return tokens, nil
+}
+
+// tokenMetadata reads the symbol and decimals of an ERC-20 token
+func (s *UniswapV3Source) tokenMetadata(ctx context.Context, token common.Address) (string, int, error) {
+	contract := bind.NewBoundContract(token, erc20MetadataContractABI, s.caller, nil, nil)
+
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "symbol"); err != nil {
+		return "", 0, contractCallError(s.name, "symbol", err)
+	}
+	symbol := *abi.ConvertType(out[0], new(string)).(*string)
+
+	out = nil
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "decimals"); err != nil {
+		return "", 0, contractCallError(s.name, "decimals", err)
+	}
+	decimals := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	return symbol, int(decimals), nil
+}
+
+// contractCallError wraps a failed contract call of an on-chain source, keeping timeouts and
+// cancellations classified as such
+func contractCallError(source, method string, err error) error {
+	err = fmt.Errorf("failed to call %s() on %s: %w", method, source, err)
+	class, _ := ClassifyError(err)
+	if class == ErrorClassUnknown || class == ErrorClassNetwork {
+		class = ErrorClassRPC
+	}
+	return newSourceError(class, 0, err)
+}
+
+// tokenAsset returns the pair asset a token symbol stands for
+func tokenAsset(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if asset, ok := tokenAssets[symbol]; ok {
+		return asset
+	}
+	return symbol
+}
@@ -0,0 +1,238 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeContract answers calls to one contract with canned results per method
+type fakeContract struct {
+	abi     abi.ABI
+	results map[string][]interface{}
+	// err fails every call to the contract when set
+	err error
+}
+
+// fakeCaller is a bind.ContractCaller serving ABI-encoded results of fake contracts
+type fakeCaller struct {
+	contracts map[common.Address]*fakeContract
+}
+
+func (c *fakeCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if _, ok := c.contracts[contract]; !ok {
+		return nil, nil
+	}
+	return []byte{0x60}, nil
+}
+
+func (c *fakeCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	contract, ok := c.contracts[*call.To]
+	if !ok {
+		return nil, nil
+	}
+	if contract.err != nil {
+		return nil, contract.err
+	}
+	method, err := contract.abi.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	results, ok := contract.results[method.Name]
+	if !ok {
+		return nil, fmt.Errorf("execution reverted: %s() not faked", method.Name)
+	}
+	return method.Outputs.Pack(results...)
+}
+
+var (
+	testPool = common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640")
+	testWETH = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	testUSDC = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	testDAI  = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+)
+
+// erc20 fakes the metadata of an ERC-20 token
+func erc20(symbol string, decimals uint8) *fakeContract {
+	return &fakeContract{
+		abi:     erc20MetadataContractABI,
+		results: map[string][]interface{}{"symbol": {symbol}, "decimals": {decimals}},
+	}
+}
+
+// poolCaller fakes a pool holding token0 and token1 with the given observe() and slot0() results
+func poolCaller(token0, token1 common.Address, pool map[string][]interface{}) *fakeCaller {
+	pool["token0"] = []interface{}{token0}
+	pool["token1"] = []interface{}{token1}
+	return &fakeCaller{contracts: map[common.Address]*fakeContract{
+		testPool: {abi: uniswapV3PoolContractABI, results: pool},
+		testWETH: erc20("WETH", 18),
+		testUSDC: erc20("USDC", 6),
+		testDAI:  erc20("DAI", 18),
+	}}
+}
+
+// observeResult fakes observe() over window seconds with the given tick cumulative delta
+func observeResult(window uint32, delta int64) []interface{} {
+	start := big.NewInt(1_000_000_000)
+	return []interface{}{
+		[]*big.Int{start, new(big.Int).Add(start, big.NewInt(delta))},
+		[]*big.Int{big.NewInt(0), big.NewInt(0)},
+	}
+}
+
+// slot0Result fakes slot0() at the given sqrt price
+func slot0Result(sqrtPriceX96 *big.Int) []interface{} {
+	return []interface{}{sqrtPriceX96, big.NewInt(0), uint16(0), uint16(1), uint16(1), uint8(0), true}
+}
+
+func TestUniswapV3SourcePricesPools(t *testing.T) {
+	const window = 1800
+	// 2^96 * sqrt(1e12 / 3000), the sqrt price of a USDC/WETH pool at 3000 USDC per ETH
+	sqrtPrice, _ := new(big.Int).SetString("1446501726624926496477173928747177", 10)
+
+	tests := []struct {
+		name   string
+		token0 common.Address
+		token1 common.Address
+		pool   map[string][]interface{}
+		window time.Duration
+		price  float64
+	}{
+		{
+			name:   "TWAP with base as token0",
+			token0: testWETH, token1: testDAI,
+			pool:   map[string][]interface{}{"observe": observeResult(window, 80067*window+900)},
+			window: window * time.Second,
+			price:  math.Pow(1.0001, 80067),
+		},
+		{
+			// A negative mean tick rounds towards negative infinity, as in Uniswap's OracleLibrary
+			name:   "TWAP with base as token1",
+			token0: testDAI, token1: testWETH,
+			pool:   map[string][]interface{}{"observe": observeResult(window, -80067*window-900)},
+			window: window * time.Second,
+			price:  math.Pow(1.0001, 80068),
+		},
+		{
+			name:   "TWAP scaled by token decimals",
+			token0: testUSDC, token1: testWETH,
+			pool:   map[string][]interface{}{"observe": observeResult(window, 196256*window)},
+			window: window * time.Second,
+			price:  1 / (math.Pow(1.0001, 196256) * 1e-12),
+		},
+		{
+			name:   "spot price",
+			token0: testUSDC, token1: testWETH,
+			pool:  map[string][]interface{}{"slot0": slot0Result(sqrtPrice)},
+			price: 3000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoteAsset := "DAI"
+			if tt.token0 == testUSDC {
+				quoteAsset = "USDC"
+			}
+			caller := poolCaller(tt.token0, tt.token1, tt.pool)
+			source, err := NewUniswapV3Source("univ3", pair.New("ETH", quoteAsset), caller, UniswapV3Config{Pool: testPool, Window: tt.window})
+			if err != nil {
+				t.Fatalf("NewUniswapV3Source() error = %v", err)
+			}
+
+			quote, err := source.FetchPrice(context.Background())
+			if err != nil {
+				t.Fatalf("FetchPrice() error = %v", err)
+			}
+			if math.Abs(quote.Price-tt.price)/tt.price > 1e-9 {
+				t.Errorf("Price = %v, want %v", quote.Price, tt.price)
+			}
+			if quote.Source != "univ3" {
+				t.Errorf("Source = %q, want %q", quote.Source, "univ3")
+			}
+		})
+	}
+}
+
+func TestUniswapV3SourceKeepsStablecoinsApart(t *testing.T) {
+	caller := poolCaller(testUSDC, testWETH, map[string][]interface{}{"observe": observeResult(1800, 196256*1800)})
+
+	// A USDC pool does not quote USD at par
+	source, err := NewUniswapV3Source("univ3", pair.New("ETH", "USD"), caller, UniswapV3Config{Pool: testPool, Window: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("NewUniswapV3Source() error = %v", err)
+	}
+	_, err = source.FetchPrice(context.Background())
+	if class, _ := ClassifyError(err); class != ErrorClassDecode || !strings.Contains(err.Error(), "does not match ETH/USD") {
+		t.Fatalf("FetchPrice() for ETH/USD error = %v, want a pool mismatch", err)
+	}
+
+	// USD pairs are read from USDC pools by default and converted at the USDC/USD rate
+	registry := NewRegistry()
+	quoteAsset := registry.QuoteAsset("uniswap_v3", pair.New("ETH", "USD"))
+	if quoteAsset != "USDC" {
+		t.Fatalf("QuoteAsset(uniswap_v3, ETH/USD) = %q, want USDC", quoteAsset)
+	}
+	source, err = NewUniswapV3Source("univ3", pair.New("ETH", quoteAsset), caller, UniswapV3Config{Pool: testPool, Window: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("NewUniswapV3Source() error = %v", err)
+	}
+	rates := NewRateBook()
+	rates.Set(pair.New("USDC", "USD"), 0.999, time.Now())
+	converted, err := NewConvertedSource(source, "USDC", "USD", rates, DefaultConversionConfig())
+	if err != nil {
+		t.Fatalf("NewConvertedSource() error = %v", err)
+	}
+
+	quote, err := converted.FetchPrice(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrice() error = %v", err)
+	}
+	want := 1 / (math.Pow(1.0001, 196256) * 1e-12) * 0.999
+	if math.Abs(quote.Price-want)/want > 1e-9 {
+		t.Errorf("Price = %v, want %v", quote.Price, want)
+	}
+	if quote.QuoteAsset != "USDC" {
+		t.Errorf("QuoteAsset = %q, want USDC", quote.QuoteAsset)
+	}
+}
+
+func TestUniswapV3SourceClassifiesCallFailures(t *testing.T) {
+	caller := poolCaller(testWETH, testDAI, map[string][]interface{}{})
+	source, err := NewUniswapV3Source("univ3", pair.New("ETH", "DAI"), caller, UniswapV3Config{Pool: testPool, Window: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("NewUniswapV3Source() error = %v", err)
+	}
+
+	// observe() reverts, e.g. when the pool's observations do not reach back over the window
+	_, err = source.FetchPrice(context.Background())
+	if class, _ := ClassifyError(err); class != ErrorClassRPC {
+		t.Errorf("error class for a reverted observe() = %q, want %q (error: %v)", class, ErrorClassRPC, err)
+	}
+
+	caller.contracts[testPool].err = errors.New("connection refused")
+	_, err = source.FetchPrice(context.Background())
+	if class, _ := ClassifyError(err); class != ErrorClassRPC {
+		t.Errorf("error class for an unreachable node = %q, want %q (error: %v)", class, ErrorClassRPC, err)
+	}
+}
+
+func TestNewUniswapV3SourceRejectsInvalidConfig(t *testing.T) {
+	if _, err := NewUniswapV3Source("univ3", pair.ETHUSD, &fakeCaller{}, UniswapV3Config{}); err == nil {
+		t.Error("NewUniswapV3Source() without a pool succeeded")
+	}
+	if _, err := NewUniswapV3Source("univ3", pair.ETHUSD, &fakeCaller{}, UniswapV3Config{Pool: testPool, Window: -time.Second}); err == nil {
+		t.Error("NewUniswapV3Source() with a negative window succeeded")
+	}
+}
@@ -14,6 +14,42 @@ type Metrics struct {
 	FetchErrors  prometheus.CounterVec
 	FetchSuccess prometheus.CounterVec
 
+	// Rate limiting metrics
+	SourcesSkipped prometheus.CounterVec
+
+	// Source health metrics
+	SourceHealth       prometheus.GaugeVec
+	SourceCircuitState prometheus.GaugeVec
+
+	// Streaming source metrics
+	StreamConnected prometheus.GaugeVec
+	StreamAge       prometheus.GaugeVec
+
+	// Quorum metrics
+	QuorumFailures prometheus.CounterVec
+
+	// Aggregation metrics
+	SourcesDiscarded prometheus.CounterVec
+	AggregateSources prometheus.GaugeVec
+	AggregateSpread  prometheus.GaugeVec
+
+	// Reference feed metrics
+	ReferenceDeviation prometheus.GaugeVec
+	ReferenceRejects   prometheus.CounterVec
+
+	// Validation metrics
+	ValidationRejections prometheus.CounterVec
+	JumpEvents           prometheus.CounterVec
+
+	// Stablecoin conversion metrics
+	PegDeviation   prometheus.GaugeVec
+	DepeggedQuotes prometheus.CounterVec
+
+	// Scheduling metrics
+	FetchInterval        prometheus.Gauge
+	FetchIntervalChanges prometheus.CounterVec
+	RealisedVolatility   prometheus.GaugeVec
+
 	// Price update metrics
 	PriceUpdates prometheus.CounterVec
 	PriceAge     prometheus.GaugeVec
@@ -46,35 +82,160 @@ func NewMetrics() *Metrics {
 				Help:    "Duration of price fetch operations",
 				Buckets: prometheus.DefBuckets,
 			},
-			[]string{"source", "status"},
+			[]string{"pair", "source", "status"},
 		),
 		FetchErrors: *promauto.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "price_fetch_errors_total",
 				Help: "Total number of price fetch errors",
 			},
-			[]string{"source", "error_type"},
+			[]string{"pair", "source", "error_type"},
 		),
 		FetchSuccess: *promauto.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "price_fetch_success_total",
 				Help: "Total number of successful price fetches",
 			},
-			[]string{"source"},
+			[]string{"pair", "source"},
+		),
+		SourcesSkipped: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_sources_skipped_total",
+				Help: "Total number of source fetches skipped because the source is backing off or its circuit is open",
+			},
+			[]string{"pair", "source"},
+		),
+		SourceHealth: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_source_health_score",
+				Help: "Rolling health score of a price source between 0 and 1",
+			},
+			[]string{"pair", "source"},
+		),
+		SourceCircuitState: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_source_circuit_state",
+				Help: "Circuit breaker state of a price source (0 = closed, 1 = half-open, 2 = open)",
+			},
+			[]string{"pair", "source"},
+		),
+		StreamConnected: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_stream_connected",
+				Help: "Whether a streaming price source is connected (1) or reconnecting (0)",
+			},
+			[]string{"pair", "source"},
+		),
+		StreamAge: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_stream_update_age_seconds",
+				Help: "Age in seconds of the latest update received from a streaming price source",
+			},
+			[]string{"pair", "source"},
+		),
+		QuorumFailures: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_quorum_failures_total",
+				Help: "Total number of fetch rounds skipped because too few sources returned a quote",
+			},
+			[]string{"pair"},
+		),
+		SourcesDiscarded: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_sources_discarded_total",
+				Help: "Total number of source quotes discarded during aggregation",
+			},
+			[]string{"pair", "source", "reason"},
+		),
+		AggregateSources: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_aggregate_sources",
+				Help: "Number of sources that contributed to the latest aggregated price",
+			},
+			[]string{"pair"},
+		),
+		AggregateSpread: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_aggregate_spread_ratio",
+				Help: "Spread between the highest and lowest contributing quote relative to the aggregated price",
+			},
+			[]string{"pair"},
+		),
+		ReferenceDeviation: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_reference_deviation_ratio",
+				Help: "Relative deviation of the aggregated price from the reference feed",
+			},
+			[]string{"pair", "reference"},
+		),
+		ReferenceRejects: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_reference_rejections_total",
+				Help: "Prices withheld because they deviated too far from the reference feed",
+			},
+			[]string{"pair", "reference"},
+		),
+		ValidationRejections: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_validation_rejections_total",
+				Help: "Prices rejected by the pair's validation rules, by reason code",
+			},
+			[]string{"pair", "reason"},
+		),
+		JumpEvents: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_jump_events_total",
+				Help: "Large price moves held, confirmed or discarded by the jump guard",
+			},
+			[]string{"pair", "kind"},
+		),
+		PegDeviation: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_stablecoin_peg_deviation_ratio",
+				Help: "Relative distance of a tracked stablecoin from its peg",
+			},
+			[]string{"pair"},
+		),
+		DepeggedQuotes: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_depegged_quotes_total",
+				Help: "Quotes used after conversion from a stablecoin that was off its peg",
+			},
+			[]string{"pair", "source", "asset"},
+		),
+		FetchInterval: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "price_fetch_interval_seconds",
+				Help: "Current interval between two fetch rounds",
+			},
+		),
+		FetchIntervalChanges: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "price_fetch_interval_changes_total",
+				Help: "Total number of fetch interval changes by the reason for the change",
+			},
+			[]string{"reason"},
+		),
+		RealisedVolatility: *promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "price_realised_volatility",
+				Help: "Realised volatility of recent stored prices, as the standard deviation of log returns per square root of a minute",
+			},
+			[]string{"pair"},
 		),
 		PriceUpdates: *promauto.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "price_updates_total",
 				Help: "Total number of price updates",
 			},
-			[]string{"source", "type"},
+			[]string{"pair", "source", "type"},
 		),
 		PriceAge: *promauto.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "price_age_seconds",
 				Help: "Age of the latest price in seconds",
 			},
-			[]string{"source"},
+			[]string{"pair", "source"},
 		),
 		CacheHits: *promauto.NewCounterVec(
 			prometheus.CounterOpts{
@@ -149,28 +310,106 @@ func NewMetrics() *Metrics {
 }
 
 // RecordFetchLatency records how long it took to fetch a price
-func (m *Metrics) RecordFetchLatency(duration time.Duration, source, status string) {
-	m.FetchLatency.WithLabelValues(source, status).Observe(duration.Seconds())
+func (m *Metrics) RecordFetchLatency(duration time.Duration, pair, source, status string) {
+	m.FetchLatency.WithLabelValues(pair, source, status).Observe(duration.Seconds())
 }
 
 // RecordFetchError records a price fetch error
-func (m *Metrics) RecordFetchError(source, errorType string) {
-	m.FetchErrors.WithLabelValues(source, errorType).Inc()
+func (m *Metrics) RecordFetchError(pair, source, errorType string) {
+	m.FetchErrors.WithLabelValues(pair, source, errorType).Inc()
 }
 
 // RecordFetchSuccess records a successful price fetch
-func (m *Metrics) RecordFetchSuccess(source string) {
-	m.FetchSuccess.WithLabelValues(source).Inc()
+func (m *Metrics) RecordFetchSuccess(pair, source string) {
+	m.FetchSuccess.WithLabelValues(pair, source).Inc()
+}
+
+// RecordSourceSkipped records a source fetch skipped because the source is backing off or its circuit is open
+func (m *Metrics) RecordSourceSkipped(pair, source string) {
+	m.SourcesSkipped.WithLabelValues(pair, source).Inc()
+}
+
+// RecordSourceHealth records the health score and circuit breaker state of a price source
+func (m *Metrics) RecordSourceHealth(pair, source string, score float64, circuitState float64) {
+	m.SourceHealth.WithLabelValues(pair, source).Set(score)
+	m.SourceCircuitState.WithLabelValues(pair, source).Set(circuitState)
+}
+
+// RecordStreamStatus records the connection state and data age of a streaming price source
+func (m *Metrics) RecordStreamStatus(pair, source string, connected bool, age time.Duration) {
+	value := 0.0
+	if connected {
+		value = 1
+	}
+	m.StreamConnected.WithLabelValues(pair, source).Set(value)
+	m.StreamAge.WithLabelValues(pair, source).Set(age.Seconds())
+}
+
+// RecordQuorumFailure records a fetch round that did not reach the source quorum
+func (m *Metrics) RecordQuorumFailure(pair string) {
+	m.QuorumFailures.WithLabelValues(pair).Inc()
+}
+
+// RecordSourceDiscarded records a source quote discarded during aggregation
+func (m *Metrics) RecordSourceDiscarded(pair, source, reason string) {
+	m.SourcesDiscarded.WithLabelValues(pair, source, reason).Inc()
+}
+
+// RecordAggregate records the number of contributing sources and spread of an aggregated price
+func (m *Metrics) RecordAggregate(pair string, sources int, spreadRatio float64) {
+	m.AggregateSources.WithLabelValues(pair).Set(float64(sources))
+	m.AggregateSpread.WithLabelValues(pair).Set(spreadRatio)
+}
+
+// RecordReferenceCheck records the deviation from the reference feed and whether the price was withheld
+func (m *Metrics) RecordReferenceCheck(pair, reference string, deviation float64, rejected bool) {
+	m.ReferenceDeviation.WithLabelValues(pair, reference).Set(deviation)
+	if rejected {
+		m.ReferenceRejects.WithLabelValues(pair, reference).Inc()
+	}
+}
+
+// RecordValidationRejection counts a price rejected by a validation rule
+func (m *Metrics) RecordValidationRejection(pair, reason string) {
+	m.ValidationRejections.WithLabelValues(pair, reason).Inc()
+}
+
+// RecordJumpEvent counts a decision of the jump guard
+func (m *Metrics) RecordJumpEvent(pair, kind string) {
+	m.JumpEvents.WithLabelValues(pair, kind).Inc()
+}
+
+// RecordPegDeviation records how far a tracked stablecoin is from its peg
+func (m *Metrics) RecordPegDeviation(pair string, deviation float64) {
+	m.PegDeviation.WithLabelValues(pair).Set(deviation)
+}
+
+// RecordDepeggedQuote counts a quote used after conversion from a depegged stablecoin
+func (m *Metrics) RecordDepeggedQuote(pair, source, asset string) {
+	m.DepeggedQuotes.WithLabelValues(pair, source, asset).Inc()
+}
+
+// RecordFetchInterval records the current fetch interval, counting the change and its reason when it changed
+func (m *Metrics) RecordFetchInterval(interval time.Duration, reason string, changed bool) {
+	m.FetchInterval.Set(interval.Seconds())
+	if changed {
+		m.FetchIntervalChanges.WithLabelValues(reason).Inc()
+	}
+}
+
+// RecordRealisedVolatility records the realised volatility of recent prices of a pair
+func (m *Metrics) RecordRealisedVolatility(pair string, volatility float64) {
+	m.RealisedVolatility.WithLabelValues(pair).Set(volatility)
 }
 
 // RecordPriceUpdate increments counter for successful price updates
-func (m *Metrics) RecordPriceUpdate(source, updateType string) {
-	m.PriceUpdates.WithLabelValues(source, updateType).Inc()
+func (m *Metrics) RecordPriceUpdate(pair, source, updateType string) {
+	m.PriceUpdates.WithLabelValues(pair, source, updateType).Inc()
 }
 
 // RecordPriceAge records the age of the latest price
-func (m *Metrics) RecordPriceAge(age time.Duration, source string) {
-	m.PriceAge.WithLabelValues(source).Set(age.Seconds())
+func (m *Metrics) RecordPriceAge(age time.Duration, pair, source string) {
+	m.PriceAge.WithLabelValues(pair, source).Set(age.Seconds())
 }
 
 // RecordCacheHit records a cache hit
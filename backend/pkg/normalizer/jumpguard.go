@@ -0,0 +1,179 @@
+package normalizer
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// JumpKind is the kind of a jump guard event
+type JumpKind string
+
+const (
+	// JumpHeld marks a large move held back until it is confirmed
+	JumpHeld JumpKind = "held"
+	// JumpConfirmed marks a held move that was confirmed and released
+	JumpConfirmed JumpKind = "confirmed"
+	// JumpDiscarded marks a held move dropped because the next price did not repeat it
+	JumpDiscarded JumpKind = "discarded"
+)
+
+const (
+	// ConfirmedByFetches confirms a move repeated by enough consecutive fetches
+	ConfirmedByFetches = "fetches"
+	// ConfirmedBySources confirms a move reported by enough sources within one fetch
+	ConfirmedBySources = "sources"
+)
+
+// JumpConfig controls when a price move needs confirmation before it is published
+type JumpConfig struct {
+	// Threshold is the relative move from the last published price that needs confirmation
+	Threshold float64
+	// ConfirmFetches is the number of consecutive fetches, including the first, that must repeat the move;
+	// it is at least 2, since a single fetch would confirm every move it holds
+	ConfirmFetches int
+	// ConfirmSources is the number of sources that confirm the move at once when they all report it; zero disables it
+	ConfirmSources int
+}
+
+// Validate checks if the jump guard configuration is valid
+func (c JumpConfig) Validate() error {
+	if c.Threshold <= 0 {
+		return fmt.Errorf("jump threshold must be positive, got: %f", c.Threshold)
+	}
+	if c.ConfirmFetches < 2 {
+		return fmt.Errorf("confirming fetches must be at least 2, got: %d", c.ConfirmFetches)
+	}
+	if c.ConfirmSources < 0 {
+		return fmt.Errorf("confirming sources must not be negative, got: %d", c.ConfirmSources)
+	}
+	return nil
+}
+
+// JumpEvent records a decision of the jump guard about a large move
+type JumpEvent struct {
+	Kind  JumpKind
+	Price float64
+	// Reference is the last published price the move is measured from, and Change the relative move
+	Reference float64
+	Change    float64
+	// Fetches is the number of consecutive fetches that repeated the move, and Sources the number of
+	// sources of the latest fetch that reported it
+	Fetches int
+	Sources int
+	// ConfirmedBy is ConfirmedByFetches or ConfirmedBySources for confirmations
+	ConfirmedBy string
+}
+
+// pendingJump is a move held until it is confirmed
+type pendingJump struct {
+	direction float64
+	price     float64
+	change    float64
+	fetches   int
+}
+
+// JumpGuard holds prices that move far from the last published price until the move is confirmed,
+// either by consecutive fetches or by enough sources within one fetch
+type JumpGuard struct {
+	config JumpConfig
+
+	mu        sync.Mutex
+	reference float64
+	pending   *pendingJump
+}
+
+// NewJumpGuard creates a jump guard; the first price it sees is published as the reference
+func NewJumpGuard(config JumpConfig) (*JumpGuard, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid jump guard config: %w", err)
+	}
+
+	return &JumpGuard{config: config}, nil
+}
+
+// Check reports whether price may be published, given the prices of the sources it was aggregated from,
+// and returns the events the decision caused
+// A held move is discarded as soon as a price no longer repeats it
+func (g *JumpGuard) Check(price float64, sourcePrices []float64) (bool, []JumpEvent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.reference <= 0 {
+		g.reference = price
+		return true, nil
+	}
+
+	var events []JumpEvent
+	change := (price - g.reference) / g.reference
+	direction := math.Copysign(1, change)
+	isJump := math.Abs(change) > g.config.Threshold
+
+	if g.pending != nil && (!isJump || direction != g.pending.direction) {
+		events = append(events, JumpEvent{
+			Kind:      JumpDiscarded,
+			Price:     g.pending.price,
+			Reference: g.reference,
+			Change:    g.pending.change,
+			Fetches:   g.pending.fetches,
+		})
+		g.pending = nil
+	}
+	if !isJump {
+		g.reference = price
+		return true, events
+	}
+
+	if g.pending == nil {
+		g.pending = &pendingJump{direction: direction}
+	}
+	g.pending.price = price
+	g.pending.change = change
+	g.pending.fetches++
+
+	event := JumpEvent{
+		Kind:      JumpHeld,
+		Price:     price,
+		Reference: g.reference,
+		Change:    change,
+		Fetches:   g.pending.fetches,
+		Sources:   g.agreeingSources(sourcePrices, direction),
+	}
+	switch {
+	case event.Fetches >= g.config.ConfirmFetches:
+		event.ConfirmedBy = ConfirmedByFetches
+	case g.config.ConfirmSources > 0 && event.Sources >= g.config.ConfirmSources:
+		event.ConfirmedBy = ConfirmedBySources
+	}
+	if event.ConfirmedBy == "" {
+		return false, append(events, event)
+	}
+
+	event.Kind = JumpConfirmed
+	g.reference = price
+	g.pending = nil
+	return true, append(events, event)
+}
+
+// agreeingSources counts the source prices that moved past the threshold in direction
+func (g *JumpGuard) agreeingSources(sourcePrices []float64, direction float64) int {
+	agreeing := 0
+	for _, sourcePrice := range sourcePrices {
+		change := (sourcePrice - g.reference) / g.reference
+		if math.Abs(change) > g.config.Threshold && math.Copysign(1, change) == direction {
+			agreeing++
+		}
+	}
+	return agreeing
+}
+
+// Pending returns the held price and the number of fetches that repeated it, reporting false when nothing is held
+func (g *JumpGuard) Pending() (float64, int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pending == nil {
+		return 0, 0, false
+	}
+	return g.pending.price, g.pending.fetches, true
+}
@@ -0,0 +1,153 @@
+package normalizer
+
+import "testing"
+
+// testJumpConfig holds moves over 5% until 3 fetches or 2 sources repeat them
+func testJumpConfig() JumpConfig {
+	return JumpConfig{Threshold: 0.05, ConfirmFetches: 3, ConfirmSources: 2}
+}
+
+func TestJumpGuardCheck(t *testing.T) {
+	type step struct {
+		price   float64
+		sources []float64
+		publish bool
+		// kinds are the kinds of the events the step causes, in order
+		kinds []JumpKind
+		// fetches and confirmedBy describe the last event of the step
+		fetches     int
+		confirmedBy string
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		// pending is the price held after the last step, zero when nothing is held
+		pending float64
+	}{
+		{
+			name: "first price becomes the reference",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 104, publish: true},
+			},
+		},
+		{
+			name: "holds below the confirming fetches",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 110, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 1},
+				{price: 111, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 2},
+			},
+			pending: 111,
+		},
+		{
+			name: "confirms at the confirming fetches",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 110, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 1},
+				{price: 111, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 2},
+				{price: 112, publish: true, kinds: []JumpKind{JumpConfirmed}, fetches: 3, confirmedBy: ConfirmedByFetches},
+				// The confirmed price is the new reference
+				{price: 113, publish: true},
+			},
+		},
+		{
+			name: "confirms by agreeing sources",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 110, sources: []float64{110, 111, 100}, publish: true, kinds: []JumpKind{JumpConfirmed}, fetches: 1, confirmedBy: ConfirmedBySources},
+			},
+		},
+		{
+			name: "holds when too few sources agree",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 110, sources: []float64{110, 95, 100}, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 1},
+			},
+			pending: 110,
+		},
+		{
+			name: "discards on a direction flip",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 110, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 1},
+				// The drop is held afresh, measured from the unchanged reference
+				{price: 90, publish: false, kinds: []JumpKind{JumpDiscarded, JumpHeld}, fetches: 1},
+			},
+			pending: 90,
+		},
+		{
+			name: "discards on a return under the threshold",
+			steps: []step{
+				{price: 100, publish: true},
+				{price: 110, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 1},
+				{price: 111, publish: false, kinds: []JumpKind{JumpHeld}, fetches: 2},
+				{price: 102, publish: true, kinds: []JumpKind{JumpDiscarded}, fetches: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := NewJumpGuard(testJumpConfig())
+			if err != nil {
+				t.Fatalf("NewJumpGuard() error = %v", err)
+			}
+
+			for i, step := range tt.steps {
+				publish, events := guard.Check(step.price, step.sources)
+				if publish != step.publish {
+					t.Errorf("step %d: Check(%v) publish = %v, want %v", i+1, step.price, publish, step.publish)
+				}
+				if len(events) != len(step.kinds) {
+					t.Fatalf("step %d: Check(%v) events = %+v, want %v", i+1, step.price, events, step.kinds)
+				}
+				for j, event := range events {
+					if event.Kind != step.kinds[j] {
+						t.Errorf("step %d: event %d kind = %s, want %s", i+1, j+1, event.Kind, step.kinds[j])
+					}
+				}
+				if len(events) == 0 {
+					continue
+				}
+				last := events[len(events)-1]
+				if last.Fetches != step.fetches || last.ConfirmedBy != step.confirmedBy {
+					t.Errorf("step %d: last event = %+v, want %d fetches confirmed by %q", i+1, last, step.fetches, step.confirmedBy)
+				}
+				if last.Reference != 100 {
+					t.Errorf("step %d: reference = %v, want the first price 100", i+1, last.Reference)
+				}
+			}
+
+			price, _, held := guard.Pending()
+			if held != (tt.pending != 0) || price != tt.pending {
+				t.Errorf("Pending() = %v, %v, want %v", price, held, tt.pending)
+			}
+		})
+	}
+}
+
+func TestJumpConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*JumpConfig)
+	}{
+		{name: "zero threshold", modify: func(c *JumpConfig) { c.Threshold = 0 }},
+		{name: "single confirming fetch", modify: func(c *JumpConfig) { c.ConfirmFetches = 1 }},
+		{name: "negative confirming sources", modify: func(c *JumpConfig) { c.ConfirmSources = -1 }},
+	}
+
+	if err := testJumpConfig().Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testJumpConfig()
+			tt.modify(&config)
+			if err := config.Validate(); err == nil {
+				t.Error("Validate() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package normalizer
 
 import (
-	"fmt"
 	"math"
+	"math/big"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/validation"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
 )
 
 const (
@@ -10,20 +13,33 @@ const (
 	DecimalPrecision = 8
 	// Multiplier is 10^8 for 8 decimal places
 	Multiplier = 100000000
+	// DefaultMinPrice is the lowest price accepted unless bounds are configured
+	DefaultMinPrice = 1
+	// DefaultMaxPrice is the highest price accepted unless bounds are configured
+	DefaultMaxPrice = 1000000
 )
 
 // Normalizer handles price normalization to ensure consistent decimal precision
 type Normalizer struct {
 	precision  int
 	multiplier float64
+	minPrice   float64
+	maxPrice   float64
 }
 
 // NewNormalizer creates a new normalizer with specified precision
 func NewNormalizer(precision int) *Normalizer {
+	return NewNormalizerWithBounds(precision, DefaultMinPrice, DefaultMaxPrice)
+}
+
+// NewNormalizerWithBounds creates a normalizer that only accepts prices within [minPrice, maxPrice]
+func NewNormalizerWithBounds(precision int, minPrice, maxPrice float64) *Normalizer {
 	multiplier := math.Pow10(precision)
 	return &Normalizer{
 		precision:  precision,
 		multiplier: multiplier,
+		minPrice:   minPrice,
+		maxPrice:   maxPrice,
 	}
 }
 
@@ -34,48 +50,64 @@ func NewDefaultNormalizer() *Normalizer {
 
 // NormalizePrice ensures consistent decimal precision by rounding to specified decimal places
 func (n *Normalizer) NormalizePrice(price float64) float64 {
+	return n.NormalizeFixed(price).Float64()
+}
+
+// NormalizeFixed converts a price to an exact fixed-point price with the normalizer's precision
+// Non-positive and non-finite prices normalize to zero, like NormalizePrice
+func (n *Normalizer) NormalizeFixed(price float64) fixedpoint.Price {
 	if price <= 0 {
-		return 0
+		return fixedpoint.New(nil, n.precision)
+	}
+
+	fixed, err := fixedpoint.FromFloat(price, n.precision)
+	if err != nil {
+		return fixedpoint.New(nil, n.precision)
+	}
+	return fixed
+}
+
+// NormalizeExact rounds an exact price to the normalizer's precision without passing through a float
+// Non-positive prices normalize to zero, like NormalizeFixed
+func (n *Normalizer) NormalizeExact(price fixedpoint.Price) fixedpoint.Price {
+	if price.Sign() <= 0 {
+		return fixedpoint.New(nil, n.precision)
 	}
+	return price.Rescale(n.precision)
+}
 
-	// Round to the specified number of decimal places
-	rounded := math.Round(price*n.multiplier) / n.multiplier
+// NormalizeRat rounds an exact value, such as a cross rate, to the normalizer's precision
+// Non-positive values normalize to zero, like NormalizeFixed
+func (n *Normalizer) NormalizeRat(value *big.Rat) fixedpoint.Price {
+	if value == nil || value.Sign() <= 0 {
+		return fixedpoint.New(nil, n.precision)
+	}
 
-	return rounded
+	fixed, err := fixedpoint.FromRat(value, n.precision)
+	if err != nil {
+		return fixedpoint.New(nil, n.precision)
+	}
+	return fixed
 }
 
 // NormalizePriceToInt converts a price to an integer representation with specified precision
 // This is useful for blockchain operations where we need integer values
 func (n *Normalizer) NormalizePriceToInt(price float64) int64 {
-	if price <= 0 {
-		return 0
-	}
-
-	normalized := n.NormalizePrice(price)
-	return int64(normalized * n.multiplier)
+	return n.NormalizeFixed(price).Units().Int64()
 }
 
 // IntToPrice converts an integer representation back to a float price
 func (n *Normalizer) IntToPrice(priceInt int64) float64 {
-	return float64(priceInt) / n.multiplier
+	return fixedpoint.New(big.NewInt(priceInt), n.precision).Float64()
 }
 
 // ValidatePrice checks if a price is within reasonable bounds
+// It applies the bounds rule of the validation engine, returning a *validation.Rejection
 func (n *Normalizer) ValidatePrice(price float64) error {
-	if price <= 0 {
-		return fmt.Errorf("price must be positive, got: %f", price)
-	}
-
-	// Check for unreasonably high prices (e.g., > $1M per ETH)
-	if price > 1000000 {
-		return fmt.Errorf("price seems unreasonably high: %f", price)
-	}
-
-	// Check for unreasonably low prices (e.g., < $1 per ETH)
-	if price < 1 {
-		return fmt.Errorf("price seems unreasonably low: %f", price)
+	rule := validation.BoundsRule{Min: n.minPrice, Max: n.maxPrice}
+	if rejection := rule.Check(validation.Input{Price: price}); rejection != nil {
+		return rejection
 	}
-
 	return nil
 }
 
@@ -84,6 +116,11 @@ func (n *Normalizer) GetPrecision() int {
 	return n.precision
 }
 
+// GetBounds returns the lowest and highest accepted prices
+func (n *Normalizer) GetBounds() (float64, float64) {
+	return n.minPrice, n.maxPrice
+}
+
 // GetMultiplier returns the current multiplier
 func (n *Normalizer) GetMultiplier() float64 {
 	return n.multiplier
@@ -0,0 +1,82 @@
+package pair
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pair is a base/quote asset pair such as ETH/USD
+type Pair struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+}
+
+// ETHUSD is the pair the pipeline served before it supported multiple assets
+var ETHUSD = New("ETH", "USD")
+
+// New creates a pair from base and quote asset symbols
+func New(base, quote string) Pair {
+	return Pair{
+		Base:  strings.ToUpper(strings.TrimSpace(base)),
+		Quote: strings.ToUpper(strings.TrimSpace(quote)),
+	}
+}
+
+// Parse parses a pair written as "ETH/USD", "ETH-USD" or "ETH_USD" (case-insensitive)
+func Parse(s string) (Pair, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '/' || r == '-' || r == '_'
+	})
+	if len(parts) != 2 {
+		return Pair{}, fmt.Errorf("invalid pair %q, expected BASE/QUOTE", s)
+	}
+
+	p := New(parts[0], parts[1])
+	if err := p.Validate(); err != nil {
+		return Pair{}, err
+	}
+	return p, nil
+}
+
+// Validate checks that both assets are set and alphanumeric
+func (p Pair) Validate() error {
+	for _, asset := range []string{p.Base, p.Quote} {
+		if asset == "" {
+			return fmt.Errorf("invalid pair %q: base and quote are required", p.String())
+		}
+		for _, r := range asset {
+			if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+				return fmt.Errorf("invalid pair %q: asset %q must be alphanumeric", p.String(), asset)
+			}
+		}
+	}
+	if p.Base == p.Quote {
+		return fmt.Errorf("invalid pair %q: base and quote must differ", p.String())
+	}
+	return nil
+}
+
+// String returns the pair as "BASE/QUOTE"
+func (p Pair) String() string {
+	return p.Base + "/" + p.Quote
+}
+
+// Key returns the lowercase "base_quote" form used in cache keys
+func (p Pair) Key() string {
+	return strings.ToLower(p.Base + "_" + p.Quote)
+}
+
+// EnvPrefix returns the uppercase "BASE_QUOTE" form used in environment variable names
+func (p Pair) EnvPrefix() string {
+	return p.Base + "_" + p.Quote
+}
+
+// Token returns the lowercase "basequote" form used in NATS subjects
+func (p Pair) Token() string {
+	return strings.ToLower(p.Base + p.Quote)
+}
+
+// IsZero reports whether the pair is unset
+func (p Pair) IsZero() bool {
+	return p.Base == "" && p.Quote == ""
+}
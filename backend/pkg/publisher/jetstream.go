@@ -0,0 +1,127 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultStreamName is the JetStream stream price messages are kept in
+const DefaultStreamName = "PRICES"
+
+// StreamConfig describes the JetStream stream prices are published to
+type StreamConfig struct {
+	Name     string
+	Subjects []string
+	// MaxAge is how long prices are kept for consumers that are down
+	MaxAge time.Duration
+	// Duplicates is the window in which a second message with the same Nats-Msg-Id is dropped
+	Duplicates time.Duration
+	// AckTimeout is how long a publish waits for the stream to acknowledge it
+	AckTimeout time.Duration
+}
+
+// Validate checks if the stream configuration is valid
+func (c StreamConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("stream name is required")
+	}
+	if len(c.Subjects) == 0 {
+		return fmt.Errorf("stream must cover at least one subject")
+	}
+	if c.MaxAge <= 0 {
+		return fmt.Errorf("stream max age must be positive, got: %v", c.MaxAge)
+	}
+	if c.Duplicates <= 0 || c.Duplicates > c.MaxAge {
+		return fmt.Errorf("duplicate window must be positive and not above the max age, got: %v", c.Duplicates)
+	}
+	if c.AckTimeout <= 0 {
+		return fmt.Errorf("ack timeout must be positive, got: %v", c.AckTimeout)
+	}
+	return nil
+}
+
+// EnableJetStream makes the publisher publish to the stream of config and wait for its acknowledgement
+// The stream is created if it does not exist; an existing stream must cover the configured subjects
+// and keep a duplicate window at least as long as configured
+func (p *Publisher) EnableJetStream(ctx context.Context, config StreamConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid stream config: %w", err)
+	}
+
+	js, err := jetstream.New(p.conn)
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	stream, err := js.Stream(ctx, config.Name)
+	switch {
+	case errors.Is(err, jetstream.ErrStreamNotFound):
+		_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:       config.Name,
+			Subjects:   config.Subjects,
+			Storage:    jetstream.FileStorage,
+			MaxAge:     config.MaxAge,
+			Duplicates: config.Duplicates,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create stream %s: %w", config.Name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to look up stream %s: %w", config.Name, err)
+	default:
+		if err := checkStream(stream.CachedInfo().Config, config); err != nil {
+			return fmt.Errorf("stream %s does not match the configuration: %w", config.Name, err)
+		}
+	}
+
+	p.js = js
+	p.stream = config.Name
+	p.ackTimeout = config.AckTimeout
+	return nil
+}
+
+// checkStream compares an existing stream with the configuration it is expected to satisfy
+func checkStream(existing jetstream.StreamConfig, config StreamConfig) error {
+	for _, subject := range config.Subjects {
+		covered := slices.ContainsFunc(existing.Subjects, func(streamSubject string) bool {
+			return wire.SubjectCovers(streamSubject, subject)
+		})
+		if !covered {
+			return fmt.Errorf("subject %s is not covered, the stream has %v", subject, existing.Subjects)
+		}
+	}
+	if existing.Duplicates < config.Duplicates {
+		return fmt.Errorf("duplicate window is %v, below the configured %v", existing.Duplicates, config.Duplicates)
+	}
+	return nil
+}
+
+// JetStreamEnabled reports whether prices are published to a JetStream stream
+func (p *Publisher) JetStreamEnabled() bool {
+	return p.js != nil
+}
+
+// publishJetStream publishes msg to the stream under the message ID id and waits for the ack,
+// at most the ack timeout and never past ctx
+// A message the stream already holds within its duplicate window is acknowledged but not stored again
+func (p *Publisher) publishJetStream(ctx context.Context, msg *nats.Msg, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.ackTimeout)
+	defer cancel()
+
+	opts := []jetstream.PublishOpt{jetstream.WithExpectStream(p.stream)}
+	if id != "" {
+		opts = append(opts, jetstream.WithMsgID(id))
+	}
+	if _, err := p.js.PublishMsg(ctx, msg, opts...); err != nil {
+		return fmt.Errorf("failed to publish price to stream %s: %w", p.stream, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runJetStreamServer starts an embedded NATS server with JetStream and connects to it
+func runJetStreamServer(t *testing.T) *nats.Conn {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(conn.Close)
+	return conn
+}
+
+// testStreamConfig is a stream of every per-pair price subject
+func testStreamConfig() StreamConfig {
+	return StreamConfig{
+		Name:       DefaultStreamName,
+		Subjects:   []string{DefaultSubjectPrefix + ".*"},
+		MaxAge:     time.Hour,
+		Duplicates: 2 * time.Minute,
+		AckTimeout: 5 * time.Second,
+	}
+}
+
+func TestEnableJetStreamAcceptsWildcardStreams(t *testing.T) {
+	tests := []struct {
+		name     string
+		subjects []string
+		wantErr  bool
+	}{
+		{name: "same subject", subjects: []string{"prices.*"}, wantErr: false},
+		{name: "full wildcard", subjects: []string{"prices.>"}, wantErr: false},
+		{name: "one of several", subjects: []string{"other", "prices.>"}, wantErr: false},
+		{name: "single pair only", subjects: []string{"prices.ethusd"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := runJetStreamServer(t)
+			ctx := context.Background()
+
+			js, err := jetstream.New(conn)
+			if err != nil {
+				t.Fatalf("jetstream.New() error = %v", err)
+			}
+			_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+				Name:       DefaultStreamName,
+				Subjects:   tt.subjects,
+				Duplicates: 2 * time.Minute,
+			})
+			if err != nil {
+				t.Fatalf("CreateStream() error = %v", err)
+			}
+
+			err = NewPublisherWithConn(conn, "prices.ethusd").EnableJetStream(ctx, testStreamConfig())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EnableJetStream() against a stream of %v error = %v, want error %v", tt.subjects, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPublishJetStreamDropsDuplicates(t *testing.T) {
+	conn := runJetStreamServer(t)
+	ctx := context.Background()
+
+	publisher := NewPublisherWithConn(conn, "prices.ethusd")
+	if err := publisher.EnableJetStream(ctx, testStreamConfig()); err != nil {
+		t.Fatalf("EnableJetStream() error = %v", err)
+	}
+
+	// The same fetch published twice, e.g. after a timed out ack, carries the same Nats-Msg-Id
+	timestamp := time.Now()
+	for range 2 {
+		if err := publisher.PublishPriceForPair(context.Background(), pair.ETHUSD, 3412.57, timestamp, "aggregated"); err != nil {
+			t.Fatalf("PublishPriceForPair() error = %v", err)
+		}
+	}
+	if err := publisher.PublishPriceForPair(context.Background(), pair.ETHUSD, 3413.01, timestamp.Add(time.Second), "aggregated"); err != nil {
+		t.Fatalf("PublishPriceForPair() error = %v", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatalf("jetstream.New() error = %v", err)
+	}
+	stream, err := js.Stream(ctx, DefaultStreamName)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if info.State.Msgs != 2 {
+		t.Errorf("stream holds %d messages, want 2 with the duplicate dropped", info.State.Msgs)
+	}
+}
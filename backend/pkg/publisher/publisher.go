@@ -1,25 +1,38 @@
 package publisher
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/ed25519"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
-// PriceMessage represents a message published to NATS
-type PriceMessage struct {
-	Price     float64   `json:"price"`
-	Timestamp time.Time `json:"timestamp"`
-	Source    string    `json:"source"`
-	ID        string    `json:"id"`
-}
+// DefaultSubjectPrefix is the subject prefix pair prices are published under, e.g. prices.ethusd
+const DefaultSubjectPrefix = "prices"
+
+// PriceMessage represents a message published to NATS, in the schema shared with the updater
+type PriceMessage = wire.PriceMessage
 
 // Publisher handles publishing price updates to NATS
 type Publisher struct {
-	conn    *nats.Conn
-	subject string
+	conn          *nats.Conn
+	subject       string
+	subjectPrefix string
+	// encoding is the preferred wire encoding, used when the server supports headers
+	encoding wire.Encoding
+	// signingKey signs every message when set
+	signingKey ed25519.PrivateKey
+
+	// js is set once JetStream is enabled, so publishes are stored in stream and acknowledged
+	js         jetstream.JetStream
+	stream     string
+	ackTimeout time.Duration
 }
 
 // NewPublisher creates a new publisher instance
@@ -30,42 +43,138 @@ func NewPublisher(natsURL, subject string) (*Publisher, error) {
 	}
 
 	return &Publisher{
-		conn:    conn,
-		subject: subject,
+		conn:          conn,
+		subject:       subject,
+		subjectPrefix: DefaultSubjectPrefix,
+		encoding:      wire.EncodingJSON,
 	}, nil
 }
 
 // NewPublisherWithConn creates a publisher with an existing NATS connection
 func NewPublisherWithConn(conn *nats.Conn, subject string) *Publisher {
 	return &Publisher{
-		conn:    conn,
-		subject: subject,
+		conn:          conn,
+		subject:       subject,
+		subjectPrefix: DefaultSubjectPrefix,
+		encoding:      wire.EncodingJSON,
 	}
 }
 
 // PublishPrice publishes a normalized ETH/USD price to the NATS topic
-func (p *Publisher) PublishPrice(price float64, timestamp time.Time, source string) error {
+func (p *Publisher) PublishPrice(ctx context.Context, price float64, timestamp time.Time, source string) error {
 	message := PriceMessage{
+		Pair:      pair.ETHUSD.String(),
 		Price:     price,
 		Timestamp: timestamp,
 		Source:    source,
-		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		ID:        messageID(pair.ETHUSD, timestamp),
 	}
 
-	data, err := json.Marshal(message)
+	return p.publish(ctx, p.subject, message)
+}
+
+// PublishPriceForPair publishes a normalized price of pr to the pair's subject
+func (p *Publisher) PublishPriceForPair(ctx context.Context, pr pair.Pair, price float64, timestamp time.Time, source string) error {
+	return p.PublishMessage(ctx, pr, PriceMessage{
+		Price:     price,
+		Timestamp: timestamp,
+		Source:    source,
+	})
+}
+
+// PublishMessage publishes a message about pr to the pair's subject, filling in the pair and ID
+func (p *Publisher) PublishMessage(ctx context.Context, pr pair.Pair, message PriceMessage) error {
+	message.Pair = pr.String()
+	if message.ID == "" {
+		message.ID = messageID(pr, message.Timestamp)
+	}
+
+	return p.publish(ctx, p.SubjectForPair(pr), message)
+}
+
+// messageID identifies the price of pr fetched at timestamp, so publishing it again yields the same ID
+// and JetStream drops the copy
+func messageID(pr pair.Pair, timestamp time.Time) string {
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return fmt.Sprintf("%s-%d", pr.Token(), timestamp.UnixNano())
+}
+
+// publish encodes a message and publishes it to subject, waiting for the stream's ack in JetStream mode
+// until ctx is done or the ack timeout passes
+func (p *Publisher) publish(ctx context.Context, subject string, message PriceMessage) error {
+	msg, err := p.encode(subject, message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal price message: %w", err)
+		return err
 	}
 
-	if err := p.conn.Publish(p.subject, data); err != nil {
+	if p.js != nil {
+		return p.publishJetStream(ctx, msg, message.ID)
+	}
+	if err := p.conn.PublishMsg(msg); err != nil {
 		return fmt.Errorf("failed to publish price: %w", err)
 	}
 
 	return nil
 }
 
+// encode signs message if a signing key is set and builds its NATS message in the negotiated encoding
+// Servers without header support cannot carry the content type, so they only get JSON, which every
+// consumer decodes as legacy JSON
+func (p *Publisher) encode(subject string, message PriceMessage) (*nats.Msg, error) {
+	encoding := p.encoding
+	headers := p.conn.HeadersSupported()
+	if !headers {
+		encoding = wire.EncodingJSON
+	}
+
+	if p.signingKey != nil {
+		wire.Sign(&message, p.signingKey)
+	}
+	data, err := wire.Encode(message, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	if headers {
+		msg.Header.Set(wire.ContentTypeHeader, encoding.ContentType())
+		msg.Header.Set(wire.SchemaVersionHeader, strconv.Itoa(wire.SchemaVersion))
+	}
+	return msg, nil
+}
+
 // PublishPriceWithFilter publishes a price only if it meets certain criteria
-func (p *Publisher) PublishPriceWithFilter(price float64, timestamp time.Time, source string, lastPrice float64, threshold float64) error {
+func (p *Publisher) PublishPriceWithFilter(ctx context.Context, price float64, timestamp time.Time, source string, lastPrice float64, threshold float64) error {
+	if !exceedsThreshold(price, lastPrice, threshold) {
+		return nil // Skip publishing
+	}
+
+	return p.PublishPrice(ctx, price, timestamp, source)
+}
+
+// PublishPriceForPairWithFilter publishes a price of pr only if it moved at least threshold from lastPrice
+func (p *Publisher) PublishPriceForPairWithFilter(ctx context.Context, pr pair.Pair, price float64, timestamp time.Time, source string, lastPrice float64, threshold float64) error {
+	if !exceedsThreshold(price, lastPrice, threshold) {
+		return nil // Skip publishing
+	}
+
+	return p.PublishPriceForPair(ctx, pr, price, timestamp, source)
+}
+
+// PublishMessageWithFilter publishes a message about pr only if its price moved at least threshold from lastPrice
+func (p *Publisher) PublishMessageWithFilter(ctx context.Context, pr pair.Pair, message PriceMessage, lastPrice float64, threshold float64) error {
+	if !exceedsThreshold(message.Price, lastPrice, threshold) {
+		return nil // Skip publishing
+	}
+
+	return p.PublishMessage(ctx, pr, message)
+}
+
+// exceedsThreshold reports whether price moved at least threshold from lastPrice; with no last price it always does
+func exceedsThreshold(price, lastPrice, threshold float64) bool {
 	// Calculate percentage change
 	if lastPrice > 0 {
 		change := (price - lastPrice) / lastPrice
@@ -75,45 +184,17 @@ func (p *Publisher) PublishPriceWithFilter(price float64, timestamp time.Time, s
 
 		// Only publish if change is above threshold
 		if change < threshold {
-			return nil // Skip publishing
+			return false
 		}
 	}
 
-	return p.PublishPrice(price, timestamp, source)
-}
-
-// PublishPriceAsync publishes a price asynchronously
-func (p *Publisher) PublishPriceAsync(price float64, timestamp time.Time, source string) error {
-	message := PriceMessage{
-		Price:     price,
-		Timestamp: timestamp,
-		Source:    source,
-		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
-	}
-
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal price message: %w", err)
-	}
-
-	// Use Publish for async operation (NATS is inherently async)
-	err = p.conn.Publish(p.subject, data)
-	if err != nil {
-		return fmt.Errorf("failed to publish price async: %w", err)
-	}
-
-	return nil
+	return true
 }
 
 // PublishBatch publishes multiple prices in a batch
-func (p *Publisher) PublishBatch(prices []PriceMessage) error {
+func (p *Publisher) PublishBatch(ctx context.Context, prices []PriceMessage) error {
 	for _, message := range prices {
-		data, err := json.Marshal(message)
-		if err != nil {
-			return fmt.Errorf("failed to marshal price message: %w", err)
-		}
-
-		if err := p.conn.Publish(p.subject, data); err != nil {
+		if err := p.publish(ctx, p.subject, message); err != nil {
 			return fmt.Errorf("failed to publish price in batch: %w", err)
 		}
 	}
@@ -136,6 +217,32 @@ func (p *Publisher) GetSubject() string {
 	return p.subject
 }
 
+// SubjectForPair returns the subject prices of pr are published to, e.g. prices.ethusd
+func (p *Publisher) SubjectForPair(pr pair.Pair) string {
+	return p.subjectPrefix + "." + pr.Token()
+}
+
+// SetSubjectPrefix changes the prefix of the per-pair subjects
+func (p *Publisher) SetSubjectPrefix(prefix string) {
+	p.subjectPrefix = prefix
+}
+
+// SetEncoding sets the wire encoding prices are published in when the server supports headers
+func (p *Publisher) SetEncoding(encoding wire.Encoding) error {
+	parsed, err := wire.ParseEncoding(string(encoding))
+	if err != nil {
+		return err
+	}
+
+	p.encoding = parsed
+	return nil
+}
+
+// SetSigningKey makes the publisher sign every message with key, so consumers can check where it came from
+func (p *Publisher) SetSigningKey(key ed25519.PrivateKey) {
+	p.signingKey = key
+}
+
 // SetSubject changes the subject for publishing
 func (p *Publisher) SetSubject(subject string) {
 	p.subject = subject
@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reason explains why the scheduler chose its current interval
+type Reason string
+
+const (
+	// ReasonHighVolatility shortens the interval because prices are moving fast
+	ReasonHighVolatility Reason = "high_volatility"
+	// ReasonLowVolatility lengthens the interval because prices are calm
+	ReasonLowVolatility Reason = "low_volatility"
+	// ReasonNormalVolatility moves the interval back towards the base interval
+	ReasonNormalVolatility Reason = "normal_volatility"
+	// ReasonInsufficientData returns to the base interval when volatility cannot be measured
+	ReasonInsufficientData Reason = "insufficient_data"
+)
+
+// Config bounds the interval of an adaptive scheduler and sets its volatility thresholds
+// Volatility is measured as the standard deviation of log returns per square root of a minute
+type Config struct {
+	// Base is the interval used in normal markets and before volatility can be measured
+	Base time.Duration
+	Min  time.Duration
+	Max  time.Duration
+	// HighVolatility halves the interval at or above it and LowVolatility doubles it at or below it
+	HighVolatility float64
+	LowVolatility  float64
+}
+
+// Validate checks that the interval bounds and thresholds are consistent
+func (c Config) Validate() error {
+	if c.Min <= 0 {
+		return fmt.Errorf("min interval must be positive, got: %v", c.Min)
+	}
+	if c.Base < c.Min || c.Base > c.Max {
+		return fmt.Errorf("base interval %v must be between the min %v and max %v", c.Base, c.Min, c.Max)
+	}
+	if c.LowVolatility < 0 || c.HighVolatility <= c.LowVolatility {
+		return fmt.Errorf("low volatility must not be negative and must be below high volatility")
+	}
+	return nil
+}
+
+// Scheduler adapts the fetch interval to realised volatility
+// Each adjustment moves the interval by at most a factor of two, so one noisy reading
+// cannot swing it from one bound to the other
+type Scheduler struct {
+	config Config
+
+	mu       sync.Mutex
+	interval time.Duration
+	reason   Reason
+}
+
+// NewScheduler creates a scheduler starting at the base interval
+func NewScheduler(config Config) (*Scheduler, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scheduler config: %w", err)
+	}
+
+	return &Scheduler{
+		config:   config,
+		interval: config.Base,
+		reason:   ReasonInsufficientData,
+	}, nil
+}
+
+// Interval returns the current interval and the reason it was chosen
+func (s *Scheduler) Interval() (time.Duration, Reason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.interval, s.reason
+}
+
+// Adjust picks the next interval from the latest volatility, where ok is false when it could not be measured
+// It returns the new interval, the reason for it and whether the interval changed
+func (s *Scheduler) Adjust(volatility float64, ok bool) (time.Duration, Reason, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	interval := s.interval
+	var reason Reason
+	switch {
+	case !ok:
+		interval, reason = s.config.Base, ReasonInsufficientData
+	case volatility >= s.config.HighVolatility:
+		interval, reason = interval/2, ReasonHighVolatility
+	case volatility <= s.config.LowVolatility:
+		interval, reason = interval*2, ReasonLowVolatility
+	default:
+		interval, reason = s.towardsBase(interval), ReasonNormalVolatility
+	}
+	interval = s.clamp(interval)
+
+	changed := interval != s.interval
+	s.interval = interval
+	s.reason = reason
+	return interval, reason, changed
+}
+
+// towardsBase moves interval one step back towards the base interval without overshooting it
+func (s *Scheduler) towardsBase(interval time.Duration) time.Duration {
+	switch {
+	case interval < s.config.Base:
+		return min(interval*2, s.config.Base)
+	case interval > s.config.Base:
+		return max(interval/2, s.config.Base)
+	}
+	return interval
+}
+
+// clamp keeps interval within the configured bounds
+func (s *Scheduler) clamp(interval time.Duration) time.Duration {
+	return min(max(interval, s.config.Min), s.config.Max)
+}
+
+// Observation is a price at a point in time
+type Observation struct {
+	Price float64
+	Time  time.Time
+}
+
+// RealisedVolatility returns the standard deviation of log returns between consecutive observations,
+// scaled to one minute so that returns over different intervals are comparable
+// Observations may be given in any order; ok is false when fewer than two returns can be computed
+func RealisedVolatility(observations []Observation) (float64, bool) {
+	sorted := make([]Observation, 0, len(observations))
+	for _, observation := range observations {
+		if observation.Price > 0 && !observation.Time.IsZero() {
+			sorted = append(sorted, observation)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var sum float64
+	var returns int
+	for i := 1; i < len(sorted); i++ {
+		minutes := sorted[i].Time.Sub(sorted[i-1].Time).Minutes()
+		if minutes <= 0 {
+			continue
+		}
+		logReturn := math.Log(sorted[i].Price / sorted[i-1].Price)
+		sum += logReturn * logReturn / minutes
+		returns++
+	}
+	if returns < 2 {
+		return 0, false
+	}
+
+	// Realised volatility assumes a zero mean return, which holds over short horizons
+	return math.Sqrt(sum / float64(returns)), true
+}
@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testConfig adapts between 5s and 2m around a 30s base
+func testConfig() Config {
+	return Config{
+		Base:           30 * time.Second,
+		Min:            5 * time.Second,
+		Max:            2 * time.Minute,
+		HighVolatility: 0.01,
+		LowVolatility:  0.001,
+	}
+}
+
+func TestSchedulerAdjust(t *testing.T) {
+	type step struct {
+		volatility float64
+		ok         bool
+		interval   time.Duration
+		reason     Reason
+		changed    bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "halves down to the min",
+			steps: []step{
+				{volatility: 0.02, ok: true, interval: 15 * time.Second, reason: ReasonHighVolatility, changed: true},
+				{volatility: 0.01, ok: true, interval: 7500 * time.Millisecond, reason: ReasonHighVolatility, changed: true},
+				{volatility: 0.05, ok: true, interval: 5 * time.Second, reason: ReasonHighVolatility, changed: true},
+				{volatility: 0.05, ok: true, interval: 5 * time.Second, reason: ReasonHighVolatility, changed: false},
+			},
+		},
+		{
+			name: "doubles up to the max",
+			steps: []step{
+				{volatility: 0.0005, ok: true, interval: time.Minute, reason: ReasonLowVolatility, changed: true},
+				{volatility: 0.001, ok: true, interval: 2 * time.Minute, reason: ReasonLowVolatility, changed: true},
+				{volatility: 0, ok: true, interval: 2 * time.Minute, reason: ReasonLowVolatility, changed: false},
+			},
+		},
+		{
+			name: "steps back to the base from below",
+			steps: []step{
+				{volatility: 0.02, ok: true, interval: 15 * time.Second, reason: ReasonHighVolatility, changed: true},
+				{volatility: 0.02, ok: true, interval: 7500 * time.Millisecond, reason: ReasonHighVolatility, changed: true},
+				{volatility: 0.005, ok: true, interval: 15 * time.Second, reason: ReasonNormalVolatility, changed: true},
+				{volatility: 0.005, ok: true, interval: 30 * time.Second, reason: ReasonNormalVolatility, changed: true},
+				{volatility: 0.005, ok: true, interval: 30 * time.Second, reason: ReasonNormalVolatility, changed: false},
+			},
+		},
+		{
+			name: "steps back to the base from above",
+			steps: []step{
+				{volatility: 0.0001, ok: true, interval: time.Minute, reason: ReasonLowVolatility, changed: true},
+				{volatility: 0.0001, ok: true, interval: 2 * time.Minute, reason: ReasonLowVolatility, changed: true},
+				{volatility: 0.005, ok: true, interval: time.Minute, reason: ReasonNormalVolatility, changed: true},
+				{volatility: 0.005, ok: true, interval: 30 * time.Second, reason: ReasonNormalVolatility, changed: true},
+			},
+		},
+		{
+			name: "insufficient data resets to the base",
+			steps: []step{
+				{volatility: 0.02, ok: true, interval: 15 * time.Second, reason: ReasonHighVolatility, changed: true},
+				{volatility: 0.02, ok: true, interval: 7500 * time.Millisecond, reason: ReasonHighVolatility, changed: true},
+				{ok: false, interval: 30 * time.Second, reason: ReasonInsufficientData, changed: true},
+				{ok: false, interval: 30 * time.Second, reason: ReasonInsufficientData, changed: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScheduler(testConfig())
+			if err != nil {
+				t.Fatalf("NewScheduler() error = %v", err)
+			}
+			if interval, reason := s.Interval(); interval != 30*time.Second || reason != ReasonInsufficientData {
+				t.Fatalf("Interval() = %v, %s, want the base until volatility is measured", interval, reason)
+			}
+
+			for i, step := range tt.steps {
+				interval, reason, changed := s.Adjust(step.volatility, step.ok)
+				if interval != step.interval || reason != step.reason || changed != step.changed {
+					t.Errorf("step %d: Adjust(%v, %v) = %v, %s, %v, want %v, %s, %v",
+						i+1, step.volatility, step.ok, interval, reason, changed, step.interval, step.reason, step.changed)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{name: "zero min", modify: func(c *Config) { c.Min = 0 }},
+		{name: "base below min", modify: func(c *Config) { c.Base = time.Second }},
+		{name: "base above max", modify: func(c *Config) { c.Base = time.Hour }},
+		{name: "negative low volatility", modify: func(c *Config) { c.LowVolatility = -0.1 }},
+		{name: "high volatility not above low", modify: func(c *Config) { c.HighVolatility = c.LowVolatility }},
+	}
+
+	if err := testConfig().Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.modify(&config)
+			if err := config.Validate(); err == nil {
+				t.Error("Validate() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestRealisedVolatility(t *testing.T) {
+	start := time.Date(2024, 6, 10, 16, 0, 0, 0, time.UTC)
+	at := func(minutes float64) time.Time {
+		return start.Add(time.Duration(minutes * float64(time.Minute)))
+	}
+
+	tests := []struct {
+		name         string
+		observations []Observation
+		volatility   float64
+		ok           bool
+	}{
+		{
+			name: "regular minutes",
+			observations: []Observation{
+				{Price: 100, Time: at(0)},
+				{Price: 100 * math.Exp(0.01), Time: at(1)},
+				{Price: 100, Time: at(2)},
+			},
+			volatility: 0.01,
+			ok:         true,
+		},
+		{
+			// A 2% move over 4 minutes has the same per-minute variance as a 1% move over one
+			name: "irregular gaps",
+			observations: []Observation{
+				{Price: 100, Time: at(0)},
+				{Price: 100 * math.Exp(0.01), Time: at(1)},
+				{Price: 100 * math.Exp(0.03), Time: at(5)},
+			},
+			volatility: 0.01,
+			ok:         true,
+		},
+		{
+			name: "sub-minute gaps",
+			observations: []Observation{
+				{Price: 100, Time: at(0)},
+				{Price: 100 * math.Exp(0.005), Time: at(0.25)},
+				{Price: 100, Time: at(0.5)},
+			},
+			volatility: 0.01,
+			ok:         true,
+		},
+		{
+			name: "out of order",
+			observations: []Observation{
+				{Price: 100 * math.Exp(0.03), Time: at(5)},
+				{Price: 100, Time: at(0)},
+				{Price: 100 * math.Exp(0.01), Time: at(1)},
+			},
+			volatility: 0.01,
+			ok:         true,
+		},
+		{
+			name: "unusable observations are skipped",
+			observations: []Observation{
+				{Price: 100, Time: at(0)},
+				{Price: 0, Time: at(0.5)},
+				{Price: 100 * math.Exp(0.01), Time: at(1)},
+				{Price: 120},
+				{Price: 100 * math.Exp(0.01), Time: at(1)},
+				{Price: 100, Time: at(2)},
+			},
+			volatility: 0.01,
+			ok:         true,
+		},
+		{
+			name:         "one return",
+			observations: []Observation{{Price: 100, Time: at(0)}, {Price: 101, Time: at(1)}},
+			ok:           false,
+		},
+		{
+			name:         "no observations",
+			observations: nil,
+			ok:           false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volatility, ok := RealisedVolatility(tt.observations)
+			if ok != tt.ok || math.Abs(volatility-tt.volatility) > 1e-12 {
+				t.Errorf("RealisedVolatility() = %v, %v, want %v, %v", volatility, ok, tt.volatility, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RawResponse is an archived upstream response of one source in one fetch round
+// Responses of rounds that produced a price are linked to its record; the others are kept unlinked
+type RawResponse struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	PriceRecordID *uint      `gorm:"index" json:"price_record_id,omitempty"`
+	Pair          string     `gorm:"size:20;not null" json:"pair"`
+	Source        string     `gorm:"size:100;not null" json:"source"`
+	FetchedAt     time.Time  `gorm:"not null;index" json:"fetched_at"`
+	ObservedAt    *time.Time `json:"observed_at,omitempty"`
+	LatencyMS     float64    `json:"latency_ms"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	Price         float64    `json:"price,omitempty"`
+	Error         string     `gorm:"type:text" json:"error,omitempty"`
+	ErrorClass    string     `gorm:"size:50" json:"error_class,omitempty"`
+	// Discarded is the reason aggregation left the quote out, empty when it was used
+	Discarded string `gorm:"size:50" json:"discarded,omitempty"`
+	// Headers holds the response headers as JSON and Body the gzip-compressed response body
+	Headers   string    `gorm:"type:text" json:"-"`
+	Body      []byte    `gorm:"type:bytea" json:"-"`
+	BodySize  int       `json:"body_size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DerivationLeg links a derived price record to the leg prices it was computed from
+type DerivationLeg struct {
+	ID            uint `gorm:"primaryKey" json:"id"`
+	PriceRecordID uint `gorm:"not null;index" json:"price_record_id"`
+	// LegRecordID is the record of the leg price, nil if the leg price was not stored
+	LegRecordID *uint     `json:"leg_record_id,omitempty"`
+	Pair        string    `gorm:"size:20;not null" json:"pair"`
+	Price       float64   `gorm:"not null;type:decimal(20,8)" json:"price"`
+	ObservedAt  time.Time `json:"observed_at"`
+}
+
+// excludedHeaders are response headers that are not archived
+var excludedHeaders = []string{"Set-Cookie"}
+
+// SetHeaders stores the response headers, leaving out cookies
+func (r *RawResponse) SetHeaders(header http.Header) error {
+	if len(header) == 0 {
+		return nil
+	}
+
+	header = header.Clone()
+	for _, name := range excludedHeaders {
+		header.Del(name)
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+	r.Headers = string(encoded)
+	return nil
+}
+
+// Header returns the archived response headers
+func (r *RawResponse) Header() (http.Header, error) {
+	if r.Headers == "" {
+		return nil, nil
+	}
+
+	var header http.Header
+	if err := json.Unmarshal([]byte(r.Headers), &header); err != nil {
+		return nil, fmt.Errorf("failed to decode headers: %w", err)
+	}
+	return header, nil
+}
+
+// SetBody compresses and stores the response body
+func (r *RawResponse) SetBody(body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return fmt.Errorf("failed to compress body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress body: %w", err)
+	}
+
+	r.Body = buf.Bytes()
+	r.BodySize = len(body)
+	return nil
+}
+
+// RawBody returns the decompressed response body
+func (r *RawResponse) RawBody() ([]byte, error) {
+	if len(r.Body) == 0 {
+		return nil, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress body: %w", err)
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress body: %w", err)
+	}
+	return body, nil
+}
+
+// SavePriceRecordWithInputs stores a price record together with the responses and leg prices it was
+// computed from in one transaction, linking them to the record
+func (s *Storage) SavePriceRecordWithInputs(ctx context.Context, record *PriceRecord, responses []RawResponse, legs []DerivationLeg) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		for i := range responses {
+			responses[i].PriceRecordID = &record.ID
+		}
+		if len(responses) > 0 {
+			if err := tx.Create(&responses).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range legs {
+			legs[i].PriceRecordID = record.ID
+		}
+		if len(legs) > 0 {
+			if err := tx.Create(&legs).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save price: %w", err)
+	}
+
+	return nil
+}
+
+// SaveRawResponses archives the responses of a fetch round that produced no price
+func (s *Storage) SaveRawResponses(ctx context.Context, responses []RawResponse) error {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	if err := s.db.WithContext(ctx).Create(&responses).Error; err != nil {
+		return fmt.Errorf("failed to save raw responses: %w", err)
+	}
+
+	return nil
+}
+
+// GetPriceRecord retrieves a price record by ID
+func (s *Storage) GetPriceRecord(id uint) (*PriceRecord, error) {
+	var record PriceRecord
+
+	if err := s.db.First(&record, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("price record %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get price record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// GetRawResponses retrieves the responses archived with a price record, ordered by source
+func (s *Storage) GetRawResponses(recordID uint) ([]RawResponse, error) {
+	var responses []RawResponse
+
+	if err := s.db.Where("price_record_id = ?", recordID).Order("source ASC").Find(&responses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get raw responses: %w", err)
+	}
+
+	return responses, nil
+}
+
+// GetDerivationLegs retrieves the leg prices a derived price record was computed from
+func (s *Storage) GetDerivationLegs(recordID uint) ([]DerivationLeg, error) {
+	var legs []DerivationLeg
+
+	if err := s.db.Where("price_record_id = ?", recordID).Order("id ASC").Find(&legs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get derivation legs: %w", err)
+	}
+
+	return legs, nil
+}
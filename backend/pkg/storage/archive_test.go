@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestStorage returns a storage backed by a fresh in-memory SQLite database
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database handle: %v", err)
+	}
+	// Every connection to :memory: opens its own database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&PriceRecord{}, &RawResponse{}, &DerivationLeg{}, &JumpEvent{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return NewStorageWithDB(db)
+}
+
+// rawResponse returns an archived response of source with a body and headers
+func rawResponse(t *testing.T, source string, price float64, fetchedAt time.Time) RawResponse {
+	t.Helper()
+
+	response := RawResponse{Pair: "ETH/USD", Source: source, FetchedAt: fetchedAt, LatencyMS: 42, StatusCode: 200, Price: price}
+	if err := response.SetHeaders(http.Header{"Content-Type": {"application/json"}, "Set-Cookie": {"session=secret"}}); err != nil {
+		t.Fatalf("SetHeaders() error = %v", err)
+	}
+	if err := response.SetBody([]byte(`{"ethereum":{"usd":3412.57}}`)); err != nil {
+		t.Fatalf("SetBody() error = %v", err)
+	}
+	return response
+}
+
+func TestSavePriceRecordWithInputsLinksResponses(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	record := &PriceRecord{Pair: "ETH/USD", Price: 3412.57, Timestamp: now, Source: "aggregated"}
+	responses := []RawResponse{
+		rawResponse(t, "kraken", 3412.58, now),
+		rawResponse(t, "coingecko", 3412.57, now),
+	}
+	if err := s.SavePriceRecordWithInputs(ctx, record, responses, nil); err != nil {
+		t.Fatalf("SavePriceRecordWithInputs() error = %v", err)
+	}
+	if record.ID == 0 {
+		t.Fatal("SavePriceRecordWithInputs() left the record without an ID")
+	}
+
+	// Responses of a round without a price are archived unlinked
+	if err := s.SaveRawResponses(ctx, []RawResponse{rawResponse(t, "binance", 0, now)}); err != nil {
+		t.Fatalf("SaveRawResponses() error = %v", err)
+	}
+
+	linked, err := s.GetRawResponses(record.ID)
+	if err != nil {
+		t.Fatalf("GetRawResponses() error = %v", err)
+	}
+	if len(linked) != 2 || linked[0].Source != "coingecko" || linked[1].Source != "kraken" {
+		t.Fatalf("GetRawResponses() = %+v, want coingecko and kraken", linked)
+	}
+	for _, response := range linked {
+		if response.PriceRecordID == nil || *response.PriceRecordID != record.ID {
+			t.Errorf("%s response linked to %v, want record %d", response.Source, response.PriceRecordID, record.ID)
+		}
+	}
+
+	body, err := linked[0].RawBody()
+	if err != nil || string(body) != `{"ethereum":{"usd":3412.57}}` {
+		t.Errorf("RawBody() = %s, %v, want the archived body", body, err)
+	}
+	if linked[0].BodySize != len(body) {
+		t.Errorf("BodySize = %d, want %d", linked[0].BodySize, len(body))
+	}
+	header, err := linked[0].Header()
+	if err != nil {
+		t.Fatalf("Header() error = %v", err)
+	}
+	if header.Get("Content-Type") != "application/json" || header.Get("Set-Cookie") != "" {
+		t.Errorf("Header() = %v, want the content type without cookies", header)
+	}
+}
+
+func TestSavePriceRecordWithInputsLinksLegs(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	ethUSD := &PriceRecord{Pair: "ETH/USD", Price: 3000, Timestamp: now, Source: "aggregated"}
+	eurUSD := &PriceRecord{Pair: "EUR/USD", Price: 1.08, Timestamp: now, Source: "aggregated"}
+	for _, record := range []*PriceRecord{ethUSD, eurUSD} {
+		if err := s.SavePriceRecordWithInputs(ctx, record, nil, nil); err != nil {
+			t.Fatalf("SavePriceRecordWithInputs() error = %v", err)
+		}
+	}
+
+	derived := &PriceRecord{Pair: "ETH/EUR", Price: 2777.77777778, Timestamp: now, Source: "derived:ETH/USD,EUR/USD", Derived: true}
+	legs := []DerivationLeg{
+		{LegRecordID: &ethUSD.ID, Pair: "ETH/USD", Price: 3000, ObservedAt: now},
+		{LegRecordID: &eurUSD.ID, Pair: "EUR/USD", Price: 1.08, ObservedAt: now},
+	}
+	if err := s.SavePriceRecordWithInputs(ctx, derived, nil, legs); err != nil {
+		t.Fatalf("SavePriceRecordWithInputs() error = %v", err)
+	}
+
+	stored, err := s.GetDerivationLegs(derived.ID)
+	if err != nil {
+		t.Fatalf("GetDerivationLegs() error = %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("GetDerivationLegs() = %+v, want two legs", stored)
+	}
+	for i, want := range []*PriceRecord{ethUSD, eurUSD} {
+		leg := stored[i]
+		if leg.PriceRecordID != derived.ID || leg.LegRecordID == nil || *leg.LegRecordID != want.ID || leg.Pair != want.Pair {
+			t.Errorf("leg %d = %+v, want %s record %d of derived record %d", i, leg, want.Pair, want.ID, derived.ID)
+			continue
+		}
+		// The leg record resolves to the price the derivation used
+		record, err := s.GetPriceRecord(*leg.LegRecordID)
+		if err != nil || record.Price != leg.Price {
+			t.Errorf("GetPriceRecord(%d) = %+v, %v, want the %s price %v", *leg.LegRecordID, record, err, leg.Pair, leg.Price)
+		}
+	}
+
+	// A derived price has no responses of its own
+	if responses, err := s.GetRawResponses(derived.ID); err != nil || len(responses) != 0 {
+		t.Errorf("GetRawResponses() of a derived record = %+v, %v, want none", responses, err)
+	}
+}
+
+func TestGetPriceRecordNotFound(t *testing.T) {
+	if _, err := newTestStorage(t).GetPriceRecord(99); err == nil {
+		t.Error("GetPriceRecord() of a missing record succeeded")
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+)
+
+// JumpEvent records a large price move held, confirmed or discarded by a pair's jump guard
+type JumpEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Pair      string    `gorm:"size:20;not null;index:idx_jump_events_pair_timestamp,priority:1" json:"pair"`
+	Kind      string    `gorm:"size:20;not null" json:"kind"`
+	Price     float64   `gorm:"not null;type:decimal(20,8)" json:"price"`
+	Timestamp time.Time `gorm:"not null;index:idx_jump_events_pair_timestamp,priority:2" json:"timestamp"`
+	// ReferencePrice is the last published price the move is measured from, and Change the relative move
+	ReferencePrice float64 `gorm:"type:decimal(20,8)" json:"reference_price"`
+	Change         float64 `json:"change"`
+	// Fetches and Sources are the consecutive fetches and the sources of the latest fetch that repeated the move
+	Fetches     int       `json:"fetches"`
+	Sources     int       `json:"sources"`
+	ConfirmedBy string    `gorm:"size:20" json:"confirmed_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SaveJumpEvents stores jump guard events
+func (s *Storage) SaveJumpEvents(ctx context.Context, events []JumpEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.db.WithContext(ctx).Create(&events).Error; err != nil {
+		return fmt.Errorf("failed to save jump events: %w", err)
+	}
+
+	return nil
+}
+
+// GetJumpEvents retrieves the most recent jump guard events of p
+func (s *Storage) GetJumpEvents(p pair.Pair, limit int) ([]JumpEvent, error) {
+	var events []JumpEvent
+
+	if err := s.db.Where("pair = ?", p.String()).Order("timestamp DESC, id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get jump events: %w", err)
+	}
+
+	return events, nil
+}
@@ -1,22 +1,36 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 // PriceRecord represents a price record in the database
+// Pair is stored as "BASE/QUOTE"; rows written before pairs existed default to ETH/USD
+// Derived marks cross rates computed from other pairs rather than fetched from sources
 type PriceRecord struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	Pair      string    `gorm:"size:20;not null;default:'ETH/USD';index:idx_price_records_pair_timestamp,priority:1" json:"pair"`
 	Price     float64   `gorm:"not null;type:decimal(20,8)" json:"price"`
-	Timestamp time.Time `gorm:"not null;index" json:"timestamp"`
+	Timestamp time.Time `gorm:"not null;index;index:idx_price_records_pair_timestamp,priority:2" json:"timestamp"`
 	Source    string    `gorm:"size:100" json:"source"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Derived   bool      `gorm:"not null;default:false" json:"derived"`
+	// ObservedAt is when the providers observed the price, while Timestamp is when it was fetched
+	// It is nil for records stored before observation times were kept
+	ObservedAt *time.Time `gorm:"index" json:"observed_at,omitempty"`
+	// ConfidenceLower and ConfidenceUpper bound the price and StdError is the standard error of its
+	// quotes; they are nil for records stored before confidence bands were kept
+	ConfidenceLower *float64  `gorm:"type:decimal(20,8)" json:"confidence_lower,omitempty"`
+	ConfidenceUpper *float64  `gorm:"type:decimal(20,8)" json:"confidence_upper,omitempty"`
+	StdError        *float64  `json:"std_error,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // Storage handles database operations for price persistence
@@ -34,7 +48,7 @@ func NewStorage(dsn string) (*Storage, error) {
 	}
 
 	// Auto-migrate the schema
-	if err := db.AutoMigrate(&PriceRecord{}); err != nil {
+	if err := db.AutoMigrate(&PriceRecord{}, &RawResponse{}, &DerivationLeg{}, &JumpEvent{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
@@ -48,37 +62,61 @@ func NewStorageWithDB(db *gorm.DB) *Storage {
 
 // SavePrice stores a price record in the SQL database
 func (s *Storage) SavePrice(price float64, timestamp time.Time, source string) error {
-	record := PriceRecord{
+	return s.SavePriceWithContext(context.Background(), price, timestamp, source)
+}
+
+// SavePriceWithContext stores an ETH/USD price record in the SQL database, giving up when ctx is done
+func (s *Storage) SavePriceWithContext(ctx context.Context, price float64, timestamp time.Time, source string) error {
+	return s.SavePriceForPair(ctx, pair.ETHUSD, price, timestamp, source)
+}
+
+// SavePriceForPair stores a price record of p in the SQL database, giving up when ctx is done
+func (s *Storage) SavePriceForPair(ctx context.Context, p pair.Pair, price float64, timestamp time.Time, source string) error {
+	return s.SavePriceRecord(ctx, &PriceRecord{
+		Pair:      p.String(),
 		Price:     price,
 		Timestamp: timestamp,
 		Source:    source,
-	}
+	})
+}
 
-	if err := s.db.Create(&record).Error; err != nil {
+// SavePriceRecord stores a fully populated price record in the SQL database, giving up when ctx is done
+func (s *Storage) SavePriceRecord(ctx context.Context, record *PriceRecord) error {
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
 		return fmt.Errorf("failed to save price: %w", err)
 	}
 
 	return nil
 }
 
-// GetPriceHistory retrieves recent price records for computing TWAP
+// GetPriceHistory retrieves recent ETH/USD price records for computing TWAP
 func (s *Storage) GetPriceHistory(limit int) ([]PriceRecord, error) {
+	return s.GetPriceHistoryForPair(pair.ETHUSD, limit)
+}
+
+// GetPriceHistoryForPair retrieves recent price records of p
+func (s *Storage) GetPriceHistoryForPair(p pair.Pair, limit int) ([]PriceRecord, error) {
 	var records []PriceRecord
 
-	if err := s.db.Order("timestamp DESC").Limit(limit).Find(&records).Error; err != nil {
+	if err := s.db.Where("pair = ?", p.String()).Order("timestamp DESC").Limit(limit).Find(&records).Error; err != nil {
 		return nil, fmt.Errorf("failed to get price history: %w", err)
 	}
 
 	return records, nil
 }
 
-// GetLatestPrice retrieves the most recent price record
+// GetLatestPrice retrieves the most recent ETH/USD price record
 func (s *Storage) GetLatestPrice() (*PriceRecord, error) {
+	return s.GetLatestPriceForPair(pair.ETHUSD)
+}
+
+// GetLatestPriceForPair retrieves the most recent price record of p
+func (s *Storage) GetLatestPriceForPair(p pair.Pair) (*PriceRecord, error) {
 	var record PriceRecord
 
-	if err := s.db.Order("timestamp DESC").First(&record).Error; err != nil {
+	if err := s.db.Where("pair = ?", p.String()).Order("timestamp DESC").First(&record).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("no price records found")
+			return nil, fmt.Errorf("no price records found for %s", p)
 		}
 		return nil, fmt.Errorf("failed to get latest price: %w", err)
 	}
@@ -86,11 +124,16 @@ func (s *Storage) GetLatestPrice() (*PriceRecord, error) {
 	return &record, nil
 }
 
-// GetPricesInRange retrieves prices within a specific time range
+// GetPricesInRange retrieves ETH/USD prices within a specific time range
 func (s *Storage) GetPricesInRange(start, end time.Time) ([]PriceRecord, error) {
+	return s.GetPricesInRangeForPair(pair.ETHUSD, start, end)
+}
+
+// GetPricesInRangeForPair retrieves prices of p within a specific time range
+func (s *Storage) GetPricesInRangeForPair(p pair.Pair, start, end time.Time) ([]PriceRecord, error) {
 	var records []PriceRecord
 
-	if err := s.db.Where("timestamp BETWEEN ? AND ?", start, end).
+	if err := s.db.Where("pair = ? AND timestamp BETWEEN ? AND ?", p.String(), start, end).
 		Order("timestamp ASC").
 		Find(&records).Error; err != nil {
 		return nil, fmt.Errorf("failed to get prices in range: %w", err)
@@ -99,12 +142,17 @@ func (s *Storage) GetPricesInRange(start, end time.Time) ([]PriceRecord, error)
 	return records, nil
 }
 
-// CalculateTWAP calculates the Time-Weighted Average Price for a given period
+// CalculateTWAP calculates the ETH/USD Time-Weighted Average Price for a given period
 func (s *Storage) CalculateTWAP(duration time.Duration) (float64, error) {
+	return s.CalculateTWAPForPair(pair.ETHUSD, duration)
+}
+
+// CalculateTWAPForPair calculates the Time-Weighted Average Price of p for a given period
+func (s *Storage) CalculateTWAPForPair(p pair.Pair, duration time.Duration) (float64, error) {
 	end := time.Now()
 	start := end.Add(-duration)
 
-	records, err := s.GetPricesInRange(start, end)
+	records, err := s.GetPricesInRangeForPair(p, start, end)
 	if err != nil {
 		return 0, err
 	}
@@ -153,11 +201,25 @@ func (s *Storage) GetPriceCount() (int64, error) {
 	return count, nil
 }
 
-// DeleteOldRecords deletes price records older than the specified duration
+// DeleteOldRecords deletes price records older than the specified duration, along with their archived inputs
+// and jump guard events
 func (s *Storage) DeleteOldRecords(olderThan time.Duration) error {
 	cutoff := time.Now().Add(-olderThan)
 
-	if err := s.db.Where("timestamp < ?", cutoff).Delete(&PriceRecord{}).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		oldRecords := tx.Model(&PriceRecord{}).Select("id").Where("timestamp < ?", cutoff)
+		if err := tx.Where("price_record_id IN (?)", oldRecords).Delete(&DerivationLeg{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("fetched_at < ?", cutoff).Delete(&RawResponse{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("timestamp < ?", cutoff).Delete(&JumpEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("timestamp < ?", cutoff).Delete(&PriceRecord{}).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete old records: %w", err)
 	}
 
@@ -4,9 +4,94 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/pair"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/publisher"
+	"github.com/114windd/DeFiOraclePipeline.git/backend/pkg/validation"
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
 )
 
+// SourceConfig describes one configured upstream price source
+type SourceConfig struct {
+	// Name identifies the source in logs and metrics
+	Name string
+	// Kind selects the adapter, e.g. "coingecko" or "binance"
+	Kind string
+	// URL overrides the adapter's default endpoint template when set
+	URL string
+	// Weight is the source's weight in weighted median aggregation
+	Weight float64
+	// RequestsPerMinute caps the request rate; zero uses the adapter default and a negative value disables the cap
+	RequestsPerMinute int
+	// Params holds adapter-specific settings keyed by lowercase setting name, e.g. "pool"
+	Params map[string]string
+	// QuoteAsset overrides the asset the venue quotes the pair in, e.g. "USDC"; empty uses the adapter default
+	QuoteAsset string
+}
+
+// sourceParamSettings are the adapter-specific per-source settings, e.g. SOURCE_<NAME>_POOL
+var sourceParamSettings = []string{
+	"POOL", "TWAP_WINDOW", "FEED", "MAX_AGE", "FALLBACK",
+	"MODEL", "SEED", "START_PRICE", "VOLATILITY", "DRIFT", "STEP", "NOISE",
+	"JUMP_PROBABILITY", "JUMP_SIZE", "CRASH_AFTER", "CRASH_DEPTH", "CRASH_RECOVERY", "FREEZE_AFTER", "DIVERGENCE",
+}
+
+// PairConfig holds the configuration of one served pair
+type PairConfig struct {
+	Pair       pair.Pair
+	Sources    []SourceConfig
+	MinSources int
+	// MinPrice and MaxPrice bound the prices accepted for the pair; zero leaves the bound to the pair's oracle contract
+	MinPrice float64
+	MaxPrice float64
+	// MaxLastDeviation, MaxTWAPDeviation and ValidationMinSources enable the optional validation rules when set
+	MaxLastDeviation     float64
+	MaxLastPriceAge      time.Duration
+	MaxTWAPDeviation     float64
+	TWAPDeviationWindow  time.Duration
+	ValidationMinSources int
+	// PriceChangeThreshold is the relative move needed before a new price is published
+	PriceChangeThreshold float64
+	// OracleContractAddr is the oracle contract updated with the pair's price; empty disables on-chain updates
+	OracleContractAddr string
+	// MaxQuoteAge is how long before the fetch a provider may have observed a quote; zero disables the check
+	MaxQuoteAge time.Duration
+	// JumpThreshold is the relative move held until JumpConfirmFetches consecutive fetches or
+	// JumpConfirmSources sources repeat it; zero disables the jump guard
+	JumpThreshold      float64
+	JumpConfirmFetches int
+	JumpConfirmSources int
+	// ReferenceFeed is an AggregatorV3-compatible feed prices are cross-checked against; empty disables the check
+	ReferenceFeed string
+	// ReferenceMaxDeviation is the largest relative deviation from the reference feed before a price is withheld
+	ReferenceMaxDeviation float64
+	// ReferenceMaxAge is how old the reference round may be before it is ignored
+	ReferenceMaxAge time.Duration
+}
+
+// DerivedPairConfig holds the configuration of a pair computed from two served pairs
+type DerivedPairConfig struct {
+	Pair pair.Pair
+	// Legs are the served pairs the price is derived from, e.g. ETH/USD and EUR/USD for ETH/EUR
+	Legs [2]pair.Pair
+	// MaxLegAge is how old a leg price may be before the pair is no longer derived
+	MaxLegAge time.Duration
+	// MinPrice and MaxPrice bound the prices accepted for the pair; zero leaves the bound to the pair's oracle contract
+	MinPrice float64
+	MaxPrice float64
+	// MaxLastDeviation and MaxTWAPDeviation enable the optional validation rules when set
+	MaxLastDeviation    float64
+	MaxLastPriceAge     time.Duration
+	MaxTWAPDeviation    float64
+	TWAPDeviationWindow time.Duration
+	// PriceChangeThreshold is the relative move needed before a new price is published
+	PriceChangeThreshold float64
+	// OracleContractAddr is the oracle contract updated with the pair's price; empty disables on-chain updates
+	OracleContractAddr string
+}
+
 // Config holds application configuration
 type Config struct {
 	// Server configuration
@@ -20,24 +105,82 @@ type Config struct {
 	RedisURL string
 
 	// NATS configuration
-	NATSURL     string
-	NATSSubject string
+	NATSURL           string
+	NATSSubject       string
+	NATSSubjectPrefix string
+	// NATSEncoding is the wire encoding of price messages, json or cbor
+	NATSEncoding string
+	// SigningKey is the hex ed25519 seed price messages are signed with; empty leaves them unsigned
+	SigningKey string
+	// NATSJetStream publishes prices to a durable JetStream stream instead of core NATS
+	NATSJetStream       bool
+	NATSStream          string
+	NATSStreamMaxAge    time.Duration
+	NATSDuplicateWindow time.Duration
+	NATSAckTimeout      time.Duration
 
 	// API configuration
 	CoinGeckoURL  string
 	FetchInterval time.Duration
 	FetchTimeout  time.Duration
 
+	// Adaptive interval configuration; FetchInterval is the base interval when enabled
+	AdaptiveInterval bool
+	MinFetchInterval time.Duration
+	MaxFetchInterval time.Duration
+	VolatilityWindow int
+	HighVolatility   float64
+	LowVolatility    float64
+
+	// Pair configuration
+	Pairs        []PairConfig
+	DerivedPairs []DerivedPairConfig
+
+	// Circuit breaker configuration
+	CircuitFailureThreshold  int
+	CircuitOpenTimeout       time.Duration
+	HealthWindow             int
+	HealthLatencyTarget      time.Duration
+	HealthDeviationTolerance float64
+
+	// Streaming source configuration
+	StreamMaxAge           time.Duration
+	StreamReconnectMin     time.Duration
+	StreamReconnectMax     time.Duration
+	StreamHandshakeTimeout time.Duration
+
+	// Replay configuration
+	ReplayFile  string
+	ReplaySpeed float64
+	RecordFile  string
+
+	// Aggregation configuration
+	AggregationMethod string
+	OutlierFilter     string
+	OutlierThreshold  float64
+
 	// Price filtering
 	PriceChangeThreshold float64
 
+	// Stablecoin conversion configuration
+	// StablecoinPairs are served pairs such as USDT/USD whose prices convert stablecoin-quoted sources
+	StablecoinPairs      []pair.Pair
+	DepegThreshold       float64
+	DepegAction          string
+	ConversionMaxRateAge time.Duration
+
 	// Cache configuration
 	CacheExpiration time.Duration
 
+	// Archive configuration
+	ArchiveResponses bool
+
 	// Blockchain configuration
 	BlockchainRPCURL     string
 	OracleContractAddr   string
 	BlockchainPrivateKey string
+	// PriceDecimals is the precision prices are normalized to; it must match the PRICE_DECIMALS of every oracle contract
+	PriceDecimals int
 
 	// Logging
 	LogLevel string
@@ -52,7 +195,10 @@ func LoadConfig() (*Config, error) {
 		RedisURL:             getEnv("REDIS_URL", "redis://localhost:6379"),
 		NATSURL:              getEnv("NATS_URL", "nats://localhost:4222"),
 		NATSSubject:          getEnv("NATS_SUBJECT", "prices.ethusd"),
-		CoinGeckoURL:         getEnv("COINGECKO_URL", "https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=usd"),
+		NATSSubjectPrefix:    getEnv("NATS_SUBJECT_PREFIX", "prices"),
+		NATSEncoding:         getEnv("NATS_ENCODING", string(wire.EncodingJSON)),
+		SigningKey:           getEnv("SIGNING_KEY", ""),
+		CoinGeckoURL:         getEnv("COINGECKO_URL", "https://api.coingecko.com/api/v3/simple/price?ids={symbol}&vs_currencies={quote_lower}&include_last_updated_at=true"),
 		FetchInterval:        getDurationEnv("FETCH_INTERVAL", "30s"),
 		FetchTimeout:         getDurationEnv("FETCH_TIMEOUT", "10s"),
 		PriceChangeThreshold: getFloatEnv("PRICE_CHANGE_THRESHOLD", 0.005), // 0.5%
@@ -60,7 +206,72 @@ func LoadConfig() (*Config, error) {
 		BlockchainRPCURL:     getEnv("BLOCKCHAIN_RPC_URL", "http://localhost:8545"),
 		OracleContractAddr:   getEnv("ORACLE_CONTRACT_ADDR", "0x5FbDB2315678afecb367f032d93F642f64180aa3"),
 		BlockchainPrivateKey: getEnv("BLOCKCHAIN_PRIVATE_KEY", ""),
+		PriceDecimals:        getIntEnv("PRICE_DECIMALS", 8),
+		AggregationMethod:    getEnv("AGGREGATION_METHOD", "median"),
+		OutlierFilter:        getEnv("OUTLIER_FILTER", "mad"),
+		OutlierThreshold:     getFloatEnv("OUTLIER_THRESHOLD", 3),
 		LogLevel:             getEnv("LOG_LEVEL", "info"),
+
+		// JetStream configuration
+		NATSJetStream:       getBoolEnv("NATS_JETSTREAM", false),
+		NATSStream:          getEnv("NATS_STREAM", publisher.DefaultStreamName),
+		NATSStreamMaxAge:    getDurationEnv("NATS_STREAM_MAX_AGE", "24h"),
+		NATSDuplicateWindow: getDurationEnv("NATS_DUPLICATE_WINDOW", "2m"),
+		NATSAckTimeout:      getDurationEnv("NATS_ACK_TIMEOUT", "5s"),
+
+		// Adaptive interval configuration
+		AdaptiveInterval: getBoolEnv("ADAPTIVE_INTERVAL", false),
+		MinFetchInterval: getDurationEnv("MIN_FETCH_INTERVAL", "5s"),
+		MaxFetchInterval: getDurationEnv("MAX_FETCH_INTERVAL", "2m"),
+		VolatilityWindow: getIntEnv("VOLATILITY_WINDOW", 30),
+		HighVolatility:   getFloatEnv("HIGH_VOLATILITY", 0.002),
+		LowVolatility:    getFloatEnv("LOW_VOLATILITY", 0.0005),
+
+		// Circuit breaker configuration
+		CircuitFailureThreshold:  getIntEnv("CIRCUIT_FAILURE_THRESHOLD", 3),
+		CircuitOpenTimeout:       getDurationEnv("CIRCUIT_OPEN_TIMEOUT", "2m"),
+		HealthWindow:             getIntEnv("HEALTH_WINDOW", 20),
+		HealthLatencyTarget:      getDurationEnv("HEALTH_LATENCY_TARGET", "2s"),
+		HealthDeviationTolerance: getFloatEnv("HEALTH_DEVIATION_TOLERANCE", 0.005),
+
+		// Streaming source configuration
+		StreamMaxAge:           getDurationEnv("STREAM_MAX_AGE", "30s"),
+		StreamReconnectMin:     getDurationEnv("STREAM_RECONNECT_MIN", "1s"),
+		StreamReconnectMax:     getDurationEnv("STREAM_RECONNECT_MAX", "1m"),
+		StreamHandshakeTimeout: getDurationEnv("STREAM_HANDSHAKE_TIMEOUT", "10s"),
+
+		// Archive configuration
+		ArchiveResponses: getBoolEnv("ARCHIVE_RESPONSES", true),
+
+		// Stablecoin conversion configuration
+		DepegThreshold:       getFloatEnv("DEPEG_THRESHOLD", 0.01),
+		DepegAction:          getEnv("DEPEG_ACTION", "refuse"),
+		ConversionMaxRateAge: getDurationEnv("CONVERSION_MAX_RATE_AGE", "5m"),
+
+		// Replay configuration
+		ReplayFile:  getEnv("REPLAY_FILE", ""),
+		ReplaySpeed: getFloatEnv("REPLAY_SPEED", 1),
+		RecordFile:  getEnv("RECORD_FILE", ""),
+	}
+
+	pairs, err := loadPairConfigs(getListEnv("PAIRS", "ETH/USD"), config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	config.Pairs = pairs
+
+	derivedPairs, err := loadDerivedPairConfigs(getListEnv("DERIVED_PAIRS", ""), config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	config.DerivedPairs = derivedPairs
+
+	for _, entry := range getListEnv("STABLECOIN_PAIRS", "") {
+		p, err := pair.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: STABLECOIN_PAIRS: %w", err)
+		}
+		config.StablecoinPairs = append(config.StablecoinPairs, p)
 	}
 
 	// Validate required configurations
@@ -88,17 +299,438 @@ func (c *Config) Validate() error {
 	if c.CoinGeckoURL == "" {
 		return fmt.Errorf("COINGECKO_URL is required")
 	}
+	if _, err := wire.ParseEncoding(c.NATSEncoding); err != nil {
+		return fmt.Errorf("NATS_ENCODING must be json or cbor")
+	}
+	if c.SigningKey != "" {
+		if _, err := wire.ParsePrivateKey(c.SigningKey); err != nil {
+			return fmt.Errorf("SIGNING_KEY must be a hex ed25519 seed: %w", err)
+		}
+	}
+	if c.NATSJetStream {
+		if c.NATSStream == "" {
+			return fmt.Errorf("NATS_STREAM is required when NATS_JETSTREAM is on")
+		}
+		if c.NATSStreamMaxAge <= 0 || c.NATSDuplicateWindow <= 0 || c.NATSDuplicateWindow > c.NATSStreamMaxAge {
+			return fmt.Errorf("NATS_STREAM_MAX_AGE and NATS_DUPLICATE_WINDOW must be positive, with the window not above the max age")
+		}
+		if c.NATSAckTimeout <= 0 {
+			return fmt.Errorf("NATS_ACK_TIMEOUT must be positive")
+		}
+	}
+	if c.PriceDecimals < 0 || c.PriceDecimals > 18 {
+		return fmt.Errorf("PRICE_DECIMALS must be between 0 and 18")
+	}
+	if len(c.Pairs) == 0 {
+		return fmt.Errorf("PAIRS must list at least one pair")
+	}
+	seenPairs := make(map[pair.Pair]bool)
+	for _, pairConfig := range c.Pairs {
+		if seenPairs[pairConfig.Pair] {
+			return fmt.Errorf("PAIRS contains duplicate pair %s", pairConfig.Pair)
+		}
+		seenPairs[pairConfig.Pair] = true
+		if err := pairConfig.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, derivedConfig := range c.DerivedPairs {
+		if seenPairs[derivedConfig.Pair] {
+			return fmt.Errorf("DERIVED_PAIRS pair %s is already served or derived", derivedConfig.Pair)
+		}
+		seenPairs[derivedConfig.Pair] = true
+		if err := derivedConfig.Validate(); err != nil {
+			return err
+		}
+		for _, leg := range derivedConfig.Legs {
+			if !c.servesPair(leg) {
+				return fmt.Errorf("%s_DERIVED_FROM leg %s must be listed in PAIRS", derivedConfig.Pair.EnvPrefix(), leg)
+			}
+		}
+	}
+	if c.FetchInterval <= 0 {
+		return fmt.Errorf("FETCH_INTERVAL must be positive")
+	}
+	if c.AdaptiveInterval {
+		if c.MinFetchInterval <= 0 || c.MinFetchInterval > c.FetchInterval || c.MaxFetchInterval < c.FetchInterval {
+			return fmt.Errorf("MIN_FETCH_INTERVAL must be positive and FETCH_INTERVAL must lie between MIN_FETCH_INTERVAL and MAX_FETCH_INTERVAL")
+		}
+		if c.VolatilityWindow < 3 {
+			return fmt.Errorf("VOLATILITY_WINDOW must be at least 3")
+		}
+		if c.LowVolatility < 0 || c.HighVolatility <= c.LowVolatility {
+			return fmt.Errorf("LOW_VOLATILITY must not be negative and must be below HIGH_VOLATILITY")
+		}
+	}
+	for _, p := range c.StablecoinPairs {
+		if !c.servesPair(p) {
+			return fmt.Errorf("STABLECOIN_PAIRS pair %s must be listed in PAIRS", p)
+		}
+	}
+	if c.DepegThreshold <= 0 {
+		return fmt.Errorf("DEPEG_THRESHOLD must be positive")
+	}
+	if c.DepegAction != "refuse" && c.DepegAction != "flag" {
+		return fmt.Errorf("DEPEG_ACTION must be refuse or flag")
+	}
+	if c.ConversionMaxRateAge <= 0 {
+		return fmt.Errorf("CONVERSION_MAX_RATE_AGE must be positive")
+	}
+	if c.CircuitFailureThreshold < 0 {
+		return fmt.Errorf("CIRCUIT_FAILURE_THRESHOLD must not be negative")
+	}
+	if c.StreamMaxAge <= 0 {
+		return fmt.Errorf("STREAM_MAX_AGE must be positive")
+	}
+	if c.StreamReconnectMin <= 0 || c.StreamReconnectMax < c.StreamReconnectMin {
+		return fmt.Errorf("STREAM_RECONNECT_MIN must be positive and not above STREAM_RECONNECT_MAX")
+	}
+	if c.ReplaySpeed <= 0 {
+		return fmt.Errorf("REPLAY_SPEED must be positive")
+	}
+	if c.ReplayFile != "" && c.RecordFile != "" {
+		return fmt.Errorf("REPLAY_FILE and RECORD_FILE cannot both be set")
+	}
 	if c.PriceChangeThreshold < 0 || c.PriceChangeThreshold > 1 {
 		return fmt.Errorf("PRICE_CHANGE_THRESHOLD must be between 0 and 1")
 	}
 	return nil
 }
 
+// Validate checks if the pair configuration is valid
+func (c *PairConfig) Validate() error {
+	prefix := c.Pair.EnvPrefix()
+	if len(c.Sources) == 0 {
+		return fmt.Errorf("%s_SOURCES must list at least one source", prefix)
+	}
+	seen := make(map[string]bool)
+	for _, source := range c.Sources {
+		if seen[source.Name] {
+			return fmt.Errorf("%s_SOURCES contains duplicate source %q", prefix, source.Name)
+		}
+		seen[source.Name] = true
+		if source.Weight < 0 {
+			return fmt.Errorf("%s must not be negative", sourceEnvKey(source.Name, "WEIGHT"))
+		}
+	}
+	if c.MinSources < 1 || c.MinSources > len(c.Sources) {
+		return fmt.Errorf("%s_MIN_SOURCES must be between 1 and the number of %s_SOURCES (%d)", prefix, prefix, len(c.Sources))
+	}
+	if err := validatePriceBounds(prefix, c.MinPrice, c.MaxPrice); err != nil {
+		return err
+	}
+	if err := validateDeviationRules(prefix, c.MaxLastDeviation, c.MaxLastPriceAge, c.MaxTWAPDeviation, c.TWAPDeviationWindow); err != nil {
+		return err
+	}
+	if c.ValidationMinSources < 0 || c.ValidationMinSources > len(c.Sources) {
+		return fmt.Errorf("%s_VALIDATION_MIN_SOURCES must be between 0 and the number of %s_SOURCES (%d)", prefix, prefix, len(c.Sources))
+	}
+	if c.PriceChangeThreshold < 0 || c.PriceChangeThreshold > 1 {
+		return fmt.Errorf("%s_PRICE_CHANGE_THRESHOLD must be between 0 and 1", prefix)
+	}
+	if c.MaxQuoteAge < 0 {
+		return fmt.Errorf("%s_MAX_QUOTE_AGE must not be negative", prefix)
+	}
+	if c.JumpThreshold < 0 {
+		return fmt.Errorf("%s_JUMP_THRESHOLD must not be negative", prefix)
+	}
+	if c.JumpThreshold > 0 && c.JumpConfirmFetches < 2 {
+		return fmt.Errorf("%s_JUMP_CONFIRM_FETCHES must be at least 2", prefix)
+	}
+	// Moves beyond the last-price limit but within the jump threshold would be rejected without the jump guard
+	// ever confirming them, leaving the pair stuck until MAX_LAST_PRICE_AGE
+	if c.JumpThreshold > 0 && c.MaxLastDeviation > 0 && c.MaxLastDeviation <= c.JumpThreshold {
+		return fmt.Errorf("%s_MAX_LAST_DEVIATION must be above %s_JUMP_THRESHOLD", prefix, prefix)
+	}
+	if c.JumpConfirmSources < 0 || c.JumpConfirmSources > len(c.Sources) {
+		return fmt.Errorf("%s_JUMP_CONFIRM_SOURCES must be between 0 and the number of %s_SOURCES (%d)", prefix, prefix, len(c.Sources))
+	}
+	if c.ReferenceFeed != "" && c.ReferenceMaxDeviation <= 0 {
+		return fmt.Errorf("%s_REFERENCE_MAX_DEVIATION must be positive", prefix)
+	}
+	if c.ReferenceFeed != "" && c.ReferenceMaxAge <= 0 {
+		return fmt.Errorf("%s_REFERENCE_MAX_AGE must be positive", prefix)
+	}
+	return nil
+}
+
+// Validate checks if the derived pair configuration is valid
+func (c *DerivedPairConfig) Validate() error {
+	prefix := c.Pair.EnvPrefix()
+	if c.MaxLegAge <= 0 {
+		return fmt.Errorf("%s_MAX_LEG_AGE must be positive", prefix)
+	}
+	if err := validatePriceBounds(prefix, c.MinPrice, c.MaxPrice); err != nil {
+		return err
+	}
+	if err := validateDeviationRules(prefix, c.MaxLastDeviation, c.MaxLastPriceAge, c.MaxTWAPDeviation, c.TWAPDeviationWindow); err != nil {
+		return err
+	}
+	if c.PriceChangeThreshold < 0 || c.PriceChangeThreshold > 1 {
+		return fmt.Errorf("%s_PRICE_CHANGE_THRESHOLD must be between 0 and 1", prefix)
+	}
+	return nil
+}
+
+// validatePriceBounds checks the bounds of the pair with the given env prefix, where zero is an unset bound
+func validatePriceBounds(prefix string, minPrice, maxPrice float64) error {
+	if minPrice < 0 || maxPrice < 0 {
+		return fmt.Errorf("%s_MIN_PRICE and %s_MAX_PRICE must not be negative", prefix, prefix)
+	}
+	if minPrice > 0 && maxPrice > 0 && maxPrice <= minPrice {
+		return fmt.Errorf("%s_MIN_PRICE must be below %s_MAX_PRICE", prefix, prefix)
+	}
+	return nil
+}
+
+// validateDeviationRules checks the deviation rule settings of the pair with the given env prefix
+func validateDeviationRules(prefix string, maxLastDeviation float64, maxLastPriceAge time.Duration, maxTWAPDeviation float64, twapWindow time.Duration) error {
+	if maxLastDeviation < 0 {
+		return fmt.Errorf("%s_MAX_LAST_DEVIATION must not be negative", prefix)
+	}
+	if maxLastPriceAge < 0 {
+		return fmt.Errorf("%s_MAX_LAST_PRICE_AGE must not be negative", prefix)
+	}
+	if maxTWAPDeviation < 0 {
+		return fmt.Errorf("%s_MAX_TWAP_DEVIATION must not be negative", prefix)
+	}
+	if maxTWAPDeviation > 0 && twapWindow <= 0 {
+		return fmt.Errorf("%s_TWAP_DEVIATION_WINDOW must be positive", prefix)
+	}
+	return nil
+}
+
+// ValidationConfig returns the validation rules of the pair
+func (c *PairConfig) ValidationConfig() validation.Config {
+	return validation.Config{
+		MinPrice:         c.MinPrice,
+		MaxPrice:         c.MaxPrice,
+		MaxLastDeviation: c.MaxLastDeviation,
+		MaxLastPriceAge:  c.MaxLastPriceAge,
+		MaxTWAPDeviation: c.MaxTWAPDeviation,
+		TWAPWindow:       c.TWAPDeviationWindow,
+		MinSources:       c.ValidationMinSources,
+	}
+}
+
+// ValidationConfig returns the validation rules of the derived pair
+func (c *DerivedPairConfig) ValidationConfig() validation.Config {
+	return validation.Config{
+		MinPrice:         c.MinPrice,
+		MaxPrice:         c.MaxPrice,
+		MaxLastDeviation: c.MaxLastDeviation,
+		MaxLastPriceAge:  c.MaxLastPriceAge,
+		MaxTWAPDeviation: c.MaxTWAPDeviation,
+		TWAPWindow:       c.TWAPDeviationWindow,
+	}
+}
+
+// NATSStreamConfig returns the JetStream stream prices are published to
+// It covers every per-pair subject and the legacy NATS_SUBJECT when that lies outside the prefix
+func (c *Config) NATSStreamConfig() publisher.StreamConfig {
+	subjects := []string{c.NATSSubjectPrefix + ".>"}
+	if !strings.HasPrefix(c.NATSSubject, c.NATSSubjectPrefix+".") {
+		subjects = append(subjects, c.NATSSubject)
+	}
+	return publisher.StreamConfig{
+		Name:       c.NATSStream,
+		Subjects:   subjects,
+		MaxAge:     c.NATSStreamMaxAge,
+		Duplicates: c.NATSDuplicateWindow,
+		AckTimeout: c.NATSAckTimeout,
+	}
+}
+
+// LongestFetchInterval returns the longest interval between two ticks
+func (c *Config) LongestFetchInterval() time.Duration {
+	if c.AdaptiveInterval && c.MaxFetchInterval > c.FetchInterval {
+		return c.MaxFetchInterval
+	}
+	return c.FetchInterval
+}
+
+// IsStablecoinPair reports whether p converts stablecoin-quoted sources
+func (c *Config) IsStablecoinPair(p pair.Pair) bool {
+	for _, stablecoin := range c.StablecoinPairs {
+		if stablecoin == p {
+			return true
+		}
+	}
+	return false
+}
+
+// servesPair reports whether p is fetched from sources
+func (c *Config) servesPair(p pair.Pair) bool {
+	for _, pairConfig := range c.Pairs {
+		if pairConfig.Pair == p {
+			return true
+		}
+	}
+	return false
+}
+
 // GetServerAddr returns the server address
 func (c *Config) GetServerAddr() string {
 	return c.ServerHost + ":" + c.ServerPort
 }
 
+// loadPairConfigs builds pair configs from PAIRS entries such as "ETH/USD"
+// Every setting can be overridden per pair with a <BASE>_<QUOTE>_ prefix (e.g. BTC_USD_SOURCES)
+// and otherwise falls back to the global setting
+func loadPairConfigs(entries []string, config *Config) ([]PairConfig, error) {
+	defaultSources := getEnv("PRICE_SOURCES", "coingecko")
+	defaultMinSources := getIntEnv("MIN_SOURCES", 1)
+	defaultMinPrice := getFloatEnv("MIN_PRICE", 0)
+	defaultMaxPrice := getFloatEnv("MAX_PRICE", 0)
+	defaultMaxLastDeviation := getFloatEnv("MAX_LAST_DEVIATION", 0)
+	defaultMaxLastPriceAge := getEnv("MAX_LAST_PRICE_AGE", "10m")
+	defaultMaxTWAPDeviation := getFloatEnv("MAX_TWAP_DEVIATION", 0)
+	defaultTWAPDeviationWindow := getEnv("TWAP_DEVIATION_WINDOW", "15m")
+	defaultValidationMinSources := getIntEnv("VALIDATION_MIN_SOURCES", 0)
+	defaultJumpThreshold := getFloatEnv("JUMP_THRESHOLD", 0)
+	defaultJumpConfirmFetches := getIntEnv("JUMP_CONFIRM_FETCHES", 2)
+	defaultJumpConfirmSources := getIntEnv("JUMP_CONFIRM_SOURCES", 0)
+	defaultReferenceMaxDeviation := getFloatEnv("REFERENCE_MAX_DEVIATION", 0.02)
+	defaultReferenceMaxAge := getEnv("REFERENCE_MAX_AGE", "1h")
+	defaultMaxQuoteAge := getEnv("MAX_QUOTE_AGE", "2m")
+
+	var pairs []PairConfig
+	for _, entry := range entries {
+		p, err := pair.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("PAIRS: %w", err)
+		}
+		prefix := p.EnvPrefix() + "_"
+
+		// Only ETH/USD inherits the legacy contract address, other pairs need their own oracle
+		defaultContract := ""
+		if p == pair.ETHUSD {
+			defaultContract = config.OracleContractAddr
+		}
+
+		pairs = append(pairs, PairConfig{
+			Pair:                 p,
+			Sources:              loadSourceConfigs(prefix, getListEnv(prefix+"SOURCES", defaultSources), config.CoinGeckoURL),
+			MinSources:           getIntEnv(prefix+"MIN_SOURCES", defaultMinSources),
+			MinPrice:             getFloatEnv(prefix+"MIN_PRICE", defaultMinPrice),
+			MaxPrice:             getFloatEnv(prefix+"MAX_PRICE", defaultMaxPrice),
+			PriceChangeThreshold: getFloatEnv(prefix+"PRICE_CHANGE_THRESHOLD", config.PriceChangeThreshold),
+			OracleContractAddr:   getEnv(prefix+"ORACLE_CONTRACT_ADDR", defaultContract),
+			MaxQuoteAge:          getDurationEnv(prefix+"MAX_QUOTE_AGE", defaultMaxQuoteAge),
+
+			MaxLastDeviation:     getFloatEnv(prefix+"MAX_LAST_DEVIATION", defaultMaxLastDeviation),
+			MaxLastPriceAge:      getDurationEnv(prefix+"MAX_LAST_PRICE_AGE", defaultMaxLastPriceAge),
+			MaxTWAPDeviation:     getFloatEnv(prefix+"MAX_TWAP_DEVIATION", defaultMaxTWAPDeviation),
+			TWAPDeviationWindow:  getDurationEnv(prefix+"TWAP_DEVIATION_WINDOW", defaultTWAPDeviationWindow),
+			ValidationMinSources: getIntEnv(prefix+"VALIDATION_MIN_SOURCES", defaultValidationMinSources),
+
+			JumpThreshold:      getFloatEnv(prefix+"JUMP_THRESHOLD", defaultJumpThreshold),
+			JumpConfirmFetches: getIntEnv(prefix+"JUMP_CONFIRM_FETCHES", defaultJumpConfirmFetches),
+			JumpConfirmSources: getIntEnv(prefix+"JUMP_CONFIRM_SOURCES", defaultJumpConfirmSources),
+
+			ReferenceFeed:         getEnv(prefix+"REFERENCE_FEED", ""),
+			ReferenceMaxDeviation: getFloatEnv(prefix+"REFERENCE_MAX_DEVIATION", defaultReferenceMaxDeviation),
+			ReferenceMaxAge:       getDurationEnv(prefix+"REFERENCE_MAX_AGE", defaultReferenceMaxAge),
+		})
+	}
+	return pairs, nil
+}
+
+// loadDerivedPairConfigs builds derived pair configs from DERIVED_PAIRS entries such as "ETH/EUR"
+// Each pair names its two legs in <BASE>_<QUOTE>_DERIVED_FROM (e.g. ETH_EUR_DERIVED_FROM=ETH/USD,EUR/USD);
+// the bounds, publish threshold and contract fall back to the global settings like served pairs do,
+// and legs may be at most DERIVED_MAX_LEG_AGE old (twice the fetch interval by default)
+func loadDerivedPairConfigs(entries []string, config *Config) ([]DerivedPairConfig, error) {
+	defaultMaxLegAge := getDurationEnv("DERIVED_MAX_LEG_AGE", (2 * config.LongestFetchInterval()).String())
+	defaultMinPrice := getFloatEnv("MIN_PRICE", 0)
+	defaultMaxPrice := getFloatEnv("MAX_PRICE", 0)
+	defaultMaxLastDeviation := getFloatEnv("MAX_LAST_DEVIATION", 0)
+	defaultMaxLastPriceAge := getEnv("MAX_LAST_PRICE_AGE", "10m")
+	defaultMaxTWAPDeviation := getFloatEnv("MAX_TWAP_DEVIATION", 0)
+	defaultTWAPDeviationWindow := getEnv("TWAP_DEVIATION_WINDOW", "15m")
+
+	var derivedPairs []DerivedPairConfig
+	for _, entry := range entries {
+		p, err := pair.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("DERIVED_PAIRS: %w", err)
+		}
+		prefix := p.EnvPrefix() + "_"
+
+		legEntries := getListEnv(prefix+"DERIVED_FROM", "")
+		if len(legEntries) != 2 {
+			return nil, fmt.Errorf("%sDERIVED_FROM must list exactly two pairs", prefix)
+		}
+		var legs [2]pair.Pair
+		for i, legEntry := range legEntries {
+			if legs[i], err = pair.Parse(legEntry); err != nil {
+				return nil, fmt.Errorf("%sDERIVED_FROM: %w", prefix, err)
+			}
+		}
+
+		derivedPairs = append(derivedPairs, DerivedPairConfig{
+			Pair:                 p,
+			Legs:                 legs,
+			MaxLegAge:            getDurationEnv(prefix+"MAX_LEG_AGE", defaultMaxLegAge.String()),
+			MinPrice:             getFloatEnv(prefix+"MIN_PRICE", defaultMinPrice),
+			MaxPrice:             getFloatEnv(prefix+"MAX_PRICE", defaultMaxPrice),
+			PriceChangeThreshold: getFloatEnv(prefix+"PRICE_CHANGE_THRESHOLD", config.PriceChangeThreshold),
+			OracleContractAddr:   getEnv(prefix+"ORACLE_CONTRACT_ADDR", ""),
+
+			MaxLastDeviation:    getFloatEnv(prefix+"MAX_LAST_DEVIATION", defaultMaxLastDeviation),
+			MaxLastPriceAge:     getDurationEnv(prefix+"MAX_LAST_PRICE_AGE", defaultMaxLastPriceAge),
+			MaxTWAPDeviation:    getFloatEnv(prefix+"MAX_TWAP_DEVIATION", defaultMaxTWAPDeviation),
+			TWAPDeviationWindow: getDurationEnv(prefix+"TWAP_DEVIATION_WINDOW", defaultTWAPDeviationWindow),
+		})
+	}
+	return derivedPairs, nil
+}
+
+// loadSourceConfigs builds source configs from a pair's source entries
+// Each entry is either "kind" or "name:kind"; the endpoint, weight and requests-per-minute budget
+// can be overridden with SOURCE_<NAME>_URL, SOURCE_<NAME>_WEIGHT and SOURCE_<NAME>_RATE_LIMIT and the
+// quoted asset with SOURCE_<NAME>_QUOTE_ASSET,
+// optionally prefixed with the pair (e.g. BTC_USD_SOURCE_<NAME>_URL); adapter-specific settings
+// such as SOURCE_<NAME>_POOL are collected into the source's params the same way
+func loadSourceConfigs(pairPrefix string, entries []string, coinGeckoURL string) []SourceConfig {
+	var sources []SourceConfig
+	for _, entry := range entries {
+		name, kind := entry, entry
+		if i := strings.Index(entry, ":"); i >= 0 {
+			name, kind = entry[:i], entry[i+1:]
+		}
+
+		defaultURL := ""
+		if kind == "coingecko" {
+			// Keep honouring COINGECKO_URL for existing deployments
+			defaultURL = coinGeckoURL
+		}
+
+		params := make(map[string]string)
+		for _, setting := range sourceParamSettings {
+			if value := getEnv(pairPrefix+sourceEnvKey(name, setting), getEnv(sourceEnvKey(name, setting), "")); value != "" {
+				params[strings.ToLower(setting)] = value
+			}
+		}
+
+		sources = append(sources, SourceConfig{
+			Name:              name,
+			Kind:              kind,
+			URL:               getEnv(pairPrefix+sourceEnvKey(name, "URL"), getEnv(sourceEnvKey(name, "URL"), defaultURL)),
+			Weight:            getFloatEnv(pairPrefix+sourceEnvKey(name, "WEIGHT"), getFloatEnv(sourceEnvKey(name, "WEIGHT"), 1)),
+			RequestsPerMinute: getIntEnv(pairPrefix+sourceEnvKey(name, "RATE_LIMIT"), getIntEnv(sourceEnvKey(name, "RATE_LIMIT"), 0)),
+			Params:            params,
+			QuoteAsset:        strings.ToUpper(getEnv(pairPrefix+sourceEnvKey(name, "QUOTE_ASSET"), getEnv(sourceEnvKey(name, "QUOTE_ASSET"), ""))),
+		})
+	}
+	return sources
+}
+
+// sourceEnvKey returns the environment variable name for a per-source setting
+func sourceEnvKey(name, setting string) string {
+	return "SOURCE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_" + setting
+}
+
 // getEnv gets an environment variable with a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -133,6 +765,32 @@ func getFloatEnv(key string, defaultValue float64) float64 {
 	return floatValue
 }
 
+// getBoolEnv gets a boolean environment variable with a default value
+func getBoolEnv(key string, defaultValue bool) bool {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return boolValue
+}
+
+// getListEnv gets a comma-separated list environment variable with a default value
+func getListEnv(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getIntEnv gets an integer environment variable with a default value
 func getIntEnv(key string, defaultValue int) int {
 	value := getEnv(key, "")
@@ -0,0 +1,322 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Reason is the structured code of a validation rejection
+type Reason string
+
+const (
+	// ReasonInvalidPrice rejects prices that are not positive finite numbers
+	ReasonInvalidPrice Reason = "invalid_price"
+	// ReasonBelowMin rejects prices below the pair's lower bound
+	ReasonBelowMin Reason = "below_min_price"
+	// ReasonAboveMax rejects prices above the pair's upper bound
+	ReasonAboveMax Reason = "above_max_price"
+	// ReasonLastPriceDeviation rejects prices too far from the last delivered price
+	ReasonLastPriceDeviation Reason = "last_price_deviation"
+	// ReasonTWAPDeviation rejects prices too far from the pair's recent time-weighted average
+	ReasonTWAPDeviation Reason = "twap_deviation"
+	// ReasonTooFewSources rejects prices backed by fewer sources than required
+	ReasonTooFewSources Reason = "too_few_sources"
+)
+
+// Input is a candidate price together with the context the rules judge it in
+type Input struct {
+	Price float64
+	// Sources is the number of sources the price was aggregated from
+	Sources int
+	// LastPrice is the last delivered price, zero when there is none, it is too old to judge by or the move is confirmed
+	LastPrice float64
+	// Confirmed marks a move the jump guard confirmed, which is not judged by the last delivered price
+	// but replaces it once delivered
+	Confirmed bool
+	// TWAP is the time-weighted average price over the configured window, zero when it is unknown
+	TWAP float64
+	// Time is when the price was produced, which the age of the last delivered price is measured at; zero means now
+	Time time.Time
+}
+
+// Rejection explains why a rule rejected a price
+type Rejection struct {
+	Rule   string    `json:"rule"`
+	Reason Reason    `json:"reason"`
+	Detail string    `json:"detail"`
+	Price  float64   `json:"price"`
+	Time   time.Time `json:"time"`
+}
+
+// Error returns the detail of the rejection
+func (r *Rejection) Error() string {
+	return r.Detail
+}
+
+// reject creates a rejection of input by rule
+func reject(rule Rule, reason Reason, input Input, format string, args ...any) *Rejection {
+	return &Rejection{
+		Rule:   rule.Name(),
+		Reason: reason,
+		Detail: fmt.Sprintf(format, args...),
+		Price:  input.Price,
+	}
+}
+
+// Rule checks one property of a price
+type Rule interface {
+	Name() string
+	// Check returns nil when the price passes the rule
+	Check(input Input) *Rejection
+}
+
+// BoundsRule accepts prices within [Min, Max]
+type BoundsRule struct {
+	Min float64
+	Max float64
+}
+
+// Name returns the name of the rule
+func (r BoundsRule) Name() string {
+	return "bounds"
+}
+
+// Check rejects prices that are not positive finite numbers within the bounds
+func (r BoundsRule) Check(input Input) *Rejection {
+	price := input.Price
+	if math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+		return reject(r, ReasonInvalidPrice, input, "price must be a positive finite number, got: %f", price)
+	}
+	if price > r.Max {
+		return reject(r, ReasonAboveMax, input, "price seems unreasonably high: %f", price)
+	}
+	if price < r.Min {
+		return reject(r, ReasonBelowMin, input, "price seems unreasonably low: %f", price)
+	}
+	return nil
+}
+
+// LastPriceDeviationRule accepts prices within MaxDeviation of the last delivered price
+type LastPriceDeviationRule struct {
+	MaxDeviation float64
+}
+
+// Name returns the name of the rule
+func (r LastPriceDeviationRule) Name() string {
+	return "last_price_deviation"
+}
+
+// Check rejects prices too far from the last delivered price, passing prices while there is none
+func (r LastPriceDeviationRule) Check(input Input) *Rejection {
+	if input.LastPrice <= 0 {
+		return nil
+	}
+	if deviation := math.Abs(input.Price-input.LastPrice) / input.LastPrice; deviation > r.MaxDeviation {
+		return reject(r, ReasonLastPriceDeviation, input, "price %f is %.2f%% from the last delivered price %f, above the %.2f%% limit",
+			input.Price, deviation*100, input.LastPrice, r.MaxDeviation*100)
+	}
+	return nil
+}
+
+// TWAPDeviationRule accepts prices within MaxDeviation of the time-weighted average over Window
+type TWAPDeviationRule struct {
+	MaxDeviation float64
+	Window       time.Duration
+}
+
+// Name returns the name of the rule
+func (r TWAPDeviationRule) Name() string {
+	return "twap_deviation"
+}
+
+// Check rejects prices too far from the TWAP, passing prices while no TWAP is known
+func (r TWAPDeviationRule) Check(input Input) *Rejection {
+	if input.TWAP <= 0 {
+		return nil
+	}
+	if deviation := math.Abs(input.Price-input.TWAP) / input.TWAP; deviation > r.MaxDeviation {
+		return reject(r, ReasonTWAPDeviation, input, "price %f is %.2f%% from the %v TWAP %f, above the %.2f%% limit",
+			input.Price, deviation*100, r.Window, input.TWAP, r.MaxDeviation*100)
+	}
+	return nil
+}
+
+// MinSourcesRule accepts prices aggregated from at least Min sources
+type MinSourcesRule struct {
+	Min int
+}
+
+// Name returns the name of the rule
+func (r MinSourcesRule) Name() string {
+	return "min_sources"
+}
+
+// Check rejects prices backed by fewer sources than required
+func (r MinSourcesRule) Check(input Input) *Rejection {
+	if input.Sources < r.Min {
+		return reject(r, ReasonTooFewSources, input, "price is backed by %d sources, %d required", input.Sources, r.Min)
+	}
+	return nil
+}
+
+// Config holds the validation rules of one pair; zero values disable the optional rules
+type Config struct {
+	MinPrice float64
+	MaxPrice float64
+	// MaxLastDeviation is the largest relative move from the last delivered price
+	MaxLastDeviation float64
+	// MaxLastPriceAge is how long the last delivered price is judged by; once it is older the next
+	// price passes the rule and becomes the new anchor, so a real move cannot freeze the pair.
+	// Zero keeps the anchor until the next delivery
+	MaxLastPriceAge time.Duration
+	// MaxTWAPDeviation is the largest relative distance from the TWAP over TWAPWindow
+	MaxTWAPDeviation float64
+	TWAPWindow       time.Duration
+	// MinSources is the number of sources that must remain after outliers are discarded
+	MinSources int
+}
+
+// Validate checks if the validation configuration is valid
+func (c Config) Validate() error {
+	if c.MinPrice <= 0 || c.MaxPrice <= c.MinPrice {
+		return fmt.Errorf("min price must be positive and below the max price")
+	}
+	if c.MaxLastDeviation < 0 {
+		return fmt.Errorf("max last price deviation must not be negative, got: %f", c.MaxLastDeviation)
+	}
+	if c.MaxLastPriceAge < 0 {
+		return fmt.Errorf("max last price age must not be negative, got: %v", c.MaxLastPriceAge)
+	}
+	if c.MaxTWAPDeviation < 0 {
+		return fmt.Errorf("max TWAP deviation must not be negative, got: %f", c.MaxTWAPDeviation)
+	}
+	if c.MaxTWAPDeviation > 0 && c.TWAPWindow <= 0 {
+		return fmt.Errorf("TWAP window must be positive, got: %v", c.TWAPWindow)
+	}
+	if c.MinSources < 0 {
+		return fmt.Errorf("min sources must not be negative, got: %d", c.MinSources)
+	}
+	return nil
+}
+
+// Rules returns the rules the configuration enables, in the order they are checked
+func (c Config) Rules() []Rule {
+	rules := []Rule{BoundsRule{Min: c.MinPrice, Max: c.MaxPrice}}
+	if c.MinSources > 0 {
+		rules = append(rules, MinSourcesRule{Min: c.MinSources})
+	}
+	if c.MaxLastDeviation > 0 {
+		rules = append(rules, LastPriceDeviationRule{MaxDeviation: c.MaxLastDeviation})
+	}
+	if c.MaxTWAPDeviation > 0 {
+		rules = append(rules, TWAPDeviationRule{MaxDeviation: c.MaxTWAPDeviation, Window: c.TWAPWindow})
+	}
+	return rules
+}
+
+// Stats summarises the decisions of an engine
+type Stats struct {
+	Accepted        int64            `json:"accepted"`
+	Rejected        map[Reason]int64 `json:"rejected"`
+	LastDelivered   float64          `json:"last_delivered,omitempty"`
+	LastDeliveredAt time.Time        `json:"last_delivered_at,omitempty"`
+	LastRejection   *Rejection       `json:"last_rejection,omitempty"`
+}
+
+// Engine checks the prices of one pair against its rules and remembers the last delivered price
+type Engine struct {
+	config Config
+	rules  []Rule
+
+	mu            sync.Mutex
+	lastPrice     float64
+	lastPriceAt   time.Time
+	accepted      int64
+	rejected      map[Reason]int64
+	lastRejection *Rejection
+}
+
+// NewEngine creates an engine enforcing the rules of config
+func NewEngine(config Config) (*Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid validation config: %w", err)
+	}
+
+	return &Engine{
+		config:   config,
+		rules:    config.Rules(),
+		rejected: make(map[Reason]int64),
+	}, nil
+}
+
+// Config returns the configuration of the engine
+func (e *Engine) Config() Config {
+	return e.config
+}
+
+// TWAPWindow returns the window the TWAP must be computed over, or zero when no rule needs it
+func (e *Engine) TWAPWindow() time.Duration {
+	if e.config.MaxTWAPDeviation <= 0 {
+		return 0
+	}
+	return e.config.TWAPWindow
+}
+
+// Validate checks input against every rule and returns the first rejection, or nil if the price is accepted
+// The last delivered price is filled in by the engine while it is recent enough; accepting a price does not
+// change it, since later guards may still withhold the price
+func (e *Engine) Validate(input Input) *Rejection {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if input.Time.IsZero() {
+		input.Time = time.Now()
+	}
+	if !input.Confirmed && (e.config.MaxLastPriceAge <= 0 || input.Time.Sub(e.lastPriceAt) <= e.config.MaxLastPriceAge) {
+		input.LastPrice = e.lastPrice
+	}
+	for _, rule := range e.rules {
+		if rejection := rule.Check(input); rejection != nil {
+			rejection.Time = time.Now()
+			e.rejected[rejection.Reason]++
+			e.lastRejection = rejection
+			return rejection
+		}
+	}
+
+	e.accepted++
+	return nil
+}
+
+// Deliver records a price that passed every guard and was delivered, making it the price later prices are judged by
+func (e *Engine) Deliver(price float64, at time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastPrice = price
+	e.lastPriceAt = at
+}
+
+// Stats returns the decisions of the engine so far
+func (e *Engine) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rejected := make(map[Reason]int64, len(e.rejected))
+	for reason, count := range e.rejected {
+		rejected[reason] = count
+	}
+	stats := Stats{
+		Accepted:        e.accepted,
+		Rejected:        rejected,
+		LastDelivered:   e.lastPrice,
+		LastDeliveredAt: e.lastPriceAt,
+	}
+	if e.lastRejection != nil {
+		lastRejection := *e.lastRejection
+		stats.LastRejection = &lastRejection
+	}
+	return stats
+}
@@ -0,0 +1,153 @@
+package validation
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestEngine creates an engine with a 5% last-price rule whose anchor lasts maxAge
+func newTestEngine(t *testing.T, maxAge time.Duration) *Engine {
+	t.Helper()
+
+	engine, err := NewEngine(Config{MinPrice: 1, MaxPrice: 1000000, MaxLastDeviation: 0.05, MaxLastPriceAge: maxAge})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	return engine
+}
+
+func TestEngineAnchorsOnDeliveredPricesOnly(t *testing.T) {
+	engine := newTestEngine(t, 10*time.Minute)
+	start := time.Now()
+
+	if rejection := engine.Validate(Input{Price: 100, Time: start}); rejection != nil {
+		t.Fatalf("Validate(100) = %v, want accepted", rejection)
+	}
+	engine.Deliver(100, start)
+
+	// 104 passes validation but a later guard withholds it, so it is never delivered
+	if rejection := engine.Validate(Input{Price: 104, Time: start.Add(time.Minute)}); rejection != nil {
+		t.Fatalf("Validate(104) = %v, want accepted", rejection)
+	}
+
+	// 108 is judged against the delivered 100, not the withheld 104
+	rejection := engine.Validate(Input{Price: 108, Time: start.Add(2 * time.Minute)})
+	if rejection == nil || rejection.Reason != ReasonLastPriceDeviation {
+		t.Fatalf("Validate(108) = %v, want a %s rejection", rejection, ReasonLastPriceDeviation)
+	}
+
+	stats := engine.Stats()
+	if stats.LastDelivered != 100 || !stats.LastDeliveredAt.Equal(start) {
+		t.Errorf("Stats() last delivered = %v at %v, want 100 at %v", stats.LastDelivered, stats.LastDeliveredAt, start)
+	}
+	if stats.Accepted != 2 || stats.Rejected[ReasonLastPriceDeviation] != 1 {
+		t.Errorf("Stats() = %+v, want 2 accepted and 1 rejected", stats)
+	}
+}
+
+func TestEngineReanchorsAfterMaxAge(t *testing.T) {
+	engine := newTestEngine(t, 10*time.Minute)
+	start := time.Now()
+	engine.Deliver(100, start)
+
+	// The market moves 20% for good; every price is rejected while the anchor is fresh
+	for minute := 1; minute <= 10; minute++ {
+		at := start.Add(time.Duration(minute) * time.Minute)
+		if rejection := engine.Validate(Input{Price: 120, Time: at}); rejection == nil {
+			t.Fatalf("Validate(120) after %d minutes accepted, want rejected while the anchor is fresh", minute)
+		}
+	}
+
+	// Once the anchor is too old the new level passes and, once delivered, becomes the anchor
+	at := start.Add(11 * time.Minute)
+	if rejection := engine.Validate(Input{Price: 120, Time: at}); rejection != nil {
+		t.Fatalf("Validate(120) after the anchor expired = %v, want accepted", rejection)
+	}
+	engine.Deliver(120, at)
+
+	if rejection := engine.Validate(Input{Price: 121, Time: at.Add(time.Minute)}); rejection != nil {
+		t.Errorf("Validate(121) = %v, want accepted against the new anchor", rejection)
+	}
+	if rejection := engine.Validate(Input{Price: 100, Time: at.Add(time.Minute)}); rejection == nil {
+		t.Error("Validate(100) accepted, want rejected against the new anchor")
+	}
+}
+
+func TestEngineReanchorsOnConfirmedMoves(t *testing.T) {
+	engine := newTestEngine(t, 0)
+	start := time.Now()
+	engine.Deliver(100, start)
+
+	// The jump guard confirmed the 20% move, so the last price does not hold it back
+	at := start.Add(time.Minute)
+	if rejection := engine.Validate(Input{Price: 120, Time: at, Confirmed: true}); rejection != nil {
+		t.Fatalf("Validate(120) of a confirmed move = %v, want accepted", rejection)
+	}
+	engine.Deliver(120, at)
+
+	if rejection := engine.Validate(Input{Price: 121, Time: at.Add(time.Minute)}); rejection != nil {
+		t.Errorf("Validate(121) = %v, want accepted against the new anchor", rejection)
+	}
+	// Other rules still apply to confirmed moves
+	if rejection := engine.Validate(Input{Price: 2000000, Time: at, Confirmed: true}); rejection == nil || rejection.Reason != ReasonAboveMax {
+		t.Errorf("Validate(2000000) of a confirmed move = %v, want a %s rejection", rejection, ReasonAboveMax)
+	}
+}
+
+func TestEngineKeepsAnchorWithoutMaxAge(t *testing.T) {
+	engine := newTestEngine(t, 0)
+	start := time.Now()
+	engine.Deliver(100, start)
+
+	if rejection := engine.Validate(Input{Price: 120, Time: start.Add(24 * time.Hour)}); rejection == nil {
+		t.Error("Validate(120) a day later accepted, want rejected while the anchor is kept")
+	}
+}
+
+func TestEngineRules(t *testing.T) {
+	engine, err := NewEngine(Config{
+		MinPrice:         10,
+		MaxPrice:         1000,
+		MaxTWAPDeviation: 0.1,
+		TWAPWindow:       15 * time.Minute,
+		MinSources:       2,
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		input  Input
+		reason Reason
+	}{
+		{name: "accepted", input: Input{Price: 100, Sources: 2, TWAP: 105}},
+		{name: "not a number", input: Input{Price: -1, Sources: 2}, reason: ReasonInvalidPrice},
+		{name: "below min", input: Input{Price: 5, Sources: 2}, reason: ReasonBelowMin},
+		{name: "above max", input: Input{Price: 5000, Sources: 2}, reason: ReasonAboveMax},
+		{name: "too few sources", input: Input{Price: 100, Sources: 1}, reason: ReasonTooFewSources},
+		{name: "far from TWAP", input: Input{Price: 100, Sources: 2, TWAP: 120}, reason: ReasonTWAPDeviation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rejection := engine.Validate(tt.input)
+			if tt.reason == "" {
+				if rejection != nil {
+					t.Errorf("Validate() = %v, want accepted", rejection)
+				}
+				return
+			}
+			if rejection == nil || rejection.Reason != tt.reason {
+				t.Errorf("Validate() = %v, want a %s rejection", rejection, tt.reason)
+			}
+		})
+	}
+}
+
+func TestConfigValidateRejectsNegativeMaxLastPriceAge(t *testing.T) {
+	config := Config{MinPrice: 1, MaxPrice: 10, MaxLastDeviation: 0.05, MaxLastPriceAge: -time.Minute}
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() with a negative max last price age succeeded")
+	}
+}
@@ -74,7 +74,7 @@ func main() {
 	}
 	normalizedPrice := normalizer.NormalizePrice(apiPrice)
 	fmt.Printf("🎯 Normalized price: $%.2f\n", normalizedPrice)
-	fmt.Printf("🔢 Contract units: %.0f\n", normalizedPrice*100000000)
+	fmt.Printf("🔢 Contract units: %s\n", normalizer.NormalizeFixed(apiPrice).Units())
 	fmt.Println("")
 
 	// Test 4: Update blockchain with new price
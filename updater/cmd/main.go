@@ -2,24 +2,59 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/114windd/DeFiOraclePipeline.git/updater/pkg/ethclient"
+	"github.com/114windd/DeFiOraclePipeline.git/updater/pkg/metrics"
 	"github.com/114windd/DeFiOraclePipeline.git/updater/pkg/updater"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
 	// Command line flags
 	var (
 		natsURL    = flag.String("nats-url", "nats://localhost:4222", "NATS server URL")
-		subject    = flag.String("subject", "prices.ethusd", "NATS subject to subscribe to")
+		subject    = flag.String("subject", "prices.>", "NATS subject to subscribe to")
+		pairs      = flag.String("pairs", "", "Comma-separated pairs to push on-chain, e.g. ETH/USD,BTC/USD (empty = every pair with a contract)")
 		ethRPCURL  = flag.String("eth-rpc", "https://eth-sepolia.g.alchemy.com/v2/6ChCkEoo-jvGgoa85eb9G", "Ethereum RPC URL")
 		privateKey = flag.String("private-key", "", "Private key for transaction signing")
 		gasLimit   = flag.Uint64("gas-limit", 200000, "Gas limit for transactions")
 		threshold  = flag.Float64("threshold", 0.005, "Price change threshold (0.005 = 0.5%)")
+		thresholds = flag.String("pair-thresholds", "", "Per-pair price change thresholds, e.g. BTC/USD=0.002,LINK/USD=0.01")
+
+		oracleContract  = flag.String("oracle-contract", "", "Oracle contract ETH/USD prices are written to")
+		oracleContracts = flag.String("oracle-contracts", "", "Per-pair oracle contracts, e.g. ETH/USD=0x...,BTC/USD=0x...; pairs without one are not pushed on-chain")
+		priceDecimals   = flag.Int("price-decimals", 0, "Decimals of on-chain prices (0 = each contract's PRICE_DECIMALS); must match the contract's when set")
+
+		minPrice      = flag.Float64("min-price", 0, "Lowest price pushed on-chain (0 = the contract's MIN_PRICE)")
+		maxPrice      = flag.Float64("max-price", 0, "Highest price pushed on-chain (0 = the contract's MAX_PRICE)")
+		pairMinPrices = flag.String("pair-min-prices", "", "Per-pair lowest prices, e.g. ETH/BTC=0.001")
+		pairMaxPrices = flag.String("pair-max-prices", "", "Per-pair highest prices, e.g. ETH/BTC=1")
+
+		maxConfidenceWidth = flag.Float64("max-confidence-width", 0, "Widest confidence band relative to the price (0.01 = 1%, 0 = no limit)")
+		confidenceWidths   = flag.String("pair-confidence-widths", "", "Per-pair confidence band limits, e.g. BTC/USD=0.005,LINK/USD=0.02")
+		confidenceAction   = flag.String("confidence-action", "suppress", "What to do with prices above the confidence limit: suppress or flag")
+
+		useJetStream      = flag.Bool("jetstream", false, "Consume prices through a durable JetStream consumer instead of a core NATS subscription")
+		stream            = flag.String("stream", "PRICES", "JetStream stream the backend publishes prices to")
+		durable           = flag.String("durable", "oracle-updater", "Name of the durable JetStream consumer")
+		ackWait           = flag.Duration("ack-wait", time.Minute, "How long a delivered price may go unacknowledged before it is redelivered")
+		maxDeliver        = flag.Int("max-deliver", 5, "Deliveries of a price before it is dead-lettered")
+		deadLetterSubject = flag.String("dead-letter-subject", "deadletter.prices", "Subject prices that cannot be submitted are published to; keep it outside the price stream")
+
+		publisherKeys  = flag.String("publisher-keys", "", "Comma-separated hex ed25519 public keys of publishers whose signed prices are accepted (empty = accept unsigned prices)")
+		maxMessageAge  = flag.Duration("max-message-age", 10*time.Minute, "Oldest signed price accepted (0 = no limit)")
+		seedSkewMargin = flag.Duration("seed-skew-margin", updater.DefaultSeedSkewMargin, "How far before a contract's last update signed prices are accepted after a restart, covering skew between block timestamps and the backend clock")
+		metricsAddr    = flag.String("metrics-addr", ":9091", "Address Prometheus metrics are served on at /metrics (empty = disabled)")
 	)
 	flag.Parse()
 
@@ -54,12 +89,162 @@ func main() {
 		log.Printf("Account balance: %s ETH", balance.String())
 	}
 
+	if *seedSkewMargin < 0 {
+		log.Fatalf("-seed-skew-margin must not be negative, got: %v", *seedSkewMargin)
+	}
+
+	// Parse the confidence action before the updater variable shadows its package
+	action, err := updater.ParseConfidenceAction(*confidenceAction)
+	if err != nil {
+		log.Fatalf("Invalid confidence action: %v", err)
+	}
+
+	// Collect the oracle contract of each pair before the updater variable shadows its package
+	contracts, err := parsePairAddresses(*oracleContracts)
+	if err != nil {
+		log.Fatalf("Invalid oracle contracts: %v", err)
+	}
+	if *oracleContract != "" {
+		if !common.IsHexAddress(*oracleContract) {
+			log.Fatalf("Invalid oracle contract address: %s", *oracleContract)
+		}
+		if _, ok := contracts[updater.DefaultPair]; ok {
+			log.Fatalf("Both -oracle-contract and -oracle-contracts set the %s contract", updater.DefaultPair)
+		}
+		contracts[updater.DefaultPair] = common.HexToAddress(*oracleContract)
+	}
+
+	// Describe the JetStream consumer before the updater variable shadows its package
+	consumerConfig := updater.ConsumerConfig{
+		Stream:            *stream,
+		Durable:           *durable,
+		AckWait:           *ackWait,
+		MaxDeliver:        *maxDeliver,
+		DeadLetterSubject: *deadLetterSubject,
+	}
+
 	// Initialize updater
 	updater, err := updater.NewUpdater(*natsURL, *subject, ethClient, *threshold)
 	if err != nil {
 		log.Fatalf("Failed to initialize updater: %v", err)
 	}
 
+	// Route each pair to its own oracle contract; a contract holds one price, so pairs cannot share one
+	if err := updater.SetContracts(contracts); err != nil {
+		log.Fatalf("Invalid oracle contracts: %v", err)
+	}
+
+	// Configure the per-pair update policy
+	if *pairs != "" {
+		if err := updater.SetPairs(strings.Split(*pairs, ",")); err != nil {
+			log.Fatalf("Invalid pairs: %v", err)
+		}
+	}
+	for pair, contract := range updater.Contracts() {
+		log.Printf("Pushing %s prices to oracle contract %s", pair, contract.Hex())
+	}
+	pairThresholds, err := parsePairValues(*thresholds)
+	if err != nil {
+		log.Fatalf("Invalid pair thresholds: %v", err)
+	}
+	for pair, pairThreshold := range pairThresholds {
+		updater.SetPairThreshold(pair, pairThreshold)
+	}
+
+	// Configure the bounds of prices pushed on-chain; they can only narrow the range of the pair's contract
+	if err := updater.SetPriceBounds(*minPrice, *maxPrice); err != nil {
+		log.Fatalf("Invalid price bounds: %v", err)
+	}
+	pairMins, err := parsePairValues(*pairMinPrices)
+	if err != nil {
+		log.Fatalf("Invalid pair min prices: %v", err)
+	}
+	for pair, pairMin := range pairMins {
+		updater.SetPairMinPrice(pair, pairMin)
+	}
+	pairMaxes, err := parsePairValues(*pairMaxPrices)
+	if err != nil {
+		log.Fatalf("Invalid pair max prices: %v", err)
+	}
+	for pair, pairMax := range pairMaxes {
+		updater.SetPairMaxPrice(pair, pairMax)
+	}
+
+	// Take the decimals and price range of each pair from the contract its prices are written to,
+	// refusing to start when a flag disagrees with it
+	if *priceDecimals != 0 {
+		updater.SetDecimals(*priceDecimals)
+	}
+	for pair, contract := range updater.Contracts() {
+		params, err := ethClient.ContractParams(contract)
+		if err != nil {
+			log.Fatalf("Failed to read the %s oracle contract parameters: %v", pair, err)
+		}
+		if *priceDecimals != 0 && params.Decimals != *priceDecimals {
+			log.Fatalf("%s oracle contract has %d decimals but -price-decimals is %d", pair, params.Decimals, *priceDecimals)
+		}
+		if err := updater.SetContractParams(pair, params); err != nil {
+			log.Fatalf("Price bounds disagree with the %s oracle contract: %v", pair, err)
+		}
+		log.Printf("%s oracle contract %s: %d decimals, prices in [%s, %s], max age %v",
+			pair, contract.Hex(), params.Decimals, params.MinPrice, params.MaxPrice, params.MaxAge)
+	}
+
+	// Configure how prices with a wide confidence band are handled
+	if err := updater.SetConfidencePolicy(*maxConfidenceWidth, action); err != nil {
+		log.Fatalf("Invalid confidence policy: %v", err)
+	}
+	pairConfidenceWidths, err := parsePairValues(*confidenceWidths)
+	if err != nil {
+		log.Fatalf("Invalid pair confidence widths: %v", err)
+	}
+	for pair, width := range pairConfidenceWidths {
+		updater.SetPairConfidenceWidth(pair, width)
+	}
+
+	// Read prices through a durable consumer, so updates published while the updater is down are not lost
+	if *useJetStream {
+		if err := updater.EnableJetStream(consumerConfig); err != nil {
+			log.Fatalf("Invalid JetStream consumer: %v", err)
+		}
+		log.Printf("Consuming from JetStream stream %s as %s, dead-lettering to %s after %d deliveries",
+			*stream, *durable, *deadLetterSubject, *maxDeliver)
+	}
+
+	// Only accept prices signed by the allowed publishers
+	if *publisherKeys != "" {
+		if err := updater.RequireSignatures(strings.Split(*publisherKeys, ","), *maxMessageAge); err != nil {
+			log.Fatalf("Invalid publisher keys: %v", err)
+		}
+		log.Printf("Accepting prices signed by %d publisher keys", len(strings.Split(*publisherKeys, ",")))
+
+		// Resume each pair's message order where the chain left it, so older prices are not submitted again
+		for pair, contract := range updater.Contracts() {
+			updatedAt, err := ethClient.LatestPriceTime(contract)
+			if err != nil {
+				log.Fatalf("Failed to read the %s oracle contract's last update: %v", pair, err)
+			}
+			if !updatedAt.IsZero() {
+				updater.SeedMessageOrder(pair, updatedAt, *seedSkewMargin)
+				log.Printf("Dropping %s messages from more than %v before the contract's last update at %v", pair, *seedSkewMargin, updatedAt)
+			}
+		}
+	} else {
+		log.Printf("Warning: -publisher-keys is not set, unsigned prices are accepted")
+	}
+
+	// Serve metrics
+	if *metricsAddr != "" {
+		updater.SetMetrics(metrics.NewMetrics())
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Printf("Metrics server stopped: %v", err)
+			}
+		}()
+	}
+
 	// Set up signal handling for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -73,6 +258,9 @@ func main() {
 
 	log.Printf("Updater worker started. Listening for price updates on subject: %s", *subject)
 	log.Printf("Price change threshold: %.2f%%", *threshold*100)
+	if *maxConfidenceWidth > 0 {
+		log.Printf("Confidence band limit: %.2f%% (%s)", *maxConfidenceWidth*100, action)
+	}
 
 	// Wait for shutdown signal
 	<-quit
@@ -83,3 +271,44 @@ func main() {
 	log.Println("Updater worker stopped")
 }
 
+// parsePairValues parses a comma-separated list of PAIR=VALUE entries; an empty list yields no values
+func parsePairValues(list string) (map[string]float64, error) {
+	values := make(map[string]float64)
+	if list == "" {
+		return values, nil
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		pair, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, expected PAIR=VALUE", entry)
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", pair, err)
+		}
+		values[pair] = parsed
+	}
+	return values, nil
+}
+
+// parsePairAddresses parses a comma-separated list of PAIR=ADDRESS entries; an empty list yields no addresses
+func parsePairAddresses(list string) (map[string]common.Address, error) {
+	addresses := make(map[string]common.Address)
+	if list == "" {
+		return addresses, nil
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		pair, address, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, expected PAIR=ADDRESS", entry)
+		}
+		address = strings.TrimSpace(address)
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address for %s: %s", pair, address)
+		}
+		addresses[updater.NormalizePair(pair)] = common.HexToAddress(address)
+	}
+	return addresses, nil
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -14,6 +15,28 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// ContractParams are the constants the Oracle contract enforces on price updates
+type ContractParams struct {
+	Decimals int
+	// MinPrice and MaxPrice are at Decimals
+	MinPrice fixedpoint.Price
+	MaxPrice fixedpoint.Price
+	MaxAge   time.Duration
+}
+
+// CheckPrice returns an error when a price, rounded to the contract's decimals, lies outside [MinPrice, MaxPrice],
+// the range updatePrice accepts
+func (p ContractParams) CheckPrice(price fixedpoint.Price) error {
+	price = price.Rescale(p.Decimals)
+	if price.Cmp(p.MinPrice) < 0 {
+		return fmt.Errorf("price %s is below the contract's MIN_PRICE %s", price, p.MinPrice)
+	}
+	if price.Cmp(p.MaxPrice) > 0 {
+		return fmt.Errorf("price %s is above the contract's MAX_PRICE %s", price, p.MaxPrice)
+	}
+	return nil
+}
+
 // EthClient handles Ethereum blockchain interactions
 type EthClient struct {
 	client     *ethclient.Client
@@ -47,8 +70,8 @@ func NewEthClient(rpcURL, privateKeyHex string, gasLimit uint64) (*EthClient, er
 
 	fromAddr := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// Get chain ID
-	chainID, err := client.NetworkID(context.Background())
+	// Get chain ID, which transactions are signed for
+	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chain ID: %w", err)
 	}
@@ -87,65 +110,110 @@ func NewEthClientWithConfig(
 	}
 }
 
-// UpdatePrice updates the price in the Oracle contract
-func (e *EthClient) UpdatePrice(price int64) (string, error) {
-	// This is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Load the Oracle contract ABI
-	// 2. Create a contract instance
-	// 3. Call the updatePrice function
-
-	// For now, we'll simulate a transaction
+// UpdatePrice sends an updatePrice transaction to the Oracle contract at contractAddr, with the price in contract units
+func (e *EthClient) UpdatePrice(contractAddr common.Address, price *big.Int) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Create transaction options
-	nonce, err := e.client.PendingNonceAt(ctx, e.fromAddr)
+	contract, err := e.oracleContract(contractAddr)
 	if err != nil {
-		return "", fmt.Errorf("failed to get nonce: %w", err)
+		return "", err
 	}
 
-	// Create a simple value transfer transaction as a placeholder
-	to := common.HexToAddress("0x0000000000000000000000000000000000000000") // Placeholder address
-	value := big.NewInt(0)                                                  // No ETH transfer, just contract call
+	chainID := e.chainID
+	if chainID == nil {
+		if chainID, err = e.client.ChainID(ctx); err != nil {
+			return "", fmt.Errorf("failed to get chain ID: %w", err)
+		}
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(e.privateKey, chainID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create transactor: %w", err)
+	}
+	// The nonce is the account's pending nonce, read when the transaction is built
+	opts.Context = ctx
+	opts.GasLimit = e.gasLimit
+	opts.GasPrice = e.gasPrice
 
-	tx := &bind.TransactOpts{
-		From:     e.fromAddr,
-		Nonce:    big.NewInt(int64(nonce)),
-		Value:    value,
-		GasLimit: e.gasLimit,
-		GasPrice: e.gasPrice,
-		Context:  ctx,
+	tx, err := contract.UpdatePrice(opts, price)
+	if err != nil {
+		return "", fmt.Errorf("failed to send transaction: %w", err)
 	}
 
-	// Sign the transaction
-	signedTx, err := e.signTransaction(tx, to, value)
+	return tx.Hash().Hex(), nil
+}
+
+//go:generate abigen --abi ../../../backend/pkg/blockchain/Oracle.abi.json --pkg ethclient --type OracleContract --out oracle_contract.go
+
+// oracleContract binds the Oracle contract at contractAddr, through the binding generated from the ABI the backend uses
+func (e *EthClient) oracleContract(contractAddr common.Address) (*OracleContract, error) {
+	contract, err := NewOracleContract(contractAddr, e.client)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign transaction: %w", err)
+		return nil, fmt.Errorf("failed to bind oracle contract: %w", err)
 	}
+	return contract, nil
+}
+
+// ContractParams reads PRICE_DECIMALS, MIN_PRICE, MAX_PRICE and MAX_AGE from the Oracle contract at contractAddr
+func (e *EthClient) ContractParams(contractAddr common.Address) (ContractParams, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	// Send the transaction
-	err = e.client.SendTransaction(ctx, signedTx)
+	contract, err := e.oracleContract(contractAddr)
 	if err != nil {
-		return "", fmt.Errorf("failed to send transaction: %w", err)
+		return ContractParams{}, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	values := make(map[string]*big.Int)
+	for _, constant := range []struct {
+		method string
+		call   func(*bind.CallOpts) (*big.Int, error)
+	}{
+		{method: "PRICE_DECIMALS", call: contract.PRICEDECIMALS},
+		{method: "MIN_PRICE", call: contract.MINPRICE},
+		{method: "MAX_PRICE", call: contract.MAXPRICE},
+		{method: "MAX_AGE", call: contract.MAXAGE},
+	} {
+		value, err := constant.call(opts)
+		if err != nil {
+			return ContractParams{}, fmt.Errorf("failed to call %s: %w", constant.method, err)
+		}
+		values[constant.method] = value
 	}
 
-	return signedTx.Hash().Hex(), nil
+	decimals := values["PRICE_DECIMALS"]
+	if !decimals.IsInt64() || decimals.Int64() > 36 {
+		return ContractParams{}, fmt.Errorf("unsupported PRICE_DECIMALS %s", decimals)
+	}
+	return ContractParams{
+		Decimals: int(decimals.Int64()),
+		MinPrice: fixedpoint.New(values["MIN_PRICE"], int(decimals.Int64())),
+		MaxPrice: fixedpoint.New(values["MAX_PRICE"], int(decimals.Int64())),
+		MaxAge:   time.Duration(values["MAX_AGE"].Int64()) * time.Second,
+	}, nil
 }
 
-// signTransaction signs a transaction with the private key
-func (e *EthClient) signTransaction(opts *bind.TransactOpts, to common.Address, value *big.Int) (*types.Transaction, error) {
-	// This is a simplified implementation
-	// In practice, you would use the proper transaction signing methods
+// LatestPriceTime returns when the price of the Oracle contract at contractAddr was last updated,
+// the zero time before its first update
+func (e *EthClient) LatestPriceTime(contractAddr common.Address) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	// For now, return a mock transaction hash
-	// In a real implementation, you would:
-	// 1. Create the transaction data
-	// 2. Sign it with the private key
-	// 3. Return the signed transaction
+	contract, err := e.oracleContract(contractAddr)
+	if err != nil {
+		return time.Time{}, err
+	}
 
-	// Mock transaction hash for demonstration
-	return &types.Transaction{}, nil
+	latest, err := contract.LatestPrice(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to call latestPrice: %w", err)
+	}
+	timestamp := latest.Timestamp
+	if timestamp.Sign() == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(timestamp.Int64(), 0), nil
 }
 
 // GetBalance returns the ETH balance of the account
@@ -195,12 +263,12 @@ func (e *EthClient) WaitForTransaction(txHash string) error {
 	defer cancel()
 
 	// Wait for transaction to be mined
-	receipt, err := bind.WaitMined(ctx, e.client, &types.Transaction{})
+	receipt, err := bind.WaitMinedHash(ctx, e.client, common.HexToHash(txHash))
 	if err != nil {
 		return fmt.Errorf("failed to wait for transaction: %w", err)
 	}
 
-	if receipt.Status != 1 {
+	if receipt.Status != types.ReceiptStatusSuccessful {
 		return fmt.Errorf("transaction failed")
 	}
 
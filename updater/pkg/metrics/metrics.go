@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics holds the Prometheus metrics of the updater
+type Metrics struct {
+	// Message verification metrics
+	MessagesVerified prometheus.CounterVec
+	MessagesRejected prometheus.CounterVec
+}
+
+// NewMetrics creates a new metrics instance
+func NewMetrics() *Metrics {
+	return &Metrics{
+		MessagesVerified: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "updater_messages_verified_total",
+				Help: "Price messages whose signature was verified against the publisher allow-list",
+			},
+			[]string{"pair"},
+		),
+		MessagesRejected: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "updater_messages_rejected_total",
+				Help: "Price messages rejected before submission, by reason",
+			},
+			[]string{"pair", "reason"},
+		),
+	}
+}
+
+// RecordMessageVerified counts a message with a valid signature from an allowed publisher
+func (m *Metrics) RecordMessageVerified(pair string) {
+	m.MessagesVerified.WithLabelValues(pair).Inc()
+}
+
+// RecordMessageRejected counts a message rejected because it was undecodable, unsigned, tampered, outdated or replayed
+func (m *Metrics) RecordMessageRejected(pair, reason string) {
+	m.MessagesRejected.WithLabelValues(pair, reason).Inc()
+}
@@ -0,0 +1,193 @@
+package updater
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// DeadLetterReasonHeader explains why a price was dead-lettered
+	DeadLetterReasonHeader = "Oracle-Dead-Letter-Reason"
+	// DeadLetterSubjectHeader is the subject the dead-lettered price was published to
+	DeadLetterSubjectHeader = "Oracle-Original-Subject"
+	// DeadLetterDeliveriesHeader is how many times the dead-lettered price was delivered
+	DeadLetterDeliveriesHeader = "Oracle-Deliveries"
+)
+
+// ConsumerConfig describes the durable consumer prices are read through in JetStream mode
+type ConsumerConfig struct {
+	Stream  string
+	Durable string
+	// FilterSubject is the subject the consumer reads prices from; EnableJetStream fills in the updater's subject
+	// when it is empty
+	FilterSubject string
+	// AckWait is how long a delivered price may stay unacknowledged before it is redelivered
+	AckWait time.Duration
+	// MaxDeliver is how many times a price is delivered before it is dead-lettered
+	MaxDeliver int
+	// DeadLetterSubject receives prices that cannot be decoded or were still failing on their last delivery
+	DeadLetterSubject string
+}
+
+// Validate checks if the consumer configuration is valid
+func (c ConsumerConfig) Validate() error {
+	if c.Stream == "" || c.Durable == "" {
+		return fmt.Errorf("stream and durable consumer names are required")
+	}
+	if c.AckWait <= 0 {
+		return fmt.Errorf("ack wait must be positive, got: %v", c.AckWait)
+	}
+	if c.MaxDeliver < 1 {
+		return fmt.Errorf("max deliveries must be at least 1, got: %d", c.MaxDeliver)
+	}
+	if c.DeadLetterSubject == "" {
+		return fmt.Errorf("dead-letter subject is required")
+	}
+	// A dead-lettered price the consumer also reads would be delivered to it again
+	if c.FilterSubject != "" && wire.SubjectCovers(c.FilterSubject, c.DeadLetterSubject) {
+		return fmt.Errorf("dead-letter subject %s is covered by the filter subject %s", c.DeadLetterSubject, c.FilterSubject)
+	}
+	return nil
+}
+
+// EnableJetStream makes Start consume prices through a durable pull consumer on the stream of config
+// instead of a core NATS subscription
+func (u *Updater) EnableJetStream(config ConsumerConfig) error {
+	if config.FilterSubject == "" {
+		config.FilterSubject = u.subject
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid consumer config: %w", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.consumer = &config
+	return nil
+}
+
+// startJetStream consumes prices through the durable consumer until the updater is stopped
+func (u *Updater) startJetStream(config ConsumerConfig) error {
+	log.Printf("Starting updater worker for subject %s on stream %s as %s", config.FilterSubject, config.Stream, config.Durable)
+
+	js, err := jetstream.New(u.conn)
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	stream, err := js.Stream(u.ctx, config.Stream)
+	if err != nil {
+		return fmt.Errorf("failed to look up stream %s: %w", config.Stream, err)
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(u.ctx, jetstream.ConsumerConfig{
+		Durable:       config.Durable,
+		FilterSubject: config.FilterSubject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       config.AckWait,
+		MaxDeliver:    config.MaxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s: %w", config.Durable, err)
+	}
+
+	consumeContext, err := consumer.Consume(func(msg jetstream.Msg) {
+		u.handleStreamMessage(msg, config)
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		log.Printf("JetStream consumer %s error: %v", config.Durable, err)
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to consume from %s: %w", config.Stream, err)
+	}
+	defer consumeContext.Stop()
+
+	// Wait for context cancellation
+	<-u.ctx.Done()
+	log.Println("Updater worker stopped")
+	return nil
+}
+
+// handleStreamMessage processes a price delivered by the durable consumer
+// Skipped and submitted prices are acked, failed submissions are redelivered with exponential backoff,
+// and prices that cannot be decoded or fail on their last delivery go to the dead-letter subject
+func (u *Updater) handleStreamMessage(msg jetstream.Msg, config ConsumerConfig) {
+	deliveries := 1
+	if metadata, err := msg.Metadata(); err == nil {
+		deliveries = int(metadata.NumDelivered)
+	}
+
+	update, err := u.preparePriceUpdate(msg.Data(), msg.Headers().Get(wire.ContentTypeHeader))
+	if err != nil {
+		u.deadLetter(msg, config.DeadLetterSubject, deliveries, err)
+		return
+	}
+	if update == nil {
+		if err := msg.Ack(); err != nil {
+			log.Printf("Failed to ack skipped price: %v", err)
+		}
+		return
+	}
+
+	txHash, err := u.SendPriceOnChain(update.pair, update.price)
+	if err != nil {
+		if deliveries >= config.MaxDeliver {
+			u.deadLetter(msg, config.DeadLetterSubject, deliveries, fmt.Errorf("failed to send %s price on-chain: %w", update.pair, err))
+			return
+		}
+
+		// Exponential backoff: 2^delivery seconds, as for core NATS retries
+		delay := u.retryDelay(deliveries)
+		log.Printf("Failed to send %s price on-chain (delivery %d of %d), redelivering in %v: %v",
+			update.pair, deliveries, config.MaxDeliver, delay, err)
+		if err := msg.NakWithDelay(delay); err != nil {
+			log.Printf("Failed to nak %s price: %v", update.pair, err)
+		}
+		return
+	}
+
+	u.priceSubmitted(update, txHash)
+	if err := msg.Ack(); err != nil {
+		log.Printf("Failed to ack %s price: %v", update.pair, err)
+	}
+}
+
+// deadLetter publishes a price to the dead-letter subject with the reason it failed, then terminates it
+// If the dead letter cannot be published the price is redelivered instead of being lost
+func (u *Updater) deadLetter(msg jetstream.Msg, subject string, deliveries int, reason error) {
+	log.Printf("Dead-lettering price from %s after %d deliveries: %v", msg.Subject(), deliveries, reason)
+
+	// Keep the original headers, such as the content type, so the dead letter can still be decoded
+	deadLetter := nats.NewMsg(subject)
+	deadLetter.Data = msg.Data()
+	for key, values := range msg.Headers() {
+		deadLetter.Header[key] = values
+	}
+	deadLetter.Header.Set(DeadLetterReasonHeader, reason.Error())
+	deadLetter.Header.Set(DeadLetterSubjectHeader, msg.Subject())
+	deadLetter.Header.Set(DeadLetterDeliveriesHeader, strconv.Itoa(deliveries))
+
+	if err := u.conn.PublishMsg(deadLetter); err != nil {
+		log.Printf("Failed to publish dead letter to %s: %v", subject, err)
+		if err := msg.Nak(); err != nil {
+			log.Printf("Failed to nak price: %v", err)
+		}
+		return
+	}
+	if err := msg.Term(); err != nil {
+		log.Printf("Failed to terminate dead-lettered price: %v", err)
+	}
+}
+
+// consumerConfig returns the consumer configuration, or nil when prices are read through core NATS
+func (u *Updater) consumerConfig() *ConsumerConfig {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.consumer
+}
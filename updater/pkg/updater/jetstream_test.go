@@ -0,0 +1,256 @@
+package updater
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	testStream     = "PRICES"
+	testDurable    = "updater"
+	testDeadLetter = "deadletter.prices"
+)
+
+// jetStreamTest is an updater consuming from a stream on an embedded NATS server
+type jetStreamTest struct {
+	updater *Updater
+	js      jetstream.JetStream
+	// deadLetters receives what the updater dead-letters
+	deadLetters chan *nats.Msg
+}
+
+// startJetStreamTest starts an embedded NATS server with a price stream and an updater consuming from it
+// Submissions fail, since the updater has no Ethereum client, and are retried after a few milliseconds
+func startJetStreamTest(t *testing.T, maxDeliver int) *jetStreamTest {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatalf("jetstream.New() error = %v", err)
+	}
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:       testStream,
+		Subjects:   []string{"prices.>"},
+		Duplicates: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("CreateStream() error = %v", err)
+	}
+	deadLetters := make(chan *nats.Msg, 16)
+	if _, err := conn.ChanSubscribe(testDeadLetter, deadLetters); err != nil {
+		t.Fatalf("ChanSubscribe() error = %v", err)
+	}
+
+	updaterConn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	u := newUpdater(updaterConn, "prices.>", nil, 0.005)
+	u.retryBackoff = 10 * time.Millisecond
+	if err := u.SetContracts(map[string]common.Address{"ETH/USD": ethUSDContract}); err != nil {
+		t.Fatalf("SetContracts() error = %v", err)
+	}
+	err = u.EnableJetStream(ConsumerConfig{
+		Stream:            testStream,
+		Durable:           testDurable,
+		AckWait:           5 * time.Second,
+		MaxDeliver:        maxDeliver,
+		DeadLetterSubject: testDeadLetter,
+	})
+	if err != nil {
+		t.Fatalf("EnableJetStream() error = %v", err)
+	}
+	go func() {
+		if err := u.Start(); err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	}()
+	t.Cleanup(u.Stop)
+
+	return &jetStreamTest{updater: u, js: js, deadLetters: deadLetters}
+}
+
+// publish stores a price message in the stream under the message ID id
+func (jt *jetStreamTest) publish(t *testing.T, subject, data, id string) {
+	t.Helper()
+
+	msg := nats.NewMsg(subject)
+	msg.Data = []byte(data)
+	if _, err := jt.js.PublishMsg(context.Background(), msg, jetstream.WithMsgID(id)); err != nil {
+		t.Fatalf("PublishMsg() error = %v", err)
+	}
+}
+
+// deadLetter waits for the next dead letter
+func (jt *jetStreamTest) deadLetter(t *testing.T) *nats.Msg {
+	t.Helper()
+
+	select {
+	case msg := <-jt.deadLetters:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no dead letter published")
+		return nil
+	}
+}
+
+// waitForAcks waits until the consumer has settled acked messages, returning its state
+func (jt *jetStreamTest) waitForAcks(t *testing.T, acked uint64) *jetstream.ConsumerInfo {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		consumer, err := jt.js.Consumer(context.Background(), testStream, testDurable)
+		if err == nil {
+			info, err := consumer.Info(context.Background())
+			if err == nil && info.AckFloor.Stream >= acked && info.NumAckPending == 0 {
+				return info
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("consumer did not settle %d messages", acked)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConsumerConfigValidate(t *testing.T) {
+	valid := ConsumerConfig{
+		Stream:            testStream,
+		Durable:           testDurable,
+		FilterSubject:     "prices.*",
+		AckWait:           time.Second,
+		MaxDeliver:        3,
+		DeadLetterSubject: testDeadLetter,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ConsumerConfig)
+		valid  bool
+	}{
+		{name: "valid", modify: func(c *ConsumerConfig) {}, valid: true},
+		{name: "dead letters beside a wildcard filter", modify: func(c *ConsumerConfig) { c.DeadLetterSubject = "prices.dead.letter" }, valid: true},
+		{name: "missing durable", modify: func(c *ConsumerConfig) { c.Durable = "" }},
+		{name: "zero ack wait", modify: func(c *ConsumerConfig) { c.AckWait = 0 }},
+		{name: "no deliveries", modify: func(c *ConsumerConfig) { c.MaxDeliver = 0 }},
+		{name: "missing dead-letter subject", modify: func(c *ConsumerConfig) { c.DeadLetterSubject = "" }},
+		{name: "dead letters matching the filter", modify: func(c *ConsumerConfig) { c.DeadLetterSubject = "prices.deadletter" }},
+		{name: "dead letters under a full wildcard", modify: func(c *ConsumerConfig) {
+			c.FilterSubject = "prices.>"
+			c.DeadLetterSubject = "prices.dead.letter"
+		}},
+		{name: "dead letters equal to the filter", modify: func(c *ConsumerConfig) { c.DeadLetterSubject = c.FilterSubject }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			if err := config.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestEnableJetStreamRejectsDeadLettersItReads(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0.005)
+	err := u.EnableJetStream(ConsumerConfig{
+		Stream:            testStream,
+		Durable:           testDurable,
+		AckWait:           time.Second,
+		MaxDeliver:        3,
+		DeadLetterSubject: "prices.deadletter",
+	})
+	if err == nil {
+		t.Error("EnableJetStream() with dead letters under the updater's subject succeeded")
+	}
+}
+
+func TestJetStreamDeadLettersAfterMaxDeliver(t *testing.T) {
+	jt := startJetStreamTest(t, 3)
+	jt.publish(t, "prices.ethusd", `{"pair":"ETH/USD","price":3412.57,"id":"ETH/USD-1"}`, "ETH/USD-1")
+
+	// The failed submission is redelivered until the last delivery, which is dead-lettered
+	msg := jt.deadLetter(t)
+	if deliveries := msg.Header.Get(DeadLetterDeliveriesHeader); deliveries != "3" {
+		t.Errorf("dead letter deliveries = %q, want 3", deliveries)
+	}
+	if reason := msg.Header.Get(DeadLetterReasonHeader); !strings.Contains(reason, "failed to send ETH/USD price on-chain") {
+		t.Errorf("dead letter reason = %q, want the failed submission", reason)
+	}
+	if subject := msg.Header.Get(DeadLetterSubjectHeader); subject != "prices.ethusd" {
+		t.Errorf("dead letter original subject = %q, want prices.ethusd", subject)
+	}
+	if !strings.Contains(string(msg.Data), "3412.57") {
+		t.Errorf("dead letter data = %s, want the original price", msg.Data)
+	}
+
+	info := jt.waitForAcks(t, 1)
+	if info.Delivered.Consumer != 3 {
+		t.Errorf("consumer delivered %d times, want 3", info.Delivered.Consumer)
+	}
+}
+
+func TestJetStreamDeadLettersUndecodablePrices(t *testing.T) {
+	jt := startJetStreamTest(t, 3)
+	jt.publish(t, "prices.ethusd", `{"pair":`, "ETH/USD-1")
+
+	// A message that can never be decoded is not redelivered
+	msg := jt.deadLetter(t)
+	if deliveries := msg.Header.Get(DeadLetterDeliveriesHeader); deliveries != "1" {
+		t.Errorf("dead letter deliveries = %q, want 1", deliveries)
+	}
+	if info := jt.waitForAcks(t, 1); info.Delivered.Consumer != 1 {
+		t.Errorf("consumer delivered %d times, want 1", info.Delivered.Consumer)
+	}
+}
+
+func TestJetStreamDeliversDuplicatesOnce(t *testing.T) {
+	jt := startJetStreamTest(t, 3)
+
+	// ETH/BTC has no contract, so its prices are skipped and acked on their first delivery
+	jt.publish(t, "prices.ethbtc", `{"pair":"ETH/BTC","price":0.05,"id":"ETH/BTC-1"}`, "ETH/BTC-1")
+	jt.publish(t, "prices.ethbtc", `{"pair":"ETH/BTC","price":0.05,"id":"ETH/BTC-1"}`, "ETH/BTC-1")
+	jt.publish(t, "prices.ethbtc", `{"pair":"ETH/BTC","price":0.051,"id":"ETH/BTC-2"}`, "ETH/BTC-2")
+
+	info := jt.waitForAcks(t, 2)
+	if info.Delivered.Consumer != 2 {
+		t.Errorf("consumer delivered %d messages, want 2 with the duplicate dropped", info.Delivered.Consumer)
+	}
+	select {
+	case msg := <-jt.deadLetters:
+		t.Errorf("skipped price dead-lettered: %s", msg.Header.Get(DeadLetterReasonHeader))
+	default:
+	}
+}
@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
+)
+
+const (
+	// ReasonUndecodable rejects messages that cannot be decoded
+	ReasonUndecodable = "undecodable"
+	// ReasonUnsupportedVersion rejects messages in a schema version this updater does not understand
+	ReasonUnsupportedVersion = "unsupported_version"
+	// ReasonOutdated drops messages older than the last accepted message of their pair, or than its on-chain price
+	ReasonOutdated = "outdated"
+	// ReasonReplayed rejects messages as old as the last accepted message of their pair but with another ID
+	ReasonReplayed = "replayed"
+	// ReasonStale rejects messages older than the maximum message age
+	ReasonStale = "stale"
+)
+
+// MessageRejection explains why a price message was rejected before it could be considered for submission
+type MessageRejection struct {
+	Reason string
+	Detail string
+}
+
+// Error returns the detail of the rejection
+func (r *MessageRejection) Error() string {
+	return r.Detail
+}
+
+// decodeRejection classifies a decoding error
+func decodeRejection(err error) *MessageRejection {
+	var unsupported *wire.UnsupportedVersionError
+	if errors.As(err, &unsupported) {
+		return &MessageRejection{Reason: ReasonUnsupportedVersion, Detail: err.Error()}
+	}
+	return &MessageRejection{Reason: ReasonUndecodable, Detail: err.Error()}
+}
+
+// acceptedMessage is the last message accepted for a pair
+type acceptedMessage struct {
+	timestamp time.Time
+	// id is empty when the timestamp was seeded rather than taken from an accepted message
+	id string
+}
+
+// Verifier accepts price messages signed by an allowed publisher that are neither outdated, replayed nor stale
+// A message is outdated when it is older than the last accepted message of its pair, and replayed when it is
+// as old with another ID; the same message delivered again is accepted, so JetStream redeliveries pass
+type Verifier struct {
+	keys map[string]ed25519.PublicKey
+	// maxAge is how old a message may be when it arrives, zero for no limit
+	maxAge time.Duration
+
+	mu     sync.Mutex
+	latest map[string]acceptedMessage
+}
+
+// NewVerifier creates a verifier accepting messages signed by one of the hex ed25519 public keys
+func NewVerifier(keys []string, maxAge time.Duration) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one publisher key is required")
+	}
+	if maxAge < 0 {
+		return nil, fmt.Errorf("max message age must not be negative, got: %v", maxAge)
+	}
+
+	allowed := make(map[string]ed25519.PublicKey, len(keys))
+	for _, key := range keys {
+		publicKey, err := wire.ParsePublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		allowed[hex.EncodeToString(publicKey)] = publicKey
+	}
+
+	return &Verifier{
+		keys:   allowed,
+		maxAge: maxAge,
+		latest: make(map[string]acceptedMessage),
+	}, nil
+}
+
+// Verify checks the signature, age and order of a message about pair received at now, and returns nil
+// when it is accepted, in which case it becomes the last accepted message of the pair
+func (v *Verifier) Verify(pair string, message PriceMessage, now time.Time) *MessageRejection {
+	if rejection := wire.Verify(message, v.keys); rejection != nil {
+		return &MessageRejection{Reason: rejection.Reason, Detail: rejection.Detail}
+	}
+
+	if age := now.Sub(message.Timestamp); v.maxAge > 0 && age > v.maxAge {
+		return &MessageRejection{
+			Reason: ReasonStale,
+			Detail: fmt.Sprintf("message %s is %v old, above the %v limit", message.ID, age.Round(time.Second), v.maxAge),
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if latest, ok := v.latest[pair]; ok {
+		if message.Timestamp.Before(latest.timestamp) {
+			return &MessageRejection{
+				Reason: ReasonOutdated,
+				Detail: fmt.Sprintf("message %s from %v is older than the last accepted %s price from %v",
+					message.ID, message.Timestamp, pair, latest.timestamp),
+			}
+		}
+		if message.Timestamp.Equal(latest.timestamp) && latest.id != "" && message.ID != latest.id {
+			return &MessageRejection{
+				Reason: ReasonReplayed,
+				Detail: fmt.Sprintf("message %s has the timestamp of the last accepted %s message %s", message.ID, pair, latest.id),
+			}
+		}
+	}
+
+	v.latest[pair] = acceptedMessage{timestamp: message.Timestamp, id: message.ID}
+	return nil
+}
+
+// Seed makes messages about pair from before since outdated, unless a later message was already accepted
+// It restores the order of a pair after a restart, from e.g. the time its on-chain price was last updated
+func (v *Verifier) Seed(pair string, since time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if latest, ok := v.latest[pair]; ok && !since.After(latest.timestamp) {
+		return
+	}
+	v.latest[pair] = acceptedMessage{timestamp: since}
+}
@@ -0,0 +1,201 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// testSigningKey is the publisher key of the tests
+var testSigningKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+// newTestVerifier creates a verifier accepting testSigningKey, with messages valid for maxAge
+func newTestVerifier(t *testing.T, maxAge time.Duration) *Verifier {
+	t.Helper()
+
+	verifier, err := NewVerifier([]string{hex.EncodeToString(testSigningKey.Public().(ed25519.PublicKey))}, maxAge)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	return verifier
+}
+
+// signedMessage returns an ETH/USD message with every field set, fetched at timestamp and signed with testSigningKey
+func signedMessage(id string, timestamp time.Time) PriceMessage {
+	message := PriceMessage{
+		Pair:            "ETH/USD",
+		Price:           3412.57,
+		Timestamp:       timestamp,
+		Source:          "aggregated",
+		ID:              id,
+		PriceUnits:      "341257000000",
+		Decimals:        8,
+		ObservedAt:      timestamp.Add(-time.Second),
+		RecordID:        42,
+		ConfidenceLower: 3410.1,
+		ConfidenceUpper: 3415.2,
+		StdError:        1.3,
+	}
+	wire.Sign(&message, testSigningKey)
+	return message
+}
+
+func TestVerifierRejectsTamperedFields(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		tamper func(*PriceMessage)
+	}{
+		{name: "pair", tamper: func(m *PriceMessage) { m.Pair = "BTC/USD" }},
+		{name: "price", tamper: func(m *PriceMessage) { m.Price = 3412.58 }},
+		{name: "price units", tamper: func(m *PriceMessage) { m.PriceUnits = "341258000000" }},
+		{name: "decimals", tamper: func(m *PriceMessage) { m.Decimals = 6 }},
+		{name: "timestamp", tamper: func(m *PriceMessage) { m.Timestamp = m.Timestamp.Add(time.Nanosecond) }},
+		{name: "observed at", tamper: func(m *PriceMessage) { m.ObservedAt = time.Time{} }},
+		{name: "source", tamper: func(m *PriceMessage) { m.Source = "binance" }},
+		{name: "derived", tamper: func(m *PriceMessage) { m.Derived = true }},
+		{name: "id", tamper: func(m *PriceMessage) { m.ID = "ETH/USD-2" }},
+		{name: "record id", tamper: func(m *PriceMessage) { m.RecordID = 43 }},
+		{name: "confidence lower", tamper: func(m *PriceMessage) { m.ConfidenceLower = 3000 }},
+		{name: "confidence upper", tamper: func(m *PriceMessage) { m.ConfidenceUpper = 3412.58 }},
+		{name: "standard error", tamper: func(m *PriceMessage) { m.StdError = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := signedMessage("ETH/USD-1", now)
+			tt.tamper(&message)
+
+			rejection := newTestVerifier(t, 0).Verify("ETH/USD", message, now)
+			if rejection == nil || rejection.Reason != wire.ReasonBadSignature {
+				t.Errorf("Verify() of a message with a tampered %s = %v, want a %s rejection", tt.name, rejection, wire.ReasonBadSignature)
+			}
+		})
+	}
+}
+
+func TestVerifierAcceptsEncodedMessages(t *testing.T) {
+	now := time.Now()
+
+	for _, encoding := range []wire.Encoding{wire.EncodingJSON, wire.EncodingCBOR} {
+		data, err := wire.Encode(signedMessage("ETH/USD-1", now), encoding)
+		if err != nil {
+			t.Fatalf("Encode(%s) error = %v", encoding, err)
+		}
+		message, err := wire.Decode(data, encoding.ContentType())
+		if err != nil {
+			t.Fatalf("Decode(%s) error = %v", encoding, err)
+		}
+		if rejection := newTestVerifier(t, 0).Verify("ETH/USD", message, now); rejection != nil {
+			t.Errorf("Verify() of a %s message = %v, want accepted", encoding, rejection)
+		}
+	}
+}
+
+func TestVerifierOrdersMessages(t *testing.T) {
+	verifier := newTestVerifier(t, 10*time.Minute)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		message PriceMessage
+		reason  string
+	}{
+		{name: "first", message: signedMessage("ETH/USD-2", now)},
+		{name: "redelivered", message: signedMessage("ETH/USD-2", now)},
+		{name: "older", message: signedMessage("ETH/USD-1", now.Add(-time.Second)), reason: ReasonOutdated},
+		{name: "as old with another ID", message: signedMessage("ETH/USD-3", now), reason: ReasonReplayed},
+		{name: "newer", message: signedMessage("ETH/USD-4", now.Add(time.Second))},
+		{name: "stale", message: signedMessage("ETH/USD-5", now.Add(-time.Hour)), reason: ReasonStale},
+	}
+
+	for _, tt := range tests {
+		rejection := verifier.Verify("ETH/USD", tt.message, now)
+		if tt.reason == "" {
+			if rejection != nil {
+				t.Errorf("Verify(%s) = %v, want accepted", tt.name, rejection)
+			}
+			continue
+		}
+		if rejection == nil || rejection.Reason != tt.reason {
+			t.Errorf("Verify(%s) = %v, want a %s rejection", tt.name, rejection, tt.reason)
+		}
+	}
+}
+
+func TestVerifierSeedRestoresOrder(t *testing.T) {
+	verifier := newTestVerifier(t, 0)
+	updatedAt := time.Now().Truncate(time.Second)
+	verifier.Seed("ETH/USD", updatedAt)
+
+	if rejection := verifier.Verify("ETH/USD", signedMessage("ETH/USD-1", updatedAt.Add(-time.Second)), updatedAt); rejection == nil || rejection.Reason != ReasonOutdated {
+		t.Errorf("Verify() of a message from before the seed = %v, want a %s rejection", rejection, ReasonOutdated)
+	}
+	// The seed has no ID, so any message from that very time is accepted
+	if rejection := verifier.Verify("ETH/USD", signedMessage("ETH/USD-2", updatedAt), updatedAt); rejection != nil {
+		t.Errorf("Verify() of a message from the seeded time = %v, want accepted", rejection)
+	}
+
+	// An earlier seed does not move the order back
+	verifier.Seed("ETH/USD", updatedAt.Add(-time.Hour))
+	if rejection := verifier.Verify("ETH/USD", signedMessage("ETH/USD-3", updatedAt.Add(-time.Minute)), updatedAt); rejection == nil {
+		t.Error("Verify() of a message from before the accepted one succeeded after an earlier seed")
+	}
+}
+
+func TestSeedMessageOrderAllowsClockSkew(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0)
+	if err := u.RequireSignatures([]string{hex.EncodeToString(testSigningKey.Public().(ed25519.PublicKey))}, 0); err != nil {
+		t.Fatalf("RequireSignatures() error = %v", err)
+	}
+
+	// The block was mined 20s after the backend fetched the price, by its own clock
+	updatedAt := time.Now().Truncate(time.Second)
+	u.SeedMessageOrder("ETH/USD", updatedAt, DefaultSeedSkewMargin)
+
+	verifier := u.messageVerifier()
+	if rejection := verifier.Verify("ETH/USD", signedMessage("ETH/USD-1", updatedAt.Add(-2*DefaultSeedSkewMargin)), updatedAt); rejection == nil || rejection.Reason != ReasonOutdated {
+		t.Errorf("Verify() of a message from before the margin = %v, want a %s rejection", rejection, ReasonOutdated)
+	}
+	if rejection := verifier.Verify("ETH/USD", signedMessage("ETH/USD-2", updatedAt.Add(-20*time.Second)), updatedAt); rejection != nil {
+		t.Errorf("Verify() of a message within the margin = %v, want accepted", rejection)
+	}
+}
+
+func TestPreparePriceUpdateDropsOutdatedMessages(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0)
+	if err := u.SetContracts(map[string]common.Address{"ETH/USD": ethUSDContract}); err != nil {
+		t.Fatalf("SetContracts() error = %v", err)
+	}
+	if err := u.RequireSignatures([]string{hex.EncodeToString(testSigningKey.Public().(ed25519.PublicKey))}, 0); err != nil {
+		t.Fatalf("RequireSignatures() error = %v", err)
+	}
+	now := time.Now()
+
+	prepare := func(message PriceMessage) (*priceUpdate, error) {
+		data, err := wire.Encode(message, wire.EncodingJSON)
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		return u.preparePriceUpdate(data, wire.ContentTypeJSON)
+	}
+
+	if update, err := prepare(signedMessage("ETH/USD-2", now)); err != nil || update == nil {
+		t.Fatalf("preparePriceUpdate() = %+v, %v, want an update", update, err)
+	}
+
+	// An older message, such as a redelivery overtaken by a newer price, is skipped rather than failed
+	if update, err := prepare(signedMessage("ETH/USD-1", now.Add(-time.Second))); err != nil || update != nil {
+		t.Errorf("preparePriceUpdate() of an outdated message = %+v, %v, want it skipped", update, err)
+	}
+
+	// A conflicting message with the same timestamp still fails, so it is dead-lettered
+	if _, err := prepare(signedMessage("ETH/USD-3", now)); err == nil {
+		t.Error("preparePriceUpdate() of a replayed message succeeded")
+	}
+}
@@ -2,32 +2,92 @@ package updater
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/114windd/DeFiOraclePipeline.git/updater/pkg/ethclient"
+	"github.com/114windd/DeFiOraclePipeline.git/updater/pkg/metrics"
+	"github.com/114windd/DeFiOraclePipeline.git/wire"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/nats-io/nats.go"
 )
 
-// PriceMessage represents a price message from NATS
-type PriceMessage struct {
-	Price     float64   `json:"price"`
-	Timestamp time.Time `json:"timestamp"`
-	Source    string    `json:"source"`
-	ID        string    `json:"id"`
-}
+// DefaultPair is assumed for messages published before prices carried a pair
+const DefaultPair = "ETH/USD"
+
+// DefaultSeedSkewMargin is how far before a contract's last update the message order resumes after a restart
+const DefaultSeedSkewMargin = time.Minute
+
+const (
+	// DefaultMinPrice is the lowest price pushed on-chain when neither bounds nor the contract's parameters are known
+	DefaultMinPrice = 1
+	// DefaultMaxPrice is the highest price pushed on-chain when neither bounds nor the contract's parameters are known
+	DefaultMaxPrice = 1000000
+)
+
+// PriceDecimals is the default number of decimals of prices in the Oracle contract, its PRICE_DECIMALS
+const PriceDecimals = 8
+
+// ConfidenceAction selects what happens to prices whose confidence band is wider than allowed
+type ConfidenceAction string
+
+const (
+	// ConfidenceSuppress drops prices with a too wide band instead of submitting them
+	ConfidenceSuppress ConfidenceAction = "suppress"
+	// ConfidenceFlag submits prices with a too wide band but logs a warning
+	ConfidenceFlag ConfidenceAction = "flag"
+)
+
+// PriceMessage represents a price message from NATS, in the schema shared with the backend
+type PriceMessage = wire.PriceMessage
 
 // Updater handles consuming price updates and submitting them to the blockchain
 type Updater struct {
 	conn      *nats.Conn
 	ethClient *ethclient.EthClient
 	subject   string
-	lastPrice float64
 	threshold float64
 	ctx       context.Context
 	cancel    context.CancelFunc
+
+	mu             sync.Mutex
+	lastPrices     map[string]float64
+	pairThresholds map[string]float64
+	// pairs limits which pairs are pushed on-chain; empty means every pair with a contract
+	pairs map[string]bool
+	// contracts maps each pair to the Oracle contract its prices are written to; pairs without one are skipped
+	contracts map[string]common.Address
+	// maxConfidenceWidth is the widest relative confidence band accepted, zero for no limit
+	maxConfidenceWidth  float64
+	pairConfidenceWidth map[string]float64
+	confidenceAction    ConfidenceAction
+	// decimals is the contract's PRICE_DECIMALS prices are submitted in
+	decimals int
+	// minPrice and maxPrice narrow the prices pushed on-chain, overridable per pair; zero leaves the bound to the contract
+	minPrice      float64
+	maxPrice      float64
+	pairMinPrices map[string]float64
+	pairMaxPrices map[string]float64
+	// pairParams are the constants of each pair's contract, which bound its prices at contract decimals
+	pairParams map[string]ethclient.ContractParams
+	// consumer is set when prices are read through a JetStream durable consumer instead of core NATS
+	consumer *ConsumerConfig
+	// retryBackoff is the delay before the first retry of a failed submission, doubled on every further retry
+	retryBackoff time.Duration
+	// verifier rejects unsigned, tampered and replayed messages once publisher keys are configured
+	verifier *Verifier
+	metrics  *metrics.Metrics
+}
+
+// priceUpdate is a price accepted for submission on-chain
+type priceUpdate struct {
+	pair    string
+	message PriceMessage
+	price   fixedpoint.Price
 }
 
 // NewUpdater creates a new updater instance
@@ -37,6 +97,11 @@ func NewUpdater(natsURL, subject string, ethClient *ethclient.EthClient, thresho
 		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
 	}
 
+	return newUpdater(conn, subject, ethClient, threshold), nil
+}
+
+// newUpdater creates an updater consuming from conn
+func newUpdater(conn *nats.Conn, subject string, ethClient *ethclient.EthClient, threshold float64) *Updater {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Updater{
@@ -46,11 +111,311 @@ func NewUpdater(natsURL, subject string, ethClient *ethclient.EthClient, thresho
 		threshold: threshold,
 		ctx:       ctx,
 		cancel:    cancel,
-	}, nil
+
+		lastPrices:     make(map[string]float64),
+		pairThresholds: make(map[string]float64),
+		pairs:          make(map[string]bool),
+		contracts:      make(map[string]common.Address),
+
+		pairConfidenceWidth: make(map[string]float64),
+		confidenceAction:    ConfidenceSuppress,
+
+		decimals:      PriceDecimals,
+		pairMinPrices: make(map[string]float64),
+		pairMaxPrices: make(map[string]float64),
+		pairParams:    make(map[string]ethclient.ContractParams),
+
+		retryBackoff: 2 * time.Second,
+	}
+}
+
+// NormalizePair returns a pair in the canonical "BASE/QUOTE" form, accepting "-" and "_" as separators
+func NormalizePair(pair string) string {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	return strings.NewReplacer("-", "/", "_", "/").Replace(pair)
+}
+
+// SetPairs limits the pairs pushed on-chain; an empty list allows every pair with a contract
+// Every listed pair must already have a contract
+func (u *Updater) SetPairs(pairs []string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	allowed := make(map[string]bool)
+	for _, pair := range pairs {
+		pair = NormalizePair(pair)
+		if _, ok := u.contracts[pair]; !ok {
+			return fmt.Errorf("%s has no oracle contract", pair)
+		}
+		allowed[pair] = true
+	}
+	u.pairs = allowed
+	return nil
+}
+
+// SetContracts sets the Oracle contract each pair's prices are written to
+// Each contract holds a single price, so two pairs may not share one
+func (u *Updater) SetContracts(contracts map[string]common.Address) error {
+	if len(contracts) == 0 {
+		return fmt.Errorf("at least one pair needs an oracle contract")
+	}
+
+	normalized := make(map[string]common.Address, len(contracts))
+	owners := make(map[common.Address]string, len(contracts))
+	for pair, contract := range contracts {
+		pair = NormalizePair(pair)
+		if contract == (common.Address{}) {
+			return fmt.Errorf("%s has the zero address as its oracle contract", pair)
+		}
+		if owner, ok := owners[contract]; ok {
+			return fmt.Errorf("%s and %s both write to oracle contract %s; each pair needs its own", owner, pair, contract.Hex())
+		}
+		owners[contract] = pair
+		normalized[pair] = contract
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.contracts = normalized
+	return nil
+}
+
+// GetContract returns the Oracle contract prices of pair are written to
+func (u *Updater) GetContract(pair string) (common.Address, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	contract, ok := u.contracts[NormalizePair(pair)]
+	return contract, ok
+}
+
+// Contracts returns the Oracle contract of every pair pushed on-chain
+func (u *Updater) Contracts() map[string]common.Address {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	contracts := make(map[string]common.Address, len(u.contracts))
+	for pair, contract := range u.contracts {
+		if len(u.pairs) == 0 || u.pairs[pair] {
+			contracts[pair] = contract
+		}
+	}
+	return contracts
+}
+
+// SetPairThreshold overrides the price change threshold of one pair
+func (u *Updater) SetPairThreshold(pair string, threshold float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pairThresholds[NormalizePair(pair)] = threshold
+}
+
+// RequireSignatures rejects messages unless they are signed by one of keys, hex ed25519 public keys,
+// and are neither replayed nor older than maxAge; a zero maxAge accepts messages of any age
+func (u *Updater) RequireSignatures(keys []string, maxAge time.Duration) error {
+	verifier, err := NewVerifier(keys, maxAge)
+	if err != nil {
+		return fmt.Errorf("invalid publisher keys: %w", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.verifier = verifier
+	return nil
+}
+
+// messageVerifier returns the message verifier, or nil when signatures are not required
+func (u *Updater) messageVerifier() *Verifier {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.verifier
+}
+
+// SeedMessageOrder makes signed messages about pair from more than margin before updatedAt outdated, so messages
+// older than the price already on-chain are not submitted again after a restart; it has no effect without publisher keys
+// updatedAt is a block timestamp while messages carry the backend's fetch time, so margin covers the skew between
+// the two clocks and the time a price takes to be mined, at the cost of resubmitting prices up to margin older
+func (u *Updater) SeedMessageOrder(pair string, updatedAt time.Time, margin time.Duration) {
+	if verifier := u.messageVerifier(); verifier != nil {
+		verifier.Seed(NormalizePair(pair), updatedAt.Add(-margin))
+	}
 }
 
-// Start begins consuming price updates from NATS
+// SetMetrics records message verification in m
+func (u *Updater) SetMetrics(m *metrics.Metrics) {
+	u.metrics = m
+}
+
+// SetDecimals sets the number of decimals prices are submitted in when the contract's PRICE_DECIMALS is not known
+func (u *Updater) SetDecimals(decimals int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.decimals = decimals
+}
+
+// GetDecimals returns the number of decimals prices of pair are submitted in, its contract's PRICE_DECIMALS once known
+func (u *Updater) GetDecimals(pair string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if params, ok := u.pairParams[NormalizePair(pair)]; ok {
+		return params.Decimals
+	}
+	return u.decimals
+}
+
+// SetContractParams sets the constants of the contract of pair, whose decimals and price range then apply to the pair
+// It returns an error when a configured bound of the pair lies outside the contract's range
+func (u *Updater) SetContractParams(pair string, params ethclient.ContractParams) error {
+	if params.MaxPrice.Sign() <= 0 || params.MaxPrice.Cmp(params.MinPrice) < 0 {
+		return fmt.Errorf("%s contract has an invalid price range", pair)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	pair = NormalizePair(pair)
+	minPrice, maxPrice := u.configuredBounds(pair)
+	if minPrice != 0 {
+		bound, err := fixedpoint.FromFloat(minPrice, params.Decimals)
+		if err != nil {
+			return fmt.Errorf("invalid %s min price: %w", pair, err)
+		}
+		if bound.Cmp(params.MinPrice) < 0 {
+			return fmt.Errorf("%s min price %s is below the contract's MIN_PRICE %s", pair, bound, params.MinPrice)
+		}
+	}
+	if maxPrice != 0 {
+		bound, err := fixedpoint.FromFloat(maxPrice, params.Decimals)
+		if err != nil {
+			return fmt.Errorf("invalid %s max price: %w", pair, err)
+		}
+		if bound.Cmp(params.MaxPrice) > 0 {
+			return fmt.Errorf("%s max price %s is above the contract's MAX_PRICE %s", pair, bound, params.MaxPrice)
+		}
+	}
+
+	u.pairParams[pair] = params
+	return nil
+}
+
+// contractParams returns the constants of the contract of pair, when known
+func (u *Updater) contractParams(pair string) (ethclient.ContractParams, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	params, ok := u.pairParams[pair]
+	return params, ok
+}
+
+// SetPriceBounds narrows the prices pushed on-chain for every pair; zero leaves a bound to the contract
+func (u *Updater) SetPriceBounds(minPrice, maxPrice float64) error {
+	if minPrice < 0 || maxPrice < 0 || (maxPrice != 0 && maxPrice <= minPrice) {
+		return fmt.Errorf("min price must not be negative and must be below the max price, got: %f and %f", minPrice, maxPrice)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.minPrice = minPrice
+	u.maxPrice = maxPrice
+	return nil
+}
+
+// SetPairMinPrice overrides the lowest price of one pair pushed on-chain
+func (u *Updater) SetPairMinPrice(pair string, minPrice float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pairMinPrices[NormalizePair(pair)] = minPrice
+}
+
+// SetPairMaxPrice overrides the highest price of one pair pushed on-chain
+func (u *Updater) SetPairMaxPrice(pair string, maxPrice float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pairMaxPrices[NormalizePair(pair)] = maxPrice
+}
+
+// configuredBounds returns the bounds of pair set by the operator, zero when unset
+func (u *Updater) configuredBounds(pair string) (float64, float64) {
+	minPrice, ok := u.pairMinPrices[pair]
+	if !ok {
+		minPrice = u.minPrice
+	}
+	maxPrice, ok := u.pairMaxPrices[pair]
+	if !ok {
+		maxPrice = u.maxPrice
+	}
+	return minPrice, maxPrice
+}
+
+// GetPriceBounds returns the configured lowest and highest price of pair pushed on-chain, zero when the bound is
+// left to the pair's contract; without contract parameters, unset bounds are DefaultMinPrice and DefaultMaxPrice
+func (u *Updater) GetPriceBounds(pair string) (float64, float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	pair = NormalizePair(pair)
+	minPrice, maxPrice := u.configuredBounds(pair)
+	if _, ok := u.pairParams[pair]; !ok {
+		if minPrice == 0 {
+			minPrice = DefaultMinPrice
+		}
+		if maxPrice == 0 {
+			maxPrice = DefaultMaxPrice
+		}
+	}
+	return minPrice, maxPrice
+}
+
+// ParseConfidenceAction parses the name of a confidence action
+func ParseConfidenceAction(name string) (ConfidenceAction, error) {
+	switch action := ConfidenceAction(strings.ToLower(strings.TrimSpace(name))); action {
+	case ConfidenceSuppress, ConfidenceFlag:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown confidence action %q", name)
+}
+
+// SetConfidencePolicy limits the relative width of the confidence band of submitted prices
+// A zero maxWidth accepts any band; action decides whether wider prices are suppressed or only flagged
+func (u *Updater) SetConfidencePolicy(maxWidth float64, action ConfidenceAction) error {
+	if maxWidth < 0 {
+		return fmt.Errorf("max confidence width must not be negative, got: %f", maxWidth)
+	}
+	if _, err := ParseConfidenceAction(string(action)); err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.maxConfidenceWidth = maxWidth
+	u.confidenceAction = action
+	return nil
+}
+
+// SetPairConfidenceWidth overrides the widest relative confidence band accepted for one pair
+func (u *Updater) SetPairConfidenceWidth(pair string, maxWidth float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pairConfidenceWidth[NormalizePair(pair)] = maxWidth
+}
+
+// Start begins consuming price updates from NATS, through the durable consumer if JetStream is enabled
 func (u *Updater) Start() error {
+	if config := u.consumerConfig(); config != nil {
+		return u.startJetStream(*config)
+	}
+
 	log.Printf("Starting updater worker for subject: %s", u.subject)
 
 	// Subscribe to price updates
@@ -74,41 +439,152 @@ func (u *Updater) Stop() {
 
 // handlePriceMessage processes incoming price messages
 func (u *Updater) handlePriceMessage(m *nats.Msg) {
-	var priceMsg PriceMessage
-	if err := json.Unmarshal(m.Data, &priceMsg); err != nil {
-		log.Printf("Failed to unmarshal price message: %v", err)
+	update, err := u.preparePriceUpdate(m.Data, m.Header.Get(wire.ContentTypeHeader))
+	if err != nil {
+		log.Printf("Dropping price message: %v", err)
+		return
+	}
+	if update == nil {
 		return
 	}
 
-	log.Printf("Received price update: $%.2f from %s", priceMsg.Price, priceMsg.Source)
+	// Submit to blockchain
+	txHash, err := u.SendPriceOnChain(update.pair, update.price)
+	if err != nil {
+		log.Printf("Failed to send %s price on-chain: %v", update.pair, err)
+		// Retry with exponential backoff
+		go u.retryFailedTx(update.pair, update.message.Price, update.price, 1)
+		return
+	}
+
+	u.priceSubmitted(update, txHash)
+}
+
+// preparePriceUpdate decodes a price message sent with contentType and decides whether it is submitted on-chain
+// It returns nil when the price is skipped, and an error when the message can never be submitted,
+// including messages in a schema version this updater does not understand
+func (u *Updater) preparePriceUpdate(data []byte, contentType string) (*priceUpdate, error) {
+	priceMsg, err := wire.Decode(data, contentType)
+	if err != nil {
+		rejection := decodeRejection(err)
+		u.recordRejection("unknown", rejection.Reason)
+		return nil, rejection
+	}
+
+	pair := DefaultPair
+	if priceMsg.Pair != "" {
+		pair = NormalizePair(priceMsg.Pair)
+	}
+
+	if !u.servesPair(pair) {
+		return nil, nil
+	}
+
+	// Only prices signed by an allowed publisher, and not replayed, may reach the chain
+	if verifier := u.messageVerifier(); verifier != nil {
+		if rejection := verifier.Verify(pair, priceMsg, time.Now()); rejection != nil {
+			u.recordRejection(pair, rejection.Reason)
+			// A message overtaken by a newer price is only late, such as a redelivery after a failed submission
+			if rejection.Reason == ReasonOutdated {
+				log.Printf("Dropping %s message: %v", pair, rejection)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%s message rejected as %s: %w", pair, rejection.Reason, rejection)
+		}
+		if u.metrics != nil {
+			u.metrics.RecordMessageVerified(pair)
+		}
+	}
+
+	log.Printf("Received %s price update: %.2f from %s", pair, priceMsg.Price, priceMsg.Source)
 
 	// Filter price update
-	if !u.FilterPriceUpdate(priceMsg.Price, u.lastPrice) {
-		log.Printf("Price change below threshold, skipping update")
-		return
+	if !u.FilterPairPriceUpdate(pair, priceMsg.Price, u.GetLastPrice(pair)) {
+		log.Printf("%s price change below threshold, skipping update", pair)
+		return nil, nil
+	}
+
+	fixedPrice, err := priceMsg.FixedPrice(u.GetDecimals(pair))
+	if err != nil {
+		return nil, fmt.Errorf("%s price cannot be converted to contract units: %w", pair, err)
 	}
 
 	// Validate price
-	if err := u.validatePrice(priceMsg.Price); err != nil {
-		log.Printf("Price validation failed: %v", err)
-		return
+	if err := u.validatePrice(pair, priceMsg.Price, fixedPrice); err != nil {
+		log.Printf("%s price validation failed: %v", pair, err)
+		return nil, nil
 	}
 
-	// Submit to blockchain
-	txHash, err := u.SendPriceOnChain(priceMsg.Price)
-	if err != nil {
-		log.Printf("Failed to send price on-chain: %v", err)
-		// Retry with exponential backoff
-		go u.retryFailedTx(priceMsg.Price, 1)
-		return
+	// Hold back or flag prices the sources disagreed too much on
+	if !u.checkConfidence(pair, priceMsg) {
+		return nil, nil
+	}
+
+	return &priceUpdate{pair: pair, message: priceMsg, price: fixedPrice}, nil
+}
+
+// priceSubmitted records a price submitted on-chain in transaction txHash
+func (u *Updater) priceSubmitted(update *priceUpdate, txHash string) {
+	log.Printf("Successfully submitted %s price update from record %d. TX: %s", update.pair, update.message.RecordID, txHash)
+	u.setLastPrice(update.pair, update.message.Price)
+}
+
+// recordRejection counts a rejected message when metrics are enabled
+func (u *Updater) recordRejection(pair, reason string) {
+	if u.metrics != nil {
+		u.metrics.RecordMessageRejected(pair, reason)
+	}
+}
+
+// servesPair reports whether updates of pair are pushed on-chain: the pair needs a contract and,
+// when pairs are limited, must be one of them
+func (u *Updater) servesPair(pair string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.contracts[pair]; !ok {
+		return false
+	}
+	return len(u.pairs) == 0 || u.pairs[pair]
+}
+
+// checkConfidence compares the confidence band of a price with the pair's limit and reports whether it may be submitted
+func (u *Updater) checkConfidence(pair string, priceMsg PriceMessage) bool {
+	width, ok := priceMsg.ConfidenceWidth()
+	if !ok {
+		return true
+	}
+
+	u.mu.Lock()
+	maxWidth, found := u.pairConfidenceWidth[pair]
+	if !found {
+		maxWidth = u.maxConfidenceWidth
+	}
+	action := u.confidenceAction
+	u.mu.Unlock()
+
+	if maxWidth == 0 || width <= maxWidth {
+		return true
+	}
+
+	if action == ConfidenceFlag {
+		log.Printf("Warning: %s price %.2f has a confidence band of %.4f%% [%.8g, %.8g], above the %.4f%% limit",
+			pair, priceMsg.Price, width*100, priceMsg.ConfidenceLower, priceMsg.ConfidenceUpper, maxWidth*100)
+		return true
 	}
 
-	log.Printf("Successfully submitted price update. TX: %s", txHash)
-	u.lastPrice = priceMsg.Price
+	log.Printf("Suppressing %s price %.2f: confidence band of %.4f%% [%.8g, %.8g] exceeds the %.4f%% limit",
+		pair, priceMsg.Price, width*100, priceMsg.ConfidenceLower, priceMsg.ConfidenceUpper, maxWidth*100)
+	return false
 }
 
 // FilterPriceUpdate checks if the new price should be pushed (e.g., >0.5% change)
 func (u *Updater) FilterPriceUpdate(newPrice, lastPrice float64) bool {
+	return u.FilterPairPriceUpdate(DefaultPair, newPrice, lastPrice)
+}
+
+// FilterPairPriceUpdate checks if the new price of pair moved at least the pair's threshold
+func (u *Updater) FilterPairPriceUpdate(pair string, newPrice, lastPrice float64) bool {
 	if lastPrice == 0 {
 		return true // Always update if no previous price
 	}
@@ -118,22 +594,39 @@ func (u *Updater) FilterPriceUpdate(newPrice, lastPrice float64) bool {
 		change = -change // Make it positive
 	}
 
-	return change >= u.threshold
+	return change >= u.pairThreshold(pair)
 }
 
-// validatePrice checks if the price is within reasonable bounds
-func (u *Updater) validatePrice(price float64) error {
+// pairThreshold returns the threshold of pair, falling back to the default threshold
+func (u *Updater) pairThreshold(pair string) float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if threshold, ok := u.pairThresholds[pair]; ok {
+		return threshold
+	}
+	return u.threshold
+}
+
+// validatePrice checks if the price, also given at contract decimals, is within reasonable bounds
+func (u *Updater) validatePrice(pair string, price float64, fixedPrice fixedpoint.Price) error {
 	if price <= 0 {
 		return fmt.Errorf("price must be positive, got: %f", price)
 	}
 
-	// Check for unreasonably high prices (e.g., > $1M per ETH)
-	if price > 1000000 {
-		return fmt.Errorf("price seems unreasonably high: %f", price)
+	// The contract's range is compared at its own decimals, so a price it would revert on is never sent
+	if params, ok := u.contractParams(pair); ok {
+		if err := params.CheckPrice(fixedPrice); err != nil {
+			return err
+		}
 	}
 
-	// Check for unreasonably low prices (e.g., < $1 per ETH)
-	if price < 1 {
+	// Bounds are configured per pair, since a $1 floor makes no sense for e.g. ETH/BTC
+	minPrice, maxPrice := u.GetPriceBounds(pair)
+	if maxPrice != 0 && price > maxPrice {
+		return fmt.Errorf("price seems unreasonably high: %f", price)
+	}
+	if price < minPrice {
 		return fmt.Errorf("price seems unreasonably low: %f", price)
 	}
 
@@ -143,16 +636,22 @@ func (u *Updater) validatePrice(price float64) error {
 	return nil
 }
 
-// SendPriceOnChain submits a transaction to update the price on the Solidity contract
-func (u *Updater) SendPriceOnChain(price float64) (string, error) {
+// SendPriceOnChain submits a transaction to update the price of pair on its Oracle contract
+// The price is rounded to the contract's decimals
+func (u *Updater) SendPriceOnChain(pair string, price fixedpoint.Price) (string, error) {
 	if u.ethClient == nil {
 		return "", fmt.Errorf("Ethereum client not initialized")
 	}
+	units := price.Rescale(u.GetDecimals(pair)).Units()
+	if units.Sign() <= 0 {
+		return "", fmt.Errorf("price must be positive, got %s units", units)
+	}
+	contract, ok := u.GetContract(pair)
+	if !ok {
+		return "", fmt.Errorf("%s has no oracle contract", pair)
+	}
 
-	// Convert price to integer with 8 decimal precision
-	priceInt := int64(price * 100000000) // 8 decimal places
-
-	txHash, err := u.ethClient.UpdatePrice(priceInt)
+	txHash, err := u.ethClient.UpdatePrice(contract, units)
 	if err != nil {
 		return "", fmt.Errorf("failed to update price on-chain: %w", err)
 	}
@@ -161,41 +660,63 @@ func (u *Updater) SendPriceOnChain(price float64) (string, error) {
 }
 
 // retryFailedTx retries failed Ethereum transactions with exponential backoff
-func (u *Updater) retryFailedTx(price float64, attempt int) {
+func (u *Updater) retryFailedTx(pair string, price float64, fixedPrice fixedpoint.Price, attempt int) {
 	if attempt > 5 { // Max 5 retries
-		log.Printf("Max retries reached for price update: $%.2f", price)
+		log.Printf("Max retries reached for %s price update: %.2f", pair, price)
 		return
 	}
 
 	// Exponential backoff: 2^attempt seconds
-	delay := time.Duration(1<<uint(attempt)) * time.Second
+	delay := u.retryDelay(attempt)
 	log.Printf("Retrying price update in %v (attempt %d)", delay, attempt)
 
 	time.Sleep(delay)
 
-	txHash, err := u.SendPriceOnChain(price)
+	txHash, err := u.SendPriceOnChain(pair, fixedPrice)
 	if err != nil {
 		log.Printf("Retry %d failed: %v", attempt, err)
-		go u.retryFailedTx(price, attempt+1)
+		go u.retryFailedTx(pair, price, fixedPrice, attempt+1)
 		return
 	}
 
 	log.Printf("Retry %d successful. TX: %s", attempt, txHash)
-	u.lastPrice = price
+	u.setLastPrice(pair, price)
 }
 
-// GetLastPrice returns the last processed price
-func (u *Updater) GetLastPrice() float64 {
-	return u.lastPrice
+// retryDelay returns the exponential backoff before retry attempt of a failed submission
+func (u *Updater) retryDelay(attempt int) time.Duration {
+	return u.retryBackoff << uint(min(attempt, 8)-1)
+}
+
+// GetLastPrice returns the last processed price of pair
+func (u *Updater) GetLastPrice(pair string) float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.lastPrices[NormalizePair(pair)]
 }
 
-// SetThreshold sets the price change threshold
+// setLastPrice records the last price of pair pushed on-chain
+func (u *Updater) setLastPrice(pair string, price float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.lastPrices[pair] = price
+}
+
+// SetThreshold sets the default price change threshold
 func (u *Updater) SetThreshold(threshold float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	u.threshold = threshold
 }
 
-// GetThreshold returns the current threshold
+// GetThreshold returns the default threshold
 func (u *Updater) GetThreshold() float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	return u.threshold
 }
 
@@ -0,0 +1,171 @@
+package updater
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/updater/pkg/ethclient"
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ethUSDContract = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	btcUSDContract = common.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512")
+)
+
+func TestSetContractsRejectsSharedContracts(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0.005)
+
+	err := u.SetContracts(map[string]common.Address{"ETH/USD": ethUSDContract, "ETH/BTC": ethUSDContract})
+	if err == nil || !strings.Contains(err.Error(), "each pair needs its own") {
+		t.Errorf("SetContracts() with a shared contract error = %v, want a shared contract error", err)
+	}
+
+	if err := u.SetContracts(nil); err == nil {
+		t.Error("SetContracts() without contracts succeeded")
+	}
+	if err := u.SetContracts(map[string]common.Address{"ETH/USD": {}}); err == nil {
+		t.Error("SetContracts() with the zero address succeeded")
+	}
+}
+
+func TestUpdaterServesOnlyPairsWithContracts(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0.005)
+	if err := u.SetContracts(map[string]common.Address{"eth-usd": ethUSDContract, "BTC/USD": btcUSDContract}); err != nil {
+		t.Fatalf("SetContracts() error = %v", err)
+	}
+
+	tests := []struct {
+		pair   string
+		served bool
+	}{
+		{pair: "ETH/USD", served: true},
+		{pair: "BTC/USD", served: true},
+		// Cross rates have no contract of their own, so they never reach the ETH/USD contract
+		{pair: "ETH/BTC", served: false},
+	}
+	for _, tt := range tests {
+		if served := u.servesPair(tt.pair); served != tt.served {
+			t.Errorf("servesPair(%s) = %v, want %v", tt.pair, served, tt.served)
+		}
+	}
+
+	if err := u.SetPairs([]string{"ETH/USD", "ETH/BTC"}); err == nil {
+		t.Error("SetPairs() with a pair without a contract succeeded")
+	}
+	if err := u.SetPairs([]string{"BTC/USD"}); err != nil {
+		t.Fatalf("SetPairs() error = %v", err)
+	}
+	if u.servesPair("ETH/USD") {
+		t.Error("servesPair(ETH/USD) = true after limiting pairs to BTC/USD")
+	}
+	if contracts := u.Contracts(); len(contracts) != 1 || contracts["BTC/USD"] != btcUSDContract {
+		t.Errorf("Contracts() = %v, want only the BTC/USD contract", contracts)
+	}
+}
+
+func TestPreparePriceUpdateSkipsPairsWithoutContracts(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0.005)
+	if err := u.SetContracts(map[string]common.Address{"ETH/USD": ethUSDContract}); err != nil {
+		t.Fatalf("SetContracts() error = %v", err)
+	}
+
+	update, err := u.preparePriceUpdate([]byte(`{"pair":"ETH/BTC","price":0.05,"id":"ETH/BTC-1"}`), "")
+	if err != nil || update != nil {
+		t.Errorf("preparePriceUpdate(ETH/BTC) = %+v, %v, want it skipped", update, err)
+	}
+
+	update, err = u.preparePriceUpdate([]byte(`{"pair":"ETH/USD","price":3000,"id":"ETH/USD-1"}`), "")
+	if err != nil {
+		t.Fatalf("preparePriceUpdate(ETH/USD) error = %v", err)
+	}
+	if update == nil || update.price.Units().Cmp(big.NewInt(300000000000)) != 0 || update.price.Decimals() != 8 {
+		t.Errorf("preparePriceUpdate(ETH/USD) = %+v, want 3000 in 8-decimal units", update)
+	}
+}
+
+// oracleParams are the constants of the Oracle contract: 8 decimals and prices in [$1, $1M]
+var oracleParams = ethclient.ContractParams{
+	Decimals: 8,
+	MinPrice: fixedpoint.New(big.NewInt(100000000), 8),
+	MaxPrice: fixedpoint.New(big.NewInt(100000000000000), 8),
+	MaxAge:   time.Hour,
+}
+
+func TestContractParamsBoundPrices(t *testing.T) {
+	u := newUpdater(nil, "prices.>", nil, 0.005)
+	if err := u.SetContracts(map[string]common.Address{"ETH/USD": ethUSDContract}); err != nil {
+		t.Fatalf("SetContracts() error = %v", err)
+	}
+	params := oracleParams
+	params.Decimals = 6
+	params.MinPrice = fixedpoint.New(big.NewInt(1000000), 6)
+	params.MaxPrice = fixedpoint.New(big.NewInt(5000000000), 6)
+	if err := u.SetContractParams("ETH/USD", params); err != nil {
+		t.Fatalf("SetContractParams() error = %v", err)
+	}
+
+	// Prices are submitted in the contract's decimals, and bounded by its range in units rather than by defaults
+	tests := []struct {
+		price string
+		units int64
+	}{
+		{price: "3000", units: 3000000000},
+		{price: "5000", units: 5000000000},
+		{price: "5000.000001"},
+		{price: "0.999999"},
+	}
+	for _, tt := range tests {
+		update, err := u.preparePriceUpdate([]byte(`{"pair":"ETH/USD","price":`+tt.price+`,"id":"ETH/USD-1"}`), "")
+		if err != nil {
+			t.Fatalf("preparePriceUpdate(%s) error = %v", tt.price, err)
+		}
+		if tt.units == 0 {
+			if update != nil {
+				t.Errorf("preparePriceUpdate(%s) = %+v, want it outside the contract's range", tt.price, update)
+			}
+			continue
+		}
+		if update == nil || update.price.Units().Cmp(big.NewInt(tt.units)) != 0 || update.price.Decimals() != 6 {
+			t.Errorf("preparePriceUpdate(%s) = %+v, want %d units", tt.price, update, tt.units)
+		}
+		u.setLastPrice("ETH/USD", 0)
+	}
+}
+
+func TestSetContractParamsRejectsWiderBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		minPrice float64
+		maxPrice float64
+		pairMin  float64
+		wantErr  bool
+	}{
+		{name: "contract range", wantErr: false},
+		{name: "narrower", minPrice: 100, maxPrice: 10000, wantErr: false},
+		{name: "at the contract's bounds", minPrice: 1, maxPrice: 1000000, wantErr: false},
+		{name: "max above the contract's", maxPrice: 1000000.00000001, wantErr: true},
+		{name: "min below the contract's", minPrice: 0.99999999, wantErr: true},
+		{name: "pair min below the contract's", pairMin: 0.5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newUpdater(nil, "prices.>", nil, 0.005)
+			if err := u.SetPriceBounds(tt.minPrice, tt.maxPrice); err != nil {
+				t.Fatalf("SetPriceBounds() error = %v", err)
+			}
+			if tt.pairMin != 0 {
+				u.SetPairMinPrice("ETH/USD", tt.pairMin)
+			}
+
+			err := u.SetContractParams("ETH/USD", oracleParams)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetContractParams() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package fixedpoint
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxExactDecimals is the most decimals ParseExact keeps; longer decimals are rejected rather than rounded
+const MaxExactDecimals = 36
+
+// decimalPattern matches plain decimal numbers, optionally in exponent notation, and nothing else big.Rat accepts
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// Price is an exact decimal price, held as an integer number of units of 10^-decimals
+// The zero value is a price of zero with no decimals
+type Price struct {
+	units    *big.Int
+	decimals int
+}
+
+// New creates a price of units at the given number of decimals, e.g. New(big.NewInt(199999), 2) is 1999.99
+func New(units *big.Int, decimals int) Price {
+	if units == nil {
+		units = new(big.Int)
+	}
+	return Price{
+		units:    new(big.Int).Set(units),
+		decimals: decimals,
+	}
+}
+
+// Parse reads a decimal string such as "1999.99999999" or "2.5e3", rounding half away from zero
+// to the given number of decimals
+func Parse(s string, decimals int) (Price, error) {
+	if decimals < 0 {
+		return Price{}, fmt.Errorf("decimals must not be negative, got: %d", decimals)
+	}
+
+	value, err := parseDecimal(s)
+	if err != nil {
+		return Price{}, err
+	}
+
+	return Price{
+		units:    roundRat(value, decimals),
+		decimals: decimals,
+	}, nil
+}
+
+// ParseExact reads a decimal string such as "3412.57" or "2.5e-3" at as many decimals as it needs,
+// so the price holds exactly the value written
+func ParseExact(s string) (Price, error) {
+	value, err := parseDecimal(s)
+	if err != nil {
+		return Price{}, err
+	}
+
+	// A decimal's denominator only has the factors 2 and 5, so some power of ten is a multiple of it
+	decimals := 0
+	for new(big.Int).Rem(pow10(decimals), value.Denom()).Sign() != 0 {
+		if decimals++; decimals > MaxExactDecimals {
+			return Price{}, fmt.Errorf("decimal %q has more than %d decimals", s, MaxExactDecimals)
+		}
+	}
+	return Price{
+		units:    roundRat(value, decimals),
+		decimals: decimals,
+	}, nil
+}
+
+// ParseUnits reads an integer number of units at the given number of decimals, e.g. "199999999999" at 8
+func ParseUnits(s string, decimals int) (Price, error) {
+	if decimals < 0 {
+		return Price{}, fmt.Errorf("decimals must not be negative, got: %d", decimals)
+	}
+
+	units, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return Price{}, fmt.Errorf("invalid units %q", s)
+	}
+
+	return Price{units: units, decimals: decimals}, nil
+}
+
+// FromFloat converts a float to a price at the given number of decimals
+// The float is read through its shortest decimal representation, which is the decimal it was parsed
+// from, so 1999.99999999 becomes 199999999999 units at 8 decimals rather than being truncated below it
+func FromFloat(f float64, decimals int) (Price, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Price{}, fmt.Errorf("cannot convert %f to a price", f)
+	}
+	return Parse(strconv.FormatFloat(f, 'g', -1, 64), decimals)
+}
+
+// FromFloatExact converts a float to a price holding exactly its shortest decimal representation
+func FromFloatExact(f float64) (Price, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Price{}, fmt.Errorf("cannot convert %f to a price", f)
+	}
+	return ParseExact(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// FromRat converts an exact value to a price at the given number of decimals, rounding half away from zero
+func FromRat(value *big.Rat, decimals int) (Price, error) {
+	if decimals < 0 {
+		return Price{}, fmt.Errorf("decimals must not be negative, got: %d", decimals)
+	}
+	return Price{units: roundRat(value, decimals), decimals: decimals}, nil
+}
+
+// parseDecimal reads the exact value of a decimal string
+func parseDecimal(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	value, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	return value, nil
+}
+
+// roundRat returns value scaled by 10^decimals, rounded half away from zero
+func roundRat(value *big.Rat, decimals int) *big.Int {
+	scaled := new(big.Int).Mul(value.Num(), pow10(decimals))
+	quotient, remainder := new(big.Int).QuoRem(scaled, value.Denom(), new(big.Int))
+
+	// Round away from zero when the remainder is at least half the denominator
+	if new(big.Int).Abs(new(big.Int).Lsh(remainder, 1)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return quotient
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Units returns the integer number of units of the price
+func (p Price) Units() *big.Int {
+	if p.units == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(p.units)
+}
+
+// Decimals returns the number of decimals the units are counted in
+func (p Price) Decimals() int {
+	return p.decimals
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of the price
+func (p Price) Sign() int {
+	if p.units == nil {
+		return 0
+	}
+	return p.units.Sign()
+}
+
+// Rescale returns the price at another number of decimals, rounding half away from zero when decimals are dropped
+func (p Price) Rescale(decimals int) Price {
+	if decimals >= p.decimals {
+		return Price{
+			units:    new(big.Int).Mul(p.Units(), pow10(decimals-p.decimals)),
+			decimals: decimals,
+		}
+	}
+	return Price{
+		units:    roundRat(p.Rat(), decimals),
+		decimals: decimals,
+	}
+}
+
+// Mul returns the exact product of two prices, at the sum of their decimals
+func (p Price) Mul(other Price) Price {
+	return Price{
+		units:    new(big.Int).Mul(p.Units(), other.Units()),
+		decimals: p.decimals + other.decimals,
+	}
+}
+
+// Midpoint returns the exact midpoint of two prices, needing at most one decimal more than either
+func (p Price) Midpoint(other Price) Price {
+	decimals := max(p.decimals, other.decimals)
+	sum := new(big.Int).Add(p.Rescale(decimals).units, other.Rescale(decimals).units)
+	if sum.Bit(0) == 0 {
+		return Price{units: sum.Rsh(sum, 1), decimals: decimals}
+	}
+	return Price{units: sum.Mul(sum, big.NewInt(5)), decimals: decimals + 1}
+}
+
+// Cmp compares two prices exactly, regardless of their decimals
+func (p Price) Cmp(other Price) int {
+	return p.Rat().Cmp(other.Rat())
+}
+
+// Rat returns the exact value of the price
+func (p Price) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(p.Units(), pow10(p.decimals))
+}
+
+// Float64 returns the nearest float to the price
+func (p Price) Float64() float64 {
+	f, _ := p.Rat().Float64()
+	return f
+}
+
+// String formats the price with all of its decimals, e.g. "1999.99999999"
+func (p Price) String() string {
+	return p.Rat().FloatString(p.decimals)
+}
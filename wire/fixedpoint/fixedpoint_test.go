@@ -0,0 +1,211 @@
+package fixedpoint
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseRoundsToDecimals(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals int
+		units    string
+	}{
+		{input: "1999.99999999", decimals: 8, units: "199999999999"},
+		{input: "3412.57", decimals: 8, units: "341257000000"},
+		{input: "0.000000005", decimals: 8, units: "1"},
+		{input: "0.0000000049", decimals: 8, units: "0"},
+		{input: "-0.000000005", decimals: 8, units: "-1"},
+		{input: "2.5e3", decimals: 2, units: "250000"},
+		{input: " 42 ", decimals: 0, units: "42"},
+	}
+
+	for _, tt := range tests {
+		price, err := Parse(tt.input, tt.decimals)
+		if err != nil {
+			t.Errorf("Parse(%q, %d) error = %v", tt.input, tt.decimals, err)
+			continue
+		}
+		if units := price.Units().String(); units != tt.units {
+			t.Errorf("Parse(%q, %d) units = %s, want %s", tt.input, tt.decimals, units, tt.units)
+		}
+		if price.Decimals() != tt.decimals {
+			t.Errorf("Parse(%q, %d) decimals = %d, want %d", tt.input, tt.decimals, price.Decimals(), tt.decimals)
+		}
+	}
+
+	for _, input := range []string{"", "n/a", "1.2.3", "0x10", "1_000", "1/3"} {
+		if _, err := Parse(input, 8); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", input)
+		}
+	}
+	if _, err := Parse("1", -1); err == nil {
+		t.Error("Parse() with negative decimals succeeded")
+	}
+}
+
+func TestParseExactKeepsEveryDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals int
+		value    string
+	}{
+		{input: "3412.57", decimals: 2, value: "3412.57"},
+		{input: "3412.01000000", decimals: 2, value: "3412.01"},
+		{input: "0.000000000000000001", decimals: 18, value: "0.000000000000000001"},
+		{input: "1999.99999999999999999", decimals: 17, value: "1999.99999999999999999"},
+		{input: "2.5e-3", decimals: 4, value: "0.0025"},
+		{input: "1e3", decimals: 0, value: "1000"},
+		{input: "3412", decimals: 0, value: "3412"},
+	}
+
+	for _, tt := range tests {
+		price, err := ParseExact(tt.input)
+		if err != nil {
+			t.Errorf("ParseExact(%q) error = %v", tt.input, err)
+			continue
+		}
+		if price.Decimals() != tt.decimals {
+			t.Errorf("ParseExact(%q) decimals = %d, want %d", tt.input, price.Decimals(), tt.decimals)
+		}
+		if price.String() != tt.value {
+			t.Errorf("ParseExact(%q) = %s, want %s", tt.input, price, tt.value)
+		}
+	}
+
+	for _, input := range []string{"", "price", "1/3", "1e-40"} {
+		if _, err := ParseExact(input); err == nil {
+			t.Errorf("ParseExact(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestPriceRoundTrips(t *testing.T) {
+	for _, input := range []string{"3412.57", "1999.99999999", "0.00000001", "123456789012345678901234567890.123456789"} {
+		price, err := ParseExact(input)
+		if err != nil {
+			t.Fatalf("ParseExact(%q) error = %v", input, err)
+		}
+
+		// Through the decimal string
+		reparsed, err := ParseExact(price.String())
+		if err != nil || reparsed.Cmp(price) != 0 || reparsed.String() != input {
+			t.Errorf("ParseExact(%q).String() round trip = %v, %v", input, reparsed, err)
+		}
+
+		// Through the units and decimals pushed on-chain
+		fromUnits, err := ParseUnits(price.Units().String(), price.Decimals())
+		if err != nil || fromUnits.String() != input {
+			t.Errorf("ParseUnits() round trip of %q = %v, %v", input, fromUnits, err)
+		}
+
+		// Through more decimals and back
+		if rescaled := price.Rescale(price.Decimals() + 10).Rescale(price.Decimals()); rescaled.String() != input {
+			t.Errorf("Rescale() round trip of %q = %s", input, rescaled)
+		}
+	}
+}
+
+func TestFromFloatUsesShortestDecimal(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		units    string
+	}{
+		// Truncating 1999.99999999 * 1e8 would give 199999999998
+		{value: 1999.99999999, decimals: 8, units: "199999999999"},
+		{value: 0.1, decimals: 18, units: "100000000000000000"},
+		{value: 3412.575, decimals: 2, units: "341258"},
+	}
+
+	for _, tt := range tests {
+		price, err := FromFloat(tt.value, tt.decimals)
+		if err != nil {
+			t.Fatalf("FromFloat(%v) error = %v", tt.value, err)
+		}
+		if units := price.Units().String(); units != tt.units {
+			t.Errorf("FromFloat(%v, %d) units = %s, want %s", tt.value, tt.decimals, units, tt.units)
+		}
+	}
+
+	a, b := 0.1, 0.2
+	exact, err := FromFloatExact(a + b)
+	if err != nil || exact.String() != "0.30000000000000004" {
+		t.Errorf("FromFloatExact(0.1 + 0.2) = %v, %v, want the float's shortest decimal", exact, err)
+	}
+}
+
+func TestFromRatRoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		value    *big.Rat
+		decimals int
+		units    string
+	}{
+		{value: big.NewRat(300000, 108), decimals: 8, units: "277777777778"},
+		{value: big.NewRat(1, 3), decimals: 2, units: "33"},
+		{value: big.NewRat(1, 8), decimals: 2, units: "13"},
+		{value: big.NewRat(-1, 8), decimals: 2, units: "-13"},
+	}
+
+	for _, tt := range tests {
+		price, err := FromRat(tt.value, tt.decimals)
+		if err != nil {
+			t.Fatalf("FromRat(%v) error = %v", tt.value, err)
+		}
+		if units := price.Units().String(); units != tt.units || price.Decimals() != tt.decimals {
+			t.Errorf("FromRat(%v, %d) = %s units at %d decimals, want %s", tt.value, tt.decimals, units, price.Decimals(), tt.units)
+		}
+	}
+
+	if _, err := FromRat(big.NewRat(1, 1), -1); err == nil {
+		t.Error("FromRat() with negative decimals succeeded")
+	}
+}
+
+func TestMidpointIsExact(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		midpoint string
+	}{
+		{a: "0.1", b: "0.2", midpoint: "0.15"},
+		{a: "3412.57", b: "3412.58", midpoint: "3412.575"},
+		{a: "3412.5", b: "3412.57", midpoint: "3412.535"},
+		{a: "3000", b: "3000", midpoint: "3000"},
+		{a: "0.00000001", b: "0.00000002", midpoint: "0.000000015"},
+	}
+
+	for _, tt := range tests {
+		a, _ := ParseExact(tt.a)
+		b, _ := ParseExact(tt.b)
+		if midpoint := a.Midpoint(b).String(); midpoint != tt.midpoint {
+			t.Errorf("Midpoint(%s, %s) = %s, want %s", tt.a, tt.b, midpoint, tt.midpoint)
+		}
+		if midpoint := b.Midpoint(a).String(); midpoint != tt.midpoint {
+			t.Errorf("Midpoint(%s, %s) = %s, want %s", tt.b, tt.a, midpoint, tt.midpoint)
+		}
+	}
+}
+
+func TestMulIsExact(t *testing.T) {
+	price, _ := ParseExact("3412.57")
+	rate, _ := ParseExact("0.9987")
+	if product := price.Mul(rate); product.String() != "3408.133659" {
+		t.Errorf("Mul() = %s, want 3408.133659", product)
+	}
+}
+
+func TestCmpIgnoresDecimals(t *testing.T) {
+	a := New(big.NewInt(341257), 2)
+	b := New(big.NewInt(341257000000), 8)
+	if a.Cmp(b) != 0 {
+		t.Errorf("Cmp(%s, %s) = %d, want 0", a, b, a.Cmp(b))
+	}
+	if c := New(big.NewInt(341258), 2); a.Cmp(c) >= 0 {
+		t.Errorf("Cmp(%s, %s) = %d, want -1", a, c, a.Cmp(c))
+	}
+
+	var zero Price
+	if zero.Sign() != 0 || zero.Units().Sign() != 0 || zero.String() != "0" {
+		t.Errorf("zero Price = %s, want 0", zero)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/114windd/DeFiOraclePipeline.git/wire
+
+go 1.23
+
+require github.com/fxamacker/cbor/v2 v2.9.4
+
+require github.com/x448/float16 v0.8.4 // indirect
@@ -0,0 +1,126 @@
+package wire
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signingDomain prefixes every signed payload, so a signature over a price message cannot be
+// reused for anything else; v2 signs every field consumers act on
+const signingDomain = "DeFiOraclePipeline/price/v2"
+
+const (
+	// ReasonUnsigned rejects messages without a signature
+	ReasonUnsigned = "unsigned"
+	// ReasonUnknownKey rejects messages signed by a key outside the allow-list
+	ReasonUnknownKey = "unknown_key"
+	// ReasonBadSignature rejects messages whose signature does not match their content
+	ReasonBadSignature = "bad_signature"
+)
+
+// SignatureError explains why the signature of a message was not accepted
+type SignatureError struct {
+	Reason string
+	Detail string
+}
+
+// Error returns the detail of the rejection
+func (e *SignatureError) Error() string {
+	return e.Detail
+}
+
+// SigningPayload returns the canonical bytes a signature covers: every field of the message except the
+// schema version, which only describes its encoding, and the signature itself
+// Each field is length-prefixed, so no two messages share a payload
+func (m PriceMessage) SigningPayload() []byte {
+	fields := []string{
+		signingDomain,
+		m.Pair,
+		formatFloat(m.Price),
+		m.PriceUnits,
+		strconv.Itoa(m.Decimals),
+		formatTime(m.Timestamp),
+		formatTime(m.ObservedAt),
+		m.Source,
+		strconv.FormatBool(m.Derived),
+		m.ID,
+		strconv.FormatUint(uint64(m.RecordID), 10),
+		formatFloat(m.ConfidenceLower),
+		formatFloat(m.ConfidenceUpper),
+		formatFloat(m.StdError),
+		m.SignerKey,
+	}
+
+	var payload []byte
+	for _, field := range fields {
+		payload = binary.AppendUvarint(payload, uint64(len(field)))
+		payload = append(payload, field...)
+	}
+	return payload
+}
+
+// formatFloat writes f in its shortest form, which both encodings round-trip
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// formatTime writes t in nanoseconds since the epoch, and the zero time as an empty field
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Sign signs message with key, recording the hex public key it can be verified with
+func Sign(message *PriceMessage, key ed25519.PrivateKey) {
+	message.SignerKey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	message.Signature = ed25519.Sign(key, message.SigningPayload())
+}
+
+// ParsePrivateKey reads an ed25519 private key from its 32-byte hex seed
+func ParsePrivateKey(seed string) (ed25519.PrivateKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(seed), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be a %d-byte seed, got %d bytes", ed25519.SeedSize, len(raw))
+	}
+	return ed25519.NewKeyFromSeed(raw), nil
+}
+
+// ParsePublicKey reads an ed25519 public key from hex
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(key), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Verify checks that message was signed by one of keys, indexed by their hex encoding
+// It returns nil when the signature is valid, and a rejection when the message is unsigned, signed by
+// another key or tampered with
+func Verify(message PriceMessage, keys map[string]ed25519.PublicKey) *SignatureError {
+	if len(message.Signature) == 0 {
+		return &SignatureError{Reason: ReasonUnsigned, Detail: "message is not signed"}
+	}
+
+	key, ok := keys[strings.ToLower(message.SignerKey)]
+	if !ok {
+		return &SignatureError{Reason: ReasonUnknownKey, Detail: fmt.Sprintf("signer key %q is not allowed", message.SignerKey)}
+	}
+	if !ed25519.Verify(key, message.SigningPayload(), message.Signature) {
+		return &SignatureError{Reason: ReasonBadSignature, Detail: fmt.Sprintf("signature does not match the message signed by %s", message.SignerKey)}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package wire
+
+import "strings"
+
+// SubjectCovers reports whether every subject matching subject also matches pattern, with NATS wildcards:
+// "*" matches one token and ">" one or more trailing tokens, so "prices.>" covers "prices.*" and "prices.ETH.USD"
+func SubjectCovers(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range subjectTokens {
+		if i >= len(patternTokens) {
+			return false
+		}
+		switch patternTokens[i] {
+		case ">":
+			return true
+		case "*":
+			// Only ">" covers the several tokens a ">" in the subject stands for
+			if token == ">" {
+				return false
+			}
+		default:
+			if token != patternTokens[i] {
+				return false
+			}
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -0,0 +1,31 @@
+package wire
+
+import "testing"
+
+func TestSubjectCovers(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		covers  bool
+	}{
+		{pattern: "prices.ethusd", subject: "prices.ethusd", covers: true},
+		{pattern: "prices.*", subject: "prices.ethusd", covers: true},
+		{pattern: "prices.>", subject: "prices.ethusd", covers: true},
+		{pattern: "prices.>", subject: "prices.*", covers: true},
+		{pattern: "prices.>", subject: "prices.eth.usd", covers: true},
+		{pattern: ">", subject: "prices.*", covers: true},
+		{pattern: "prices.*", subject: "prices.*", covers: true},
+		{pattern: "prices.*", subject: "prices.>", covers: false},
+		{pattern: "prices.*", subject: "prices.eth.usd", covers: false},
+		{pattern: "prices.ethusd", subject: "prices.*", covers: false},
+		{pattern: "prices.>", subject: "prices", covers: false},
+		{pattern: "prices.btcusd", subject: "prices.ethusd", covers: false},
+		{pattern: "other.>", subject: "prices.ethusd", covers: false},
+	}
+
+	for _, tt := range tests {
+		if covers := SubjectCovers(tt.pattern, tt.subject); covers != tt.covers {
+			t.Errorf("SubjectCovers(%q, %q) = %v, want %v", tt.pattern, tt.subject, covers, tt.covers)
+		}
+	}
+}
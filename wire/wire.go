@@ -0,0 +1,186 @@
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/114windd/DeFiOraclePipeline.git/wire/fixedpoint"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// SchemaVersion is the version of PriceMessage written by this package
+// Bump it when a change cannot be read correctly by older consumers
+const SchemaVersion = 1
+
+const (
+	// ContentTypeHeader names the NATS header carrying the encoding of a message
+	ContentTypeHeader = "Content-Type"
+	// SchemaVersionHeader names the NATS header carrying the schema version of a message
+	SchemaVersionHeader = "Oracle-Schema-Version"
+
+	// ContentTypeJSON is the content type of JSON messages, and of legacy messages sent without one
+	ContentTypeJSON = "application/json"
+	// ContentTypeCBOR is the content type of CBOR messages
+	ContentTypeCBOR = "application/cbor"
+)
+
+// Encoding is a wire encoding of price messages
+type Encoding string
+
+const (
+	// EncodingJSON encodes messages as JSON, readable by consumers that predate the schema version
+	EncodingJSON Encoding = "json"
+	// EncodingCBOR encodes messages as CBOR with integer keys
+	EncodingCBOR Encoding = "cbor"
+)
+
+// ParseEncoding parses the name of an encoding
+func ParseEncoding(name string) (Encoding, error) {
+	switch encoding := Encoding(strings.ToLower(strings.TrimSpace(name))); encoding {
+	case EncodingJSON, EncodingCBOR:
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q", name)
+}
+
+// ContentType returns the content type messages in the encoding are sent with
+func (e Encoding) ContentType() string {
+	if e == EncodingCBOR {
+		return ContentTypeCBOR
+	}
+	return ContentTypeJSON
+}
+
+// PriceMessage is a price published to NATS
+// CBOR keys are integers and must never be reused for another field
+type PriceMessage struct {
+	// Version is the schema version the message was written in; zero marks legacy JSON without one
+	Version   int       `json:"version,omitempty" cbor:"1,keyasint,omitempty"`
+	Pair      string    `json:"pair,omitempty" cbor:"2,keyasint,omitempty"`
+	Price     float64   `json:"price" cbor:"3,keyasint"`
+	Timestamp time.Time `json:"timestamp" cbor:"4,keyasint"`
+	Source    string    `json:"source" cbor:"5,keyasint"`
+	ID        string    `json:"id" cbor:"6,keyasint"`
+	// PriceUnits is the exact price as an integer number of units of 10^-Decimals, which Price approximates
+	PriceUnits string `json:"price_units,omitempty" cbor:"7,keyasint,omitempty"`
+	Decimals   int    `json:"decimals,omitempty" cbor:"8,keyasint,omitempty"`
+	// Derived marks cross rates computed from other pairs rather than fetched from sources
+	Derived bool `json:"derived,omitempty" cbor:"9,keyasint,omitempty"`
+	// ObservedAt is when the providers observed the price, while Timestamp is when it was fetched
+	ObservedAt time.Time `json:"observed_at" cbor:"10,keyasint"`
+	// RecordID is the stored price record, whose inputs are served at /price/{id}/derivation
+	RecordID uint `json:"record_id,omitempty" cbor:"11,keyasint,omitempty"`
+	// ConfidenceLower and ConfidenceUpper bound the price and StdError is the standard error of its quotes
+	ConfidenceLower float64 `json:"confidence_lower,omitempty" cbor:"12,keyasint,omitempty"`
+	ConfidenceUpper float64 `json:"confidence_upper,omitempty" cbor:"13,keyasint,omitempty"`
+	StdError        float64 `json:"std_error,omitempty" cbor:"14,keyasint,omitempty"`
+	// SignerKey is the hex ed25519 public key of the publisher and Signature its signature over SigningPayload
+	SignerKey string `json:"signer_key,omitempty" cbor:"15,keyasint,omitempty"`
+	Signature []byte `json:"signature,omitempty" cbor:"16,keyasint,omitempty"`
+}
+
+// ConfidenceWidth returns the width of the confidence band relative to the price
+// It reports false for messages published before prices carried a band
+func (m PriceMessage) ConfidenceWidth() (float64, bool) {
+	if m.ConfidenceUpper <= 0 || m.Price <= 0 {
+		return 0, false
+	}
+	return (m.ConfidenceUpper - m.ConfidenceLower) / m.Price, true
+}
+
+// FixedPrice returns the price at the given contract decimals
+// Messages published before prices carried units are converted from the shortest decimal form of Price,
+// which is the decimal the publisher rounded it to
+func (m PriceMessage) FixedPrice(decimals int) (fixedpoint.Price, error) {
+	if m.PriceUnits == "" {
+		return fixedpoint.FromFloat(m.Price, decimals)
+	}
+
+	if m.Decimals != decimals {
+		return fixedpoint.Price{}, fmt.Errorf("price has %d decimals, the contract expects %d", m.Decimals, decimals)
+	}
+	return fixedpoint.ParseUnits(m.PriceUnits, m.Decimals)
+}
+
+// UnsupportedVersionError rejects a message written in a schema version newer than this package reads
+type UnsupportedVersionError struct {
+	Version int
+}
+
+// Error describes the unsupported version
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported schema version %d, this consumer reads up to %d", e.Version, SchemaVersion)
+}
+
+// encMode writes times with nanoseconds, as JSON does, so both encodings carry the same message
+var encMode = mustEncMode(cbor.EncOptions{Time: cbor.TimeRFC3339Nano})
+
+// mustEncMode builds a CBOR encoding mode from options known to be valid
+func mustEncMode(options cbor.EncOptions) cbor.EncMode {
+	mode, err := options.EncMode()
+	if err != nil {
+		panic(fmt.Sprintf("invalid CBOR encoding options: %v", err))
+	}
+	return mode
+}
+
+// Encode writes message in encoding at the current schema version
+func Encode(message PriceMessage, encoding Encoding) ([]byte, error) {
+	message.Version = SchemaVersion
+
+	switch encoding {
+	case EncodingJSON:
+		data, err := json.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal price message as JSON: %w", err)
+		}
+		return data, nil
+	case EncodingCBOR:
+		data, err := encMode.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal price message as CBOR: %w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+// Decode reads a message sent with contentType, where an empty content type is legacy JSON
+// Messages in a schema version newer than SchemaVersion are rejected with an UnsupportedVersionError
+func Decode(data []byte, contentType string) (PriceMessage, error) {
+	var message PriceMessage
+
+	mediaType := ContentTypeJSON
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return PriceMessage{}, fmt.Errorf("invalid content type %q: %w", contentType, err)
+		}
+		mediaType = parsed
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		if err := json.Unmarshal(data, &message); err != nil {
+			return PriceMessage{}, fmt.Errorf("failed to unmarshal JSON price message: %w", err)
+		}
+	case ContentTypeCBOR:
+		if err := cbor.Unmarshal(data, &message); err != nil {
+			return PriceMessage{}, fmt.Errorf("failed to unmarshal CBOR price message: %w", err)
+		}
+		// CBOR messages were versioned from the start, so a missing version means a malformed message
+		if message.Version < 1 {
+			return PriceMessage{}, fmt.Errorf("CBOR price message has no schema version")
+		}
+	default:
+		return PriceMessage{}, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	if message.Version < 0 || message.Version > SchemaVersion {
+		return PriceMessage{}, &UnsupportedVersionError{Version: message.Version}
+	}
+	return message, nil
+}
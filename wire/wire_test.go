@@ -0,0 +1,167 @@
+package wire
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testMessage is a fully populated price message with a nanosecond timestamp
+func testMessage() PriceMessage {
+	timestamp := time.Date(2024, 6, 10, 16, 0, 0, 123456789, time.UTC)
+	return PriceMessage{
+		Pair:            "ETH/USD",
+		Price:           3412.57,
+		Timestamp:       timestamp,
+		Source:          "aggregated",
+		ID:              "ETHUSD-1718035200123456789",
+		PriceUnits:      "341257000000",
+		Decimals:        8,
+		ObservedAt:      timestamp.Add(-1500 * time.Millisecond),
+		RecordID:        42,
+		ConfidenceLower: 3411.9,
+		ConfidenceUpper: 3413.2,
+		StdError:        0.31,
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	for _, encoding := range []Encoding{EncodingJSON, EncodingCBOR} {
+		t.Run(string(encoding), func(t *testing.T) {
+			message := testMessage()
+			data, err := Encode(message, encoding)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			decoded, err := Decode(data, encoding.ContentType())
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			// Encoding stamps the current schema version
+			message.Version = SchemaVersion
+			if !reflect.DeepEqual(decoded, message) {
+				t.Errorf("Decode() = %+v, want %+v", decoded, message)
+			}
+			if !decoded.Timestamp.Equal(message.Timestamp) || decoded.Timestamp.Nanosecond() != 123456789 {
+				t.Errorf("Decode() timestamp = %v, want nanoseconds kept", decoded.Timestamp)
+			}
+
+			price, err := decoded.FixedPrice(8)
+			if err != nil {
+				t.Fatalf("FixedPrice() error = %v", err)
+			}
+			if price.String() != "3412.57000000" {
+				t.Errorf("FixedPrice() = %s, want 3412.57000000", price)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyJSON(t *testing.T) {
+	// Messages from publishers that predate the schema version carry neither a version nor a content type
+	data := []byte(`{"price":3412.57,"timestamp":"2024-06-10T16:00:00.123456789Z","source":"coingecko","id":"1718035200123456789"}`)
+
+	message, err := Decode(data, "")
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if message.Version != 0 || message.Price != 3412.57 || message.Pair != "" {
+		t.Errorf("Decode() = %+v, want a version 0 message without a pair", message)
+	}
+
+	// Without units the price is read from the shortest decimal of the float
+	price, err := message.FixedPrice(8)
+	if err != nil || price.Units().String() != "341257000000" {
+		t.Errorf("FixedPrice() = %v, %v, want 341257000000 units", price, err)
+	}
+}
+
+func TestDecodeRejectsInvalidMessages(t *testing.T) {
+	future := testMessage()
+	future.Version = SchemaVersion + 1
+	futureJSON := mustMarshalJSON(t, future)
+	futureCBOR, err := encMode.Marshal(future)
+	if err != nil {
+		t.Fatalf("failed to marshal CBOR: %v", err)
+	}
+
+	unversioned := testMessage()
+	unversionedCBOR, err := encMode.Marshal(unversioned)
+	if err != nil {
+		t.Fatalf("failed to marshal CBOR: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		// unsupported is the version an UnsupportedVersionError must report, zero for other errors
+		unsupported int
+	}{
+		{name: "newer JSON version", data: futureJSON, contentType: ContentTypeJSON, unsupported: SchemaVersion + 1},
+		{name: "newer CBOR version", data: futureCBOR, contentType: ContentTypeCBOR, unsupported: SchemaVersion + 1},
+		{name: "CBOR without a version", data: unversionedCBOR, contentType: ContentTypeCBOR},
+		{name: "unknown content type", data: mustMarshalJSON(t, testMessage()), contentType: "application/xml"},
+		{name: "malformed content type", data: mustMarshalJSON(t, testMessage()), contentType: "application/"},
+		{name: "malformed JSON", data: []byte(`{"price":`), contentType: ContentTypeJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data, tt.contentType)
+			if err == nil {
+				t.Fatal("Decode() succeeded, want an error")
+			}
+
+			var versionErr *UnsupportedVersionError
+			isVersionErr := errors.As(err, &versionErr)
+			if tt.unsupported != 0 && (!isVersionErr || versionErr.Version != tt.unsupported) {
+				t.Errorf("Decode() error = %v, want an UnsupportedVersionError for version %d", err, tt.unsupported)
+			}
+			if tt.unsupported == 0 && isVersionErr {
+				t.Errorf("Decode() error = %v, want an error other than an unsupported version", err)
+			}
+		})
+	}
+}
+
+func TestDecodeAcceptsContentTypeParameters(t *testing.T) {
+	data, err := Encode(testMessage(), EncodingJSON)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if _, err := Decode(data, "application/json; charset=utf-8"); err != nil {
+		t.Errorf("Decode() error = %v", err)
+	}
+}
+
+func TestFixedPriceRejectsOtherDecimals(t *testing.T) {
+	if _, err := testMessage().FixedPrice(6); err == nil {
+		t.Error("FixedPrice(6) of an 8-decimal price succeeded")
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for name, want := range map[string]Encoding{"json": EncodingJSON, " CBOR ": EncodingCBOR} {
+		if encoding, err := ParseEncoding(name); err != nil || encoding != want {
+			t.Errorf("ParseEncoding(%q) = %q, %v, want %q", name, encoding, err, want)
+		}
+	}
+	if _, err := ParseEncoding("msgpack"); err == nil {
+		t.Error("ParseEncoding(msgpack) succeeded")
+	}
+}
+
+// mustMarshalJSON encodes message as JSON without stamping a version
+func mustMarshalJSON(t *testing.T, message PriceMessage) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %v", err)
+	}
+	return data
+}